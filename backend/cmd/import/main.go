@@ -0,0 +1,197 @@
+// Command import loads quotes from a chat export into a group.
+//
+// Run it without -select to list the messages in the export, then again with
+// the IDs of the messages to turn into quotes:
+//
+//	go run ./cmd/import -group friends -email me@example.com -file chat.zip
+//	go run ./cmd/import -group friends -email me@example.com -file chat.zip -select 4,17,30 -map "Mum=Mum;+44 7700 900123=Dave"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/jamoowen/reminiscer/internal/config"
+	"github.com/jamoowen/reminiscer/internal/database"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/importer"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+func main() {
+	groupID := flag.String("group", "", "group ID to import into")
+	email := flag.String("email", "", "email of the member doing the import")
+	file := flag.String("file", "", "path to the chat export (.txt, .zip or .json)")
+	format := flag.String("format", "whatsapp", "chat export format: whatsapp, telegram or discord")
+	dateOrder := flag.String("date-order", "", "dmy, mdy or ymd; detected from the export if empty")
+	selection := flag.String("select", "", "comma separated message IDs to import, or \"all\"")
+	senderMap := flag.String("map", "", "semicolon separated Sender=Author pairs")
+	dryRun := flag.Bool("dry-run", false, "validate the selection without importing")
+	allowDuplicates := flag.Bool("allow-duplicates", false, "import messages that look like existing quotes")
+	flag.Parse()
+
+	if *groupID == "" || *email == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := database.New(cfg.Database.Path)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate("./migrations"); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	store := models.NewSQLiteStore(db.DB)
+
+	user, err := store.Users().GetByEmail(*email)
+	if err != nil || user == nil {
+		log.Fatalf("User %s not found", *email)
+	}
+
+	groups, err := store.Groups().GetByGroupID(*groupID)
+	if err != nil {
+		log.Fatalf("Group %s not found", *groupID)
+	}
+
+	isMember := false
+	for _, g := range groups {
+		if g.MemberID == user.ID {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		log.Fatalf("%s is not a member of group %s", *email, *groupID)
+	}
+
+	parser, err := importer.NewChatParser(*format, importer.ChatOptions{DateOrder: importer.DateOrder(*dateOrder)})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open export: %v", err)
+	}
+	data, err := importer.ReadChatExport(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loc, err := time.LoadLocation(groups[0].Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	messages, err := parser.Parse(data, loc)
+	if err != nil {
+		log.Fatalf("Failed to parse export: %v", err)
+	}
+
+	if *selection == "" {
+		printPreview(messages)
+		return
+	}
+
+	ids, err := parseSelection(*selection, messages)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows, rowErrors := importer.SelectMessages(messages, ids, parseSenderMap(*senderMap))
+
+	result, err := importer.Run(store.Quotes(), rows, rowErrors, importer.Options{
+		GroupID:         *groupID,
+		UploaderID:      user.ID,
+		Source:          parser.Name(),
+		DryRun:          *dryRun,
+		SkipInvalid:     true,
+		AllowDuplicates: *allowDuplicates,
+	})
+	if result != nil {
+		for _, e := range result.Errors {
+			fmt.Printf("skipping message %d: %s\n", e.Row, e.Message)
+		}
+	}
+	if err != nil {
+		log.Fatal(errors.GetMessage(err))
+	}
+
+	if *dryRun {
+		fmt.Printf("%d of %d selected messages would be imported\n", len(result.Quotes), len(ids))
+		return
+	}
+
+	fmt.Printf("Imported %d quotes (import %s)\n", result.Import.QuoteCount, result.Import.ID)
+}
+
+// printPreview lists the senders and messages in an export
+func printPreview(messages []importer.Message) {
+	fmt.Println("Senders:")
+	for _, s := range importer.Senders(messages) {
+		fmt.Printf("  %s (%d messages)\n", s.Name, s.Messages)
+	}
+
+	fmt.Println("Messages:")
+	for _, m := range messages {
+		if m.Media {
+			continue
+		}
+		text := strings.ReplaceAll(m.Text, "\n", " / ")
+		if m.ReplyTo != 0 {
+			text = fmt.Sprintf("(reply to %d) %s", m.ReplyTo, text)
+		}
+		fmt.Printf("%6d  %s  %s: %s\n", m.ID, m.Timestamp.Format("2006-01-02 15:04"), m.Sender, text)
+	}
+}
+
+// parseSelection reads the -select flag into message IDs
+func parseSelection(selection string, messages []importer.Message) ([]int, error) {
+	if selection == "all" {
+		ids := make([]int, 0, len(messages))
+		for _, m := range messages {
+			if !m.Media {
+				ids = append(ids, m.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	var ids []int
+	for _, part := range strings.Split(selection, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid message ID %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseSenderMap reads the -map flag into a sender mapping
+func parseSenderMap(value string) importer.SenderMapping {
+	mapping := make(importer.SenderMapping)
+	for _, pair := range strings.Split(value, ";") {
+		sender, author, ok := strings.Cut(pair, "=")
+		if ok {
+			mapping[strings.TrimSpace(sender)] = strings.TrimSpace(author)
+		}
+	}
+	return mapping
+}
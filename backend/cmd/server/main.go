@@ -1,18 +1,33 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	_ "time/tzdata"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/jamoowen/reminiscer/internal/activity"
+	"github.com/jamoowen/reminiscer/internal/apns"
+	"github.com/jamoowen/reminiscer/internal/capsule"
 	"github.com/jamoowen/reminiscer/internal/config"
 	"github.com/jamoowen/reminiscer/internal/database"
+	"github.com/jamoowen/reminiscer/internal/digest"
+	"github.com/jamoowen/reminiscer/internal/events"
 	"github.com/jamoowen/reminiscer/internal/handlers"
+	"github.com/jamoowen/reminiscer/internal/mail"
 	"github.com/jamoowen/reminiscer/internal/middleware"
 	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/notify"
+	"github.com/jamoowen/reminiscer/internal/push"
+	"github.com/jamoowen/reminiscer/internal/realtime"
+	"github.com/jamoowen/reminiscer/internal/slack"
+	"github.com/jamoowen/reminiscer/internal/telegram"
+	"github.com/jamoowen/reminiscer/internal/webhook"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	"golang.org/x/time/rate"
@@ -80,23 +95,154 @@ func main() {
 	fmt.Print("Initializing auth middleware")
 	authMid := middleware.NewAuthMiddleware(cfg, store)
 
+	// Notify group members about what happens in their groups
+	bus := events.NewBus()
+	notifier := notify.New(store)
+	bus.Subscribe(notifier.Handle)
+
+	// Deliver group events to the webhooks groups subscribed. Development allows local receivers.
+	dispatcher := webhook.NewDispatcher(store, webhook.NewClient(cfg.IsDevelopment()), webhook.DefaultInterval)
+	bus.Subscribe(dispatcher.Handle)
+
+	// Stream group events to connected clients
+	hub := realtime.NewHub(store, realtime.DefaultHistory)
+	bus.Subscribe(hub.Handle)
+
+	// Keep an activity log for each group's feed
+	bus.Subscribe(activity.NewRecorder(store.Activity()).Handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Push notifications to iOS devices when APNs is configured
+	if cfg.APNs.Enabled() {
+		apnsClient, err := apns.NewClient(apns.Config{
+			KeyPath:  cfg.APNs.KeyPath,
+			KeyID:    cfg.APNs.KeyID,
+			TeamID:   cfg.APNs.TeamID,
+			Topic:    cfg.APNs.Topic,
+			Endpoint: cfg.APNs.Endpoint,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize APNs client: %v", err)
+		}
+		pusher := push.New(store, apnsClient)
+		notifier.OnNotify(func(n *models.Notification) {
+			go pusher.Push(ctx, n)
+		})
+	}
+
 	// Initialize handlers
 	fmt.Print("Initializing handlers")
 	authHandler := handlers.NewAuthHandler(store, authMid)
-	quoteHandler := handlers.NewQuoteHandler(store, authMid)
-	groupHandler := handlers.NewGroupHandler(store, authMid)
+	quoteHandler := handlers.NewQuoteHandler(store, authMid, bus)
+	groupHandler := handlers.NewGroupHandler(store, authMid, bus)
+	dailyQuoteHandler := handlers.NewDailyQuoteHandler(store, authMid)
+	statsHandler := handlers.NewStatsHandler(store, authMid)
+	wrappedHandler := handlers.NewWrappedHandler(store, authMid)
+	importHandler := handlers.NewImportHandler(store, authMid)
+	exportHandler := handlers.NewExportHandler(store, authMid)
+	cardHandler := handlers.NewCardHandler(store, authMid)
+	shareHandler := handlers.NewShareHandler(store, authMid, cfg.Server.PublicURL)
+	quizHandler := handlers.NewQuizHandler(store, authMid)
+	reactionHandler := handlers.NewReactionHandler(store, authMid, bus)
+	reminisceHandler := handlers.NewReminisceHandler(store, authMid)
+	notificationHandler := handlers.NewNotificationHandler(store, authMid)
+	deviceHandler := handlers.NewDeviceHandler(store, authMid)
+	digestHandler := handlers.NewDigestHandler(store, authMid)
+	webhookHandler := handlers.NewWebhookHandler(store, authMid, dispatcher, cfg.IsDevelopment())
+	streamHandler := handlers.NewStreamHandler(store, authMid, hub)
+	activityHandler := handlers.NewActivityHandler(store, authMid)
+	chatHandler := handlers.NewChatHandler(store, authMid)
+	feedHandler := handlers.NewFeedHandler(store, authMid, cfg.Server.PublicURL)
 
 	// Set up routes
 	fmt.Print("Setting up routes")
 	authHandler.SetupRoutes(e)
 	quoteHandler.SetupRoutes(e)
 	groupHandler.SetupRoutes(e)
+	dailyQuoteHandler.SetupRoutes(e)
+	statsHandler.SetupRoutes(e)
+	wrappedHandler.SetupRoutes(e)
+	importHandler.SetupRoutes(e)
+	exportHandler.SetupRoutes(e)
+	cardHandler.SetupRoutes(e)
+	shareHandler.SetupRoutes(e)
+	quizHandler.SetupRoutes(e)
+	reactionHandler.SetupRoutes(e)
+	reminisceHandler.SetupRoutes(e)
+	notificationHandler.SetupRoutes(e)
+	deviceHandler.SetupRoutes(e)
+	digestHandler.SetupRoutes(e)
+	webhookHandler.SetupRoutes(e)
+	streamHandler.SetupRoutes(e)
+	activityHandler.SetupRoutes(e)
+	chatHandler.SetupRoutes(e)
+	feedHandler.SetupRoutes(e)
+
+	// Take quotes from Slack when the app is configured
+	if cfg.Slack.Enabled() {
+		slackHandler := handlers.NewSlackHandler(store, bus, &slack.Verifier{Secret: cfg.Slack.SigningSecret}, nil)
+		slackHandler.SetupRoutes(e)
+	}
+
+	// Take quotes by email when a mail relay is configured
+	if cfg.Inbound.Enabled() {
+		inboundHandler := handlers.NewInboundHandler(store, authMid, bus, cfg.Inbound.Secret, cfg.Inbound.Domain)
+		inboundHandler.SetupRoutes(e)
+	}
+
+	// Take quotes from Telegram chats when the bot is configured
+	if cfg.Telegram.Enabled() {
+		if cfg.Telegram.WebhookSecret == "" {
+			log.Fatalf("TELEGRAM_WEBHOOK_SECRET is required when TELEGRAM_BOT_TOKEN is set")
+		}
+		telegramClient := telegram.NewClient(cfg.Telegram.BotToken, cfg.Telegram.APIURL, nil)
+		telegramHandler := handlers.NewTelegramHandler(store, bus, telegramClient, cfg.Telegram.WebhookSecret)
+		telegramHandler.SetupRoutes(e)
+
+		if cfg.Server.PublicURL != "" {
+			go func() {
+				webhookURL := strings.TrimSuffix(cfg.Server.PublicURL, "/") + "/telegram/webhook"
+				if err := telegramClient.SetWebhook(ctx, webhookURL, cfg.Telegram.WebhookSecret); err != nil {
+					log.Printf("Failed to register Telegram webhook: %v", err)
+				}
+			}()
+		}
+	}
+
+	// Open time capsules as they unlock
+	capsuleWatcher := capsule.NewWatcher(store.Quotes(), capsule.DefaultInterval)
+	capsuleWatcher.OnUnlock(func(q *models.Quote) {
+		bus.Publish(events.Event{
+			Type:    events.CapsuleUnlocked,
+			GroupID: q.GroupID,
+			QuoteID: q.ID,
+		})
+	})
+	go capsuleWatcher.Run(ctx)
+
+	// Send queued webhook deliveries, retrying failures with backoff
+	go dispatcher.Run(ctx)
+
+	// Email weekly digests when SMTP is configured
+	if cfg.Mail.Enabled() {
+		baseURL := cfg.Server.PublicURL
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("http://localhost:%s", cfg.Server.Port)
+		}
+		transport := mail.NewSMTPTransport(cfg.Mail.SMTPAddr, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword)
+		digestScheduler := digest.NewScheduler(store, transport, cfg.Mail.From, baseURL, digest.DefaultInterval)
+		go digestScheduler.Run(ctx)
+	}
 
 	// Graceful shutdown
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
+		cancel()
+		hub.Close()
 
 		if err := e.Shutdown(nil); err != nil {
 			log.Printf("Error during server shutdown: %v", err)
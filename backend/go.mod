@@ -3,6 +3,7 @@ module github.com/jamoowen/reminiscer
 go 1.21
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-playground/validator/v10 v10.19.0
 	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/google/uuid v1.6.0
@@ -10,6 +11,10 @@ require (
 	github.com/labstack/echo/v4 v4.11.4
 	github.com/mattn/go-sqlite3 v1.14.22
 	golang.org/x/crypto v0.21.0
+	golang.org/x/image v0.18.0
+	golang.org/x/net v0.22.0
+	golang.org/x/text v0.16.0
+	golang.org/x/time v0.5.0
 )
 
 require (
@@ -23,8 +28,5 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
-	golang.org/x/net v0.22.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 )
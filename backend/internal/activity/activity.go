@@ -0,0 +1,62 @@
+// Package activity records what happens in groups for their activity feeds
+package activity
+
+import (
+	"log"
+
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// Activity types shown in the feed
+const (
+	TypeGroupCreated    = "group.created"
+	TypeGroupRenamed    = "group.renamed"
+	TypeMemberJoined    = "member.joined"
+	TypeQuoteCreated    = "quote.created"
+	TypeQuoteUpdated    = "quote.updated"
+	TypeReactionAdded   = "reaction.added"
+	TypeCapsuleUnlocked = "capsule.unlocked"
+)
+
+// activityTypes maps the events that belong in the feed to their activity types
+var activityTypes = map[events.Type]string{
+	events.GroupCreated:    TypeGroupCreated,
+	events.GroupRenamed:    TypeGroupRenamed,
+	events.GroupInvite:     TypeMemberJoined,
+	events.QuoteCreated:    TypeQuoteCreated,
+	events.QuoteUpdated:    TypeQuoteUpdated,
+	events.ReactionAdded:   TypeReactionAdded,
+	events.CapsuleUnlocked: TypeCapsuleUnlocked,
+}
+
+// Recorder appends events to the activity log
+type Recorder struct {
+	store models.ActivityStore
+}
+
+// NewRecorder creates a recorder writing to store
+func NewRecorder(store models.ActivityStore) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Handle records an event if it belongs in the feed. It is meant to be subscribed to an events.Bus.
+func (r *Recorder) Handle(e events.Event) {
+	activityType, ok := activityTypes[e.Type]
+	if !ok {
+		return
+	}
+
+	err := r.store.Append(&models.Activity{
+		GroupID: e.GroupID,
+		Type:    activityType,
+		ActorID: e.ActorID,
+		QuoteID: e.QuoteID,
+		UserID:  e.UserID,
+		Emoji:   e.Emoji,
+		Name:    e.Name,
+	})
+	if err != nil {
+		log.Printf("Failed to record %s in group %s: %v", activityType, e.GroupID, err)
+	}
+}
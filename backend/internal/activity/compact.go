@@ -0,0 +1,70 @@
+package activity
+
+import "github.com/jamoowen/reminiscer/internal/models"
+
+// TypeReactions is a compacted run of reactions to one quote
+const TypeReactions = "reactions"
+
+// Entry is an item of the feed, either one activity or several reactions to a quote folded together
+type Entry struct {
+	*models.Activity          // The newest activity the entry covers
+	Count            int      // How many activities the entry covers
+	ActorIDs         []string // Distinct actors, newest first
+	Emojis           []string // Distinct reactions, newest first
+}
+
+// Compact folds every reaction to the same quote in a page of activity (newest first) into
+// one entry at the position of the newest, so the feed reads "N new reactions" instead of
+// listing each one
+func Compact(activities []*models.Activity) []*Entry {
+	entries := make([]*Entry, 0, len(activities))
+	reactions := make(map[string]*Entry)
+
+	for _, a := range activities {
+		if a.Type != TypeReactionAdded || a.QuoteID == "" {
+			entries = append(entries, &Entry{Activity: a, Count: 1, ActorIDs: nonEmpty(a.ActorID)})
+			continue
+		}
+
+		entry, ok := reactions[a.QuoteID]
+		if !ok {
+			entry = &Entry{Activity: a, Count: 1, ActorIDs: nonEmpty(a.ActorID), Emojis: nonEmpty(a.Emoji)}
+			reactions[a.QuoteID] = entry
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Count++
+		entry.ActorIDs = appendDistinct(entry.ActorIDs, a.ActorID)
+		entry.Emojis = appendDistinct(entry.Emojis, a.Emoji)
+	}
+
+	return entries
+}
+
+// FeedType is the entry's activity type, TypeReactions when several reactions were folded together
+func (e *Entry) FeedType() string {
+	if e.Count > 1 && e.Activity.Type == TypeReactionAdded {
+		return TypeReactions
+	}
+	return e.Activity.Type
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func appendDistinct(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
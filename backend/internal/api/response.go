@@ -12,9 +12,10 @@ type Response struct {
 
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
-	Success bool   `json:"success"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Success bool        `json:"success"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // SendSuccess sends a success response
@@ -33,3 +34,13 @@ func SendError(c echo.Context, status int, code string, message string) error {
 		Message: message,
 	})
 }
+
+// SendErrorWithData sends an error response carrying extra details
+func SendErrorWithData(c echo.Context, status int, code string, message string, data interface{}) error {
+	return c.JSON(status, &ErrorResponse{
+		Success: false,
+		Code:    code,
+		Message: message,
+		Data:    data,
+	})
+}
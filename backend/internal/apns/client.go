@@ -0,0 +1,241 @@
+// Package apns sends push notifications through Apple's HTTP/2 provider API
+package apns
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// tokenLifetime is how long a provider token is reused. Apple rejects tokens older than
+	// an hour and throttles providers that refresh more often than every 20 minutes.
+	tokenLifetime = 50 * time.Minute
+
+	// DefaultAttempts is how many times a notification is sent before giving up
+	DefaultAttempts = 3
+
+	// DefaultBackoff is the wait before the first retry, doubled for each one after
+	DefaultBackoff = 500 * time.Millisecond
+)
+
+// Config holds what the client needs to reach APNs
+type Config struct {
+	KeyPath  string // .p8 signing key
+	KeyID    string
+	TeamID   string
+	Topic    string // The app's bundle ID
+	Endpoint string // e.g. https://api.push.apple.com, or a local stand-in
+
+	// HTTPClient sends the requests, one speaking HTTP/2 over TLS is made if nil
+	HTTPClient *http.Client
+	Attempts   int
+	Backoff    time.Duration
+}
+
+// Notification is a push notification for one device
+type Notification struct {
+	Alert string
+	Badge *int
+	Data  map[string]string // Extra keys the app reads alongside the alert
+}
+
+// Error is a notification APNs refused
+type Error struct {
+	Status int
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("apns: %d %s", e.Status, e.Reason)
+}
+
+// InvalidToken reports whether the device token will never work again and should be forgotten
+func (e *Error) InvalidToken() bool {
+	return e.Status == http.StatusGone ||
+		e.Reason == "BadDeviceToken" ||
+		e.Reason == "Unregistered" ||
+		e.Reason == "DeviceTokenNotForTopic"
+}
+
+// IsInvalidToken reports whether err means the device token should be forgotten
+func IsInvalidToken(err error) bool {
+	var apnsErr *Error
+	return errors.As(err, &apnsErr) && apnsErr.InvalidToken()
+}
+
+// Client sends notifications to APNs, signing requests with a provider token
+type Client struct {
+	cfg  Config
+	key  *ecdsa.PrivateKey
+	http *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewClient loads the signing key and creates a client
+func NewClient(cfg Config) (*Client, error) {
+	pem, err := os.ReadFile(cfg.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %w", err)
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse APNs key: %w", err)
+	}
+
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{ForceAttemptHTTP2: true},
+		}
+	}
+	if cfg.Attempts < 1 {
+		cfg.Attempts = DefaultAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	return &Client{cfg: cfg, key: key, http: cfg.HTTPClient}, nil
+}
+
+// Send delivers a notification to a device, retrying when APNs or the network fails.
+// Errors for tokens that will never work again satisfy IsInvalidToken.
+func (c *Client) Send(ctx context.Context, deviceToken string, n *Notification) error {
+	body, err := payload(n)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err = c.send(ctx, deviceToken, body)
+		if err == nil || attempt >= c.cfg.Attempts || !c.retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send makes a single request
+func (c *Client) send(ctx context.Context, deviceToken string, body []byte) error {
+	token, err := c.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Endpoint+"/3/device/"+deviceToken, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+token)
+	req.Header.Set("apns-topic", c.cfg.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("content-type", "application/json")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	var reply struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(res.Body).Decode(&reply)
+	return &Error{Status: res.StatusCode, Reason: reply.Reason}
+}
+
+// retryable reports whether sending again might work. A rejected provider token is
+// dropped so the retry signs a fresh one.
+func (c *Client) retryable(err error) bool {
+	var apnsErr *Error
+	if !errors.As(err, &apnsErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch {
+	case apnsErr.Reason == "ExpiredProviderToken":
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		return true
+	case apnsErr.Status == http.StatusTooManyRequests, apnsErr.Status >= 500:
+		return true
+	}
+	return false
+}
+
+// providerToken returns the signed JWT APNs authenticates the server with, reusing it for tokenLifetime
+func (c *Client) providerToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token != "" && now.Sub(c.issuedAt) < tokenLifetime {
+		return c.token, nil
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": c.cfg.TeamID,
+		"iat": now.Unix(),
+	})
+	t.Header["kid"] = c.cfg.KeyID
+
+	signed, err := t.SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs provider token: %w", err)
+	}
+
+	c.token = signed
+	c.issuedAt = now
+	return signed, nil
+}
+
+// payload builds the JSON body APNs expects
+func payload(n *Notification) ([]byte, error) {
+	aps := map[string]interface{}{
+		"alert": map[string]string{"body": n.Alert},
+		"sound": "default",
+	}
+	if n.Badge != nil {
+		aps["badge"] = *n.Badge
+	}
+
+	body := map[string]interface{}{"aps": aps}
+	for k, v := range n.Data {
+		if k != "aps" {
+			body[k] = v
+		}
+	}
+
+	return json.Marshal(body)
+}
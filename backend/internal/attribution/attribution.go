@@ -0,0 +1,46 @@
+// Package attribution splits quotes typed along with who said them, like `"text" - author`
+package attribution
+
+import "strings"
+
+// quoteMarks are the opening and closing marks a quote can be wrapped in. Chat and mail clients
+// often turn straight quotes into curly ones as they're typed.
+var quoteMarks = map[rune]rune{'"': '"', '“': '”', '„': '“', '«': '»'}
+
+// separators are what can separate a quote from its author, on the same line or starting the next
+var separators = []string{" - ", " – ", " — ", " ~ ", "\n- ", "\n– ", "\n— ", "\n~ "}
+
+// Parse splits text like `"text" - author` into the quote and its author. Quote marks
+// are optional without an author, and the author is split off at the last separator.
+func Parse(text string) (quote, author string, ok bool) {
+	text = strings.TrimSpace(text)
+
+	for _, open := range []rune{'"', '“', '„', '«'} {
+		if !strings.HasPrefix(text, string(open)) {
+			continue
+		}
+		end := strings.LastIndex(text, string(quoteMarks[open]))
+		if end <= len(string(open)) {
+			continue
+		}
+		quote = strings.TrimSpace(text[len(string(open)):end])
+		rest := strings.TrimSpace(text[end+len(string(quoteMarks[open])):])
+		rest = strings.TrimSpace(strings.TrimLeft(rest, "-–—~"))
+		return quote, rest, quote != ""
+	}
+
+	best := -1
+	var sep string
+	for _, s := range separators {
+		if i := strings.LastIndex(text, s); i > best {
+			best, sep = i, s
+		}
+	}
+	if best >= 0 {
+		quote = strings.TrimSpace(text[:best])
+		author = strings.TrimSpace(text[best+len(sep):])
+		return quote, author, quote != ""
+	}
+
+	return text, "", text != ""
+}
@@ -0,0 +1,142 @@
+// Package book lays out a group's quotes as a printable book and renders it as PDF or EPUB
+package book
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chapter orders
+const (
+	OrderChronological = "chronological" // One chapter per year
+	OrderPerson        = "person"        // One chapter per author
+)
+
+// unknownAuthor heads the chapter of quotes without an author
+const unknownAuthor = "Unknown"
+
+// Quote is a single quote as printed in the book
+type Quote struct {
+	Text   string
+	Author string
+	Date   time.Time // Already in the zone the book is printed for
+}
+
+// Chapter is a titled run of quotes
+type Chapter struct {
+	Title  string
+	Quotes []Quote
+}
+
+// Book is a title page followed by chapters of quotes
+type Book struct {
+	ID          string // Stable identifier, used by EPUB readers to recognise new editions
+	Title       string
+	Subtitle    string
+	GeneratedAt time.Time
+	Chapters    []Chapter
+}
+
+// ValidOrder reports whether order is a supported chapter order
+func ValidOrder(order string) bool {
+	return order == OrderChronological || order == OrderPerson
+}
+
+// New arranges quotes into chapters in the given order
+func New(id, title string, quotes []Quote, order string, generatedAt time.Time) (*Book, error) {
+	if !ValidOrder(order) {
+		return nil, fmt.Errorf("unsupported order %q", order)
+	}
+
+	sorted := make([]Quote, len(quotes))
+	copy(sorted, quotes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var chapters []Chapter
+	if order == OrderPerson {
+		chapters = byAuthor(sorted)
+	} else {
+		chapters = byYear(sorted)
+	}
+
+	return &Book{
+		ID:          id,
+		Title:       title,
+		Subtitle:    subtitle(sorted),
+		GeneratedAt: generatedAt,
+		Chapters:    chapters,
+	}, nil
+}
+
+// QuoteCount returns the number of quotes across all chapters
+func (b *Book) QuoteCount() int {
+	n := 0
+	for _, ch := range b.Chapters {
+		n += len(ch.Quotes)
+	}
+	return n
+}
+
+// byYear splits date-ordered quotes into a chapter per year
+func byYear(quotes []Quote) []Chapter {
+	var chapters []Chapter
+	for _, q := range quotes {
+		title := strconv.Itoa(q.Date.Year())
+		if len(chapters) == 0 || chapters[len(chapters)-1].Title != title {
+			chapters = append(chapters, Chapter{Title: title})
+		}
+		last := &chapters[len(chapters)-1]
+		last.Quotes = append(last.Quotes, q)
+	}
+	return chapters
+}
+
+// byAuthor groups date-ordered quotes into a chapter per author, alphabetically.
+// Authors differing only in case share a chapter, titled with the first spelling seen.
+func byAuthor(quotes []Quote) []Chapter {
+	index := make(map[string]int)
+	var chapters []Chapter
+	for _, q := range quotes {
+		author := strings.TrimSpace(q.Author)
+		if author == "" {
+			author = unknownAuthor
+		}
+
+		key := strings.ToLower(author)
+		i, ok := index[key]
+		if !ok {
+			i = len(chapters)
+			index[key] = i
+			chapters = append(chapters, Chapter{Title: author})
+		}
+		chapters[i].Quotes = append(chapters[i].Quotes, q)
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return strings.ToLower(chapters[i].Title) < strings.ToLower(chapters[j].Title)
+	})
+	return chapters
+}
+
+// subtitle describes the quote count and the years the quotes span
+func subtitle(quotes []Quote) string {
+	if len(quotes) == 0 {
+		return "No quotes yet"
+	}
+
+	count := fmt.Sprintf("%d quotes", len(quotes))
+	if len(quotes) == 1 {
+		count = "1 quote"
+	}
+
+	first, last := quotes[0].Date.Year(), quotes[len(quotes)-1].Date.Year()
+	if first == last {
+		return fmt.Sprintf("%s from %d", count, first)
+	}
+	return fmt.Sprintf("%s, %d–%d", count, first, last)
+}
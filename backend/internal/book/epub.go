@@ -0,0 +1,226 @@
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// epubTemplates are the files of an EPUB 3 book. toc.ncx is included for EPUB 2 readers.
+var epubTemplates = template.Must(template.New("epub").Funcs(template.FuncMap{
+	"x":           xmlEscape,
+	"paragraphs":  paragraphs,
+	"attribution": attribution,
+	"chapterFile": chapterFile,
+	"inc":         func(i int) int { return i + 1 },
+}).Parse(`
+{{define "container.xml"}}<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+{{end}}
+
+{{define "content.opf"}}<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">urn:uuid:{{x .Book.ID}}</dc:identifier>
+    <dc:title>{{x .Book.Title}}</dc:title>
+    <dc:language>en</dc:language>
+    <dc:creator>Reminiscer</dc:creator>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+{{- range $i, $ch := .Book.Chapters}}
+    <item id="chapter-{{inc $i}}" href="{{chapterFile $i}}" media-type="application/xhtml+xml"/>
+{{- end}}
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="title"/>
+    <itemref idref="nav"/>
+{{- range $i, $ch := .Book.Chapters}}
+    <itemref idref="chapter-{{inc $i}}"/>
+{{- end}}
+  </spine>
+</package>
+{{end}}
+
+{{define "toc.ncx"}}<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="urn:uuid:{{x .Book.ID}}"/>
+  </head>
+  <docTitle><text>{{x .Book.Title}}</text></docTitle>
+  <navMap>
+{{- range $i, $ch := .Book.Chapters}}
+    <navPoint id="nav-{{inc $i}}" playOrder="{{inc $i}}">
+      <navLabel><text>{{x $ch.Title}}</text></navLabel>
+      <content src="{{chapterFile $i}}"/>
+    </navPoint>
+{{- end}}
+  </navMap>
+</ncx>
+{{end}}
+
+{{define "nav.xhtml"}}<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en">
+<head>
+  <title>Contents</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>Contents</h1>
+    <ol>
+{{- range $i, $ch := .Book.Chapters}}
+      <li><a href="{{chapterFile $i}}">{{x $ch.Title}}</a></li>
+{{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+{{end}}
+
+{{define "title.xhtml"}}<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" lang="en">
+<head>
+  <title>{{x .Book.Title}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body class="title-page">
+  <h1>{{x .Book.Title}}</h1>
+  <p class="subtitle">{{x .Book.Subtitle}}</p>
+  <p class="printed">Printed {{.Book.GeneratedAt.Format "2 January 2006"}}</p>
+</body>
+</html>
+{{end}}
+
+{{define "chapter.xhtml"}}<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" lang="en">
+<head>
+  <title>{{x .Title}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+  <h2>{{x .Title}}</h2>
+{{- range .Quotes}}
+  <blockquote>
+    {{paragraphs .Text}}
+    <p class="attribution">{{x (attribution .)}}</p>
+  </blockquote>
+{{- end}}
+</body>
+</html>
+{{end}}
+`))
+
+// epubStyle is the book's stylesheet
+const epubStyle = `body { font-family: serif; line-height: 1.4; }
+h1, h2 { font-family: sans-serif; }
+.title-page { text-align: center; margin-top: 30%; }
+.subtitle { font-style: italic; }
+.printed { font-size: 0.8em; color: #666; margin-top: 4em; }
+blockquote { margin: 0 0 1.6em 0; page-break-inside: avoid; }
+blockquote p { margin: 0; }
+.attribution { text-align: right; font-style: italic; font-size: 0.85em; color: #555; margin-top: 0.3em; }
+nav ol { list-style: none; padding: 0; }
+`
+
+// epubFile is a file in the book rendered from one of epubTemplates
+type epubFile struct {
+	name     string
+	template string
+	data     interface{}
+}
+
+// WriteEPUB renders the book as an EPUB 3 file
+func WriteEPUB(w io.Writer, b *Book) error {
+	archive := zip.NewWriter(w)
+
+	// The mimetype must be the first entry and stored uncompressed
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	data := struct {
+		Book     *Book
+		Modified string
+	}{
+		Book:     b,
+		Modified: b.GeneratedAt.UTC().Format(time.RFC3339),
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", "container.xml", data},
+		{"OEBPS/content.opf", "content.opf", data},
+		{"OEBPS/toc.ncx", "toc.ncx", data},
+		{"OEBPS/nav.xhtml", "nav.xhtml", data},
+		{"OEBPS/title.xhtml", "title.xhtml", data},
+	}
+	for i, ch := range b.Chapters {
+		files = append(files, epubFile{"OEBPS/" + chapterFile(i), "chapter.xhtml", ch})
+	}
+
+	for _, f := range files {
+		fw, err := archive.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if err := epubTemplates.ExecuteTemplate(fw, f.template, f.data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", f.name, err)
+		}
+	}
+
+	style, err := archive.Create("OEBPS/style.css")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(style, epubStyle); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// chapterFile is the file name of the i-th chapter
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter-%d.xhtml", i+1)
+}
+
+// xmlEscape escapes text for use in XHTML content and attributes
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// paragraphs renders each line of a quote as an XHTML paragraph
+func paragraphs(text string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if i > 0 {
+			b.WriteString("\n    ")
+		}
+		b.WriteString("<p>")
+		b.WriteString(xmlEscape(line))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
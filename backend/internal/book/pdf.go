@@ -0,0 +1,183 @@
+package book
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Page layout in millimetres, on A5 paper
+const (
+	pdfMargin     = 18.0
+	pdfLineHeight = 5.5
+	pdfQuoteGap   = 7.0
+	pdfTOCLine    = 7.0
+)
+
+// pdfFont is the family every page is printed in, registered from the Go fonts
+const pdfFont = "Go"
+
+// WritePDF renders the book as a paginated A5 PDF.
+//
+// Text is printed in the Go fonts, which cover Latin, Greek and Cyrillic scripts.
+// Characters they have no glyph for, such as CJK or emoji, print as blank boxes.
+func WritePDF(w io.Writer, b *Book) error {
+	// Chapter page numbers are only known once the book is laid out, so it is laid out
+	// twice. The contents take the same space both times, which keeps the numbers stable.
+	_, pages, err := layoutPDF(b, nil)
+	if err != nil {
+		return err
+	}
+
+	pdf, _, err := layoutPDF(b, pages)
+	if err != nil {
+		return err
+	}
+
+	return pdf.Output(w)
+}
+
+// layoutPDF lays out the whole book, printing chapter page numbers in the contents if
+// they are known, and returns the page each chapter starts on
+func layoutPDF(b *Book, chapterPages []int) (*fpdf.Fpdf, []int, error) {
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(b.Title, true)
+	pdf.SetCreator("Reminiscer", true)
+	pdf.SetCreationDate(b.GeneratedAt)
+
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "I", goitalic.TTF)
+
+	text := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r == '\t':
+				return ' '
+			case r > 0xFFFF:
+				// The PDF fonts only address the Basic Multilingual Plane
+				return '\uFFFD'
+			}
+			return r
+		}, s)
+	}
+
+	width, height := pdf.GetPageSize()
+	contentWidth := width - 2*pdfMargin
+
+	pdf.SetFooterFunc(func() {
+		if pdf.PageNo() == 1 {
+			return
+		}
+		pdf.SetY(-pdfMargin + 4)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// Title page
+	pdf.AddPage()
+	pdf.SetY(height / 3)
+	pdf.SetFont(pdfFont, "B", 26)
+	pdf.SetTextColor(30, 30, 30)
+	pdf.MultiCell(contentWidth, 11, text(b.Title), "", "C", false)
+	pdf.Ln(4)
+	pdf.SetFont(pdfFont, "I", 13)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.MultiCell(contentWidth, 7, text(b.Subtitle), "", "C", false)
+	pdf.SetY(height - pdfMargin - 10)
+	pdf.SetFont(pdfFont, "", 8)
+	pdf.CellFormat(contentWidth, 5, text("Printed "+b.GeneratedAt.Format("2 January 2006")), "", 1, "C", false, 0, "")
+
+	// Contents, linking each entry to its chapter
+	links := make([]int, len(b.Chapters))
+	for i := range links {
+		links[i] = pdf.AddLink()
+	}
+	if len(b.Chapters) > 0 {
+		pdf.AddPage()
+		pdf.SetFont(pdfFont, "B", 16)
+		pdf.SetTextColor(30, 30, 30)
+		pdf.CellFormat(contentWidth, 10, "Contents", "", 1, "L", false, 0, "")
+		pdf.Ln(4)
+
+		pdf.SetFont(pdfFont, "", 11)
+		for i, ch := range b.Chapters {
+			page := ""
+			if i < len(chapterPages) {
+				page = fmt.Sprintf("%d", chapterPages[i])
+			}
+			title := truncatePDF(pdf, text(ch.Title), contentWidth-20)
+			pdf.CellFormat(contentWidth-15, pdfTOCLine, title, "", 0, "L", false, links[i], "")
+			pdf.CellFormat(15, pdfTOCLine, page, "", 1, "R", false, links[i], "")
+		}
+	}
+
+	// Chapters
+	pages := make([]int, len(b.Chapters))
+	for i, ch := range b.Chapters {
+		pdf.AddPage()
+		pages[i] = pdf.PageNo()
+		pdf.SetLink(links[i], -1, pages[i])
+		pdf.Bookmark(text(ch.Title), 0, -1)
+
+		pdf.SetFont(pdfFont, "B", 18)
+		pdf.SetTextColor(30, 30, 30)
+		pdf.MultiCell(contentWidth, 9, text(ch.Title), "", "L", false)
+		pdf.Ln(6)
+
+		for _, q := range ch.Quotes {
+			body := text(q.Text)
+
+			// Keep a quote and its attribution together when the quote fits on a page
+			pdf.SetFont(pdfFont, "", 12)
+			lines := pdf.SplitLines([]byte(body), contentWidth)
+			needed := float64(len(lines))*pdfLineHeight + pdfLineHeight + pdfQuoteGap
+			if pdf.GetY()+needed > height-pdfMargin && needed < height-2*pdfMargin {
+				pdf.AddPage()
+			}
+
+			pdf.SetTextColor(30, 30, 30)
+			pdf.MultiCell(contentWidth, pdfLineHeight, body, "", "L", false)
+
+			pdf.SetFont(pdfFont, "I", 10)
+			pdf.SetTextColor(100, 100, 100)
+			pdf.CellFormat(contentWidth, pdfLineHeight, text(attribution(q)), "", 1, "R", false, 0, "")
+			pdf.Ln(pdfQuoteGap)
+		}
+	}
+
+	if err := pdf.Error(); err != nil {
+		return nil, nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	return pdf, pages, nil
+}
+
+// truncatePDF shortens s with an ellipsis so it fits in width at the current font
+func truncatePDF(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// attribution is the line printed under a quote
+func attribution(q Quote) string {
+	author := strings.TrimSpace(q.Author)
+	if author == "" {
+		author = unknownAuthor
+	}
+	return fmt.Sprintf("— %s, %s", author, q.Date.Format("2 January 2006"))
+}
@@ -0,0 +1,68 @@
+// Package capsule opens time capsule quotes when their unlock time arrives
+package capsule
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// DefaultInterval is how often the watcher looks for capsules that have unlocked
+const DefaultInterval = time.Minute
+
+// Watcher polls for time capsules that have unlocked and hands each one to the registered callbacks
+type Watcher struct {
+	quotes   models.QuoteStore
+	interval time.Duration
+	onUnlock []func(*models.Quote)
+}
+
+// NewWatcher creates a watcher checking the quote store every interval
+func NewWatcher(quotes models.QuoteStore, interval time.Duration) *Watcher {
+	return &Watcher{
+		quotes:   quotes,
+		interval: interval,
+	}
+}
+
+// OnUnlock registers a callback run once for every capsule that unlocks
+func (w *Watcher) OnUnlock(fn func(*models.Quote)) {
+	w.onUnlock = append(w.onUnlock, fn)
+}
+
+// Run checks for unlocked capsules until ctx is cancelled. Capsules that
+// unlocked while the server was down are picked up on the first check.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Check(time.Now()); err != nil {
+			log.Printf("Failed to check time capsules: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check releases every capsule that has unlocked by now and runs the callbacks for it
+func (w *Watcher) Check(now time.Time) error {
+	quotes, err := w.quotes.ReleaseUnlocked(now)
+	if err != nil {
+		return err
+	}
+
+	for _, quote := range quotes {
+		for _, fn := range w.onUnlock {
+			fn(quote)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+// Package card renders quotes as PNG images for sharing
+package card
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Theme is the colour scheme of a card
+type Theme struct {
+	Background color.RGBA
+	Accent     color.RGBA
+	Text       color.RGBA
+	Muted      color.RGBA
+}
+
+// Themes are the available card colour schemes
+var Themes = map[string]Theme{
+	"light": {
+		Background: color.RGBA{0xfa, 0xf7, 0xf2, 0xff},
+		Accent:     color.RGBA{0xd9, 0x6c, 0x3f, 0xff},
+		Text:       color.RGBA{0x22, 0x22, 0x22, 0xff},
+		Muted:      color.RGBA{0x77, 0x72, 0x6b, 0xff},
+	},
+	"dark": {
+		Background: color.RGBA{0x1c, 0x1f, 0x26, 0xff},
+		Accent:     color.RGBA{0x7a, 0xa2, 0xf7, 0xff},
+		Text:       color.RGBA{0xf2, 0xf2, 0xf2, 0xff},
+		Muted:      color.RGBA{0x9a, 0xa0, 0xad, 0xff},
+	},
+	"sunset": {
+		Background: color.RGBA{0x3d, 0x1e, 0x4f, 0xff},
+		Accent:     color.RGBA{0xff, 0xb3, 0x47, 0xff},
+		Text:       color.RGBA{0xff, 0xf4, 0xe6, 0xff},
+		Muted:      color.RGBA{0xe0, 0xb8, 0xd8, 0xff},
+	},
+}
+
+// Sizes are the available card dimensions in pixels
+var Sizes = map[string]image.Point{
+	"square": {X: 1080, Y: 1080},
+	"story":  {X: 1080, Y: 1920},
+	"wide":   {X: 1200, Y: 630}, // Link previews
+}
+
+// Default theme and size
+const (
+	DefaultTheme = "light"
+	DefaultSize  = "square"
+)
+
+// Card is the content printed on a card
+type Card struct {
+	Text   string
+	Author string
+	Group  string
+}
+
+// Layout constants, as fractions of the card width
+const (
+	paddingRatio  = 0.09
+	maxTextRatio  = 0.065
+	minTextRatio  = 0.028
+	authorRatio   = 0.036
+	groupRatio    = 0.026
+	lineSpacing   = 1.35
+	accentBarSize = 12
+)
+
+var (
+	fontsOnce sync.Once
+	fontsErr  error
+	quoteFont *opentype.Font
+	textFont  *opentype.Font
+	boldFont  *opentype.Font
+)
+
+// loadFonts parses the bundled Go fonts once
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		if quoteFont, fontsErr = opentype.Parse(goitalic.TTF); fontsErr != nil {
+			return
+		}
+		if textFont, fontsErr = opentype.Parse(goregular.TTF); fontsErr != nil {
+			return
+		}
+		boldFont, fontsErr = opentype.Parse(gobold.TTF)
+	})
+	return fontsErr
+}
+
+// Render draws a card and writes it to w as a PNG
+func Render(w io.Writer, c Card, themeName, sizeName string) error {
+	theme, ok := Themes[themeName]
+	if !ok {
+		return fmt.Errorf("unknown theme %q", themeName)
+	}
+	size, ok := Sizes[sizeName]
+	if !ok {
+		return fmt.Errorf("unknown size %q", sizeName)
+	}
+	if err := loadFonts(); err != nil {
+		return fmt.Errorf("failed to load fonts: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(img, img.Bounds(), image.NewUniform(theme.Background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, accentBarSize, size.Y), image.NewUniform(theme.Accent), image.Point{}, draw.Src)
+
+	width := float64(size.X)
+	padding := int(width * paddingRatio)
+	textWidth := size.X - 2*padding
+
+	// The footer holds the author and group name, the quote fills the space above it
+	authorFace, err := newFace(boldFont, width*authorRatio)
+	if err != nil {
+		return err
+	}
+	defer authorFace.Close()
+	groupFace, err := newFace(textFont, width*groupRatio)
+	if err != nil {
+		return err
+	}
+	defer groupFace.Close()
+
+	footerHeight := lineHeight(authorFace) + lineHeight(groupFace)
+	top := padding
+	bottom := size.Y - padding - footerHeight - padding/2
+
+	// Shrink the text until it fits, and cut it short if it doesn't fit at the smallest size
+	var face font.Face
+	var lines []string
+	for ratio := maxTextRatio; ; ratio -= 0.004 {
+		if face != nil {
+			face.Close()
+		}
+		face, err = newFace(quoteFont, width*ratio)
+		if err != nil {
+			return err
+		}
+		lines = wrap(face, c.Text, textWidth)
+
+		maxLines := (bottom - top) / lineHeight(face)
+		if len(lines) <= maxLines {
+			break
+		}
+		if ratio-0.004 < minTextRatio {
+			lines = truncate(face, lines[:maxLines], textWidth)
+			break
+		}
+	}
+	defer face.Close()
+
+	// Centre the quote vertically in its space
+	blockHeight := len(lines) * lineHeight(face)
+	y := top + (bottom-top-blockHeight)/2
+	for _, line := range lines {
+		y += lineHeight(face)
+		drawText(img, face, theme.Text, line, padding, y-descent(face))
+	}
+
+	y = size.Y - padding - footerHeight
+	author := strings.TrimSpace(c.Author)
+	if author == "" {
+		author = "Unknown"
+	}
+	y += lineHeight(authorFace)
+	drawText(img, authorFace, theme.Accent, "— "+fit(authorFace, author, textWidth), padding, y-descent(authorFace))
+	if group := strings.TrimSpace(c.Group); group != "" {
+		y += lineHeight(groupFace)
+		drawText(img, groupFace, theme.Muted, fit(groupFace, group, textWidth), padding, y-descent(groupFace))
+	}
+
+	return png.Encode(w, img)
+}
+
+// newFace creates a face of the given pixel size
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// lineHeight is the distance between baselines for a face
+func lineHeight(face font.Face) int {
+	return int(float64(face.Metrics().Height.Ceil()) * lineSpacing)
+}
+
+// descent is how far below the baseline a face's glyphs reach
+func descent(face font.Face) int {
+	return face.Metrics().Descent.Ceil()
+}
+
+// drawText draws a line of text with its baseline at y
+func drawText(img draw.Image, face font.Face, col color.Color, text string, x, y int) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// wrap breaks text into lines no wider than width, keeping explicit line breaks.
+// Words too long for a line on their own are split between characters.
+func wrap(face font.Face, text string, width int) []string {
+	limit := fixed.I(width)
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		line := ""
+		for _, word := range strings.FieldsFunc(paragraph, unicode.IsSpace) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if font.MeasureString(face, candidate) <= limit {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && font.MeasureString(face, line+string(r)) > limit {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate ends the last line with an ellipsis to show the text was cut short
+func truncate(face font.Face, lines []string, width int) []string {
+	if len(lines) > 0 {
+		last := len(lines) - 1
+		lines[last] = fit(face, lines[last]+"…", width)
+	}
+	return lines
+}
+
+// fit shortens s with an ellipsis so it is no wider than width
+func fit(face font.Face, s string, width int) string {
+	limit := fixed.I(width)
+	if font.MeasureString(face, s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && font.MeasureString(face, string(runes)+"…") > limit {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
@@ -16,18 +16,25 @@ type Config struct {
 	JWT      JWTConfig
 	Database DatabaseConfig
 	Security SecurityConfig
+	APNs     APNsConfig
+	Mail     MailConfig
+	Slack    SlackConfig
+	Telegram TelegramConfig
+	Inbound  InboundConfig
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port      string
+	Env       string
+	PublicURL string // Base URL used in links shared outside the app, taken from the request if empty. Share page previews leave out their URLs without it.
 }
 
 // JWTConfig holds JWT-specific configuration
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	Secret             string
+	AccessTokenMinutes int // How long an access token works before it has to be refreshed
+	RefreshTokenDays   int // How long a refresh token lasts unused, each refresh starts it over
 }
 
 // DatabaseConfig holds database-specific configuration
@@ -42,6 +49,66 @@ type SecurityConfig struct {
 	AllowedOrigins    string
 }
 
+// APNsConfig holds Apple Push Notification service configuration
+type APNsConfig struct {
+	KeyPath  string // .p8 signing key from the Apple developer account, push is off if empty
+	KeyID    string
+	TeamID   string
+	Topic    string // The iOS app's bundle ID
+	Endpoint string
+}
+
+// Enabled returns true if push notifications can be sent
+func (c APNsConfig) Enabled() bool {
+	return c.KeyPath != ""
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	SMTPAddr     string // host:port, email is off if empty
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+// Enabled returns true if email can be sent
+func (c MailConfig) Enabled() bool {
+	return c.SMTPAddr != ""
+}
+
+// SlackConfig holds the Slack app's configuration
+type SlackConfig struct {
+	SigningSecret string // From the app's Basic Information page, Slack is off if empty
+}
+
+// Enabled returns true if Slack requests can be verified
+func (c SlackConfig) Enabled() bool {
+	return c.SigningSecret != ""
+}
+
+// TelegramConfig holds the Telegram bot's configuration
+type TelegramConfig struct {
+	BotToken      string // From @BotFather, the bot is off if empty
+	WebhookSecret string // Sent by Telegram with every update, required when the bot is on
+	APIURL        string // Bot API address, a local stand-in can be used in development
+}
+
+// Enabled returns true if the Telegram bot is configured
+func (c TelegramConfig) Enabled() bool {
+	return c.BotToken != ""
+}
+
+// InboundConfig holds the configuration for receiving quotes by email
+type InboundConfig struct {
+	Secret string // Shared with the mail relay that posts emails in, inbound email is off if empty
+	Domain string // Domain of group inbound addresses, e.g. in.example.com
+}
+
+// Enabled returns true if emails can be received
+func (c InboundConfig) Enabled() bool {
+	return c.Secret != ""
+}
+
 // LoadConfig loads the application configuration from environment variables
 func LoadConfig() (*Config, error) {
 	// Load .env file if it exists
@@ -52,10 +119,12 @@ func LoadConfig() (*Config, error) {
 	// Server configuration
 	port := getEnvOrDefault("PORT", "8080")
 	env := getEnvOrDefault("ENV", "development")
+	publicURL := os.Getenv("PUBLIC_URL")
 
 	// JWT configuration
 	jwtSecret := getEnvOrDefault("JWT_SECRET", "your-super-secret-key-change-this-in-production")
-	jwtExpHours, _ := strconv.Atoi(getEnvOrDefault("JWT_EXPIRATION_HOURS", "24"))
+	jwtAccessMinutes, _ := strconv.Atoi(getEnvOrDefault("JWT_ACCESS_TOKEN_MINUTES", "15"))
+	jwtRefreshDays, _ := strconv.Atoi(getEnvOrDefault("JWT_REFRESH_TOKEN_DAYS", "30"))
 
 	// Database configuration
 	dbPath := getEnvOrDefault("DB_PATH", filepath.Join(".", "data", "reminiscer.db"))
@@ -65,6 +134,13 @@ func LoadConfig() (*Config, error) {
 	rateLimitDur, _ := strconv.Atoi(getEnvOrDefault("RATE_LIMIT_DURATION", "60"))
 	allowedOrigins := getEnvOrDefault("ALLOWED_ORIGINS", "http://localhost:3000")
 
+	// APNs configuration, development builds of the app get tokens from the sandbox
+	apnsEndpoint := "https://api.push.apple.com"
+	if env == "development" {
+		apnsEndpoint = "https://api.sandbox.push.apple.com"
+	}
+	apnsEndpoint = getEnvOrDefault("APNS_ENDPOINT", apnsEndpoint)
+
 	// Ensure database directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
@@ -72,12 +148,14 @@ func LoadConfig() (*Config, error) {
 
 	return &Config{
 		Server: ServerConfig{
-			Port: port,
-			Env:  env,
+			Port:      port,
+			Env:       env,
+			PublicURL: publicURL,
 		},
 		JWT: JWTConfig{
-			Secret:          jwtSecret,
-			ExpirationHours: jwtExpHours,
+			Secret:             jwtSecret,
+			AccessTokenMinutes: jwtAccessMinutes,
+			RefreshTokenDays:   jwtRefreshDays,
 		},
 		Database: DatabaseConfig{
 			Path: dbPath,
@@ -87,6 +165,31 @@ func LoadConfig() (*Config, error) {
 			RateLimitDuration: rateLimitDur,
 			AllowedOrigins:    allowedOrigins,
 		},
+		Mail: MailConfig{
+			SMTPAddr:     os.Getenv("SMTP_ADDR"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			From:         getEnvOrDefault("MAIL_FROM", "Reminiscer <no-reply@localhost>"),
+		},
+		APNs: APNsConfig{
+			KeyPath:  os.Getenv("APNS_KEY_PATH"),
+			KeyID:    os.Getenv("APNS_KEY_ID"),
+			TeamID:   os.Getenv("APNS_TEAM_ID"),
+			Topic:    os.Getenv("APNS_TOPIC"),
+			Endpoint: apnsEndpoint,
+		},
+		Slack: SlackConfig{
+			SigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		},
+		Telegram: TelegramConfig{
+			BotToken:      os.Getenv("TELEGRAM_BOT_TOKEN"),
+			WebhookSecret: os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+			APIURL:        getEnvOrDefault("TELEGRAM_API_URL", "https://api.telegram.org"),
+		},
+		Inbound: InboundConfig{
+			Secret: os.Getenv("INBOUND_EMAIL_SECRET"),
+			Domain: os.Getenv("INBOUND_EMAIL_DOMAIN"),
+		},
 	}, nil
 }
 
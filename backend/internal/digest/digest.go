@@ -0,0 +1,229 @@
+// Package digest emails members a weekly round-up of their groups
+package digest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/mail"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+const (
+	// Period is how often a member gets a digest
+	Period = 7 * 24 * time.Hour
+
+	// DefaultInterval is how often the scheduler looks for members due a digest
+	DefaultInterval = time.Hour
+
+	// quotesPerGroup is how many new quotes a group shows before the rest are only counted
+	quotesPerGroup = 10
+)
+
+// Digest is everything one email shows
+type Digest struct {
+	Username       string
+	Groups         []*groupView
+	NewCount       int
+	UnsubscribeURL string
+}
+
+type groupView struct {
+	Name        string
+	NewQuotes   []*quoteView
+	NewCount    int
+	More        int
+	MostReacted *quoteView
+	OnThisDay   *quoteView
+}
+
+type quoteView struct {
+	Text      string
+	Author    string
+	Uploader  string
+	Reactions int
+	Date      time.Time
+}
+
+// Scheduler sends digests to members once every Period
+type Scheduler struct {
+	store     models.Store
+	transport mail.Transport
+	from      string
+	baseURL   string
+	interval  time.Duration
+}
+
+// NewScheduler creates a scheduler sending mail from the given address. Unsubscribe links point at baseURL.
+func NewScheduler(store models.Store, transport mail.Transport, from, baseURL string, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		transport: transport,
+		from:      from,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		interval:  interval,
+	}
+}
+
+// Run sends digests as they fall due until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(time.Now()); err != nil {
+			log.Printf("Failed to send digests: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check sends a digest to every member who hasn't had one for a Period. Members whose groups
+// were quiet are skipped until the next Period. A failed send is retried on the next check.
+func (s *Scheduler) Check(now time.Time) error {
+	recipients, err := s.store.Digests().Recipients(now.Add(-Period))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range recipients {
+		since := now.Add(-Period)
+		if r.LastSentAt != nil && r.LastSentAt.Before(since) {
+			since = *r.LastSentAt
+		}
+
+		digest, err := s.Build(r.User, since, now)
+		if err != nil {
+			log.Printf("Failed to build digest for user %s: %v", r.User.ID, err)
+			continue
+		}
+
+		if digest != nil {
+			if err := s.send(r.User, digest); err != nil {
+				log.Printf("Failed to send digest to user %s: %v", r.User.ID, err)
+				continue
+			}
+		}
+
+		if err := s.store.Digests().MarkSent(r.User.ID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Build collects a member's digest for the period from since to now, nil if nothing happened in any of their groups
+func (s *Scheduler) Build(user *models.User, since, now time.Time) (*Digest, error) {
+	memberships, err := s.store.Groups().GetByMemberID(user.ID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	digest := &Digest{Username: user.Username}
+	for _, g := range memberships {
+		// "On this day" follows the group's calendar
+		loc, err := time.LoadLocation(g.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		gd, err := s.store.Digests().GroupDigest(g.GroupID, since, now.In(loc), quotesPerGroup)
+		if err != nil {
+			return nil, err
+		}
+		if gd.Empty() {
+			continue
+		}
+
+		view := &groupView{
+			Name:        g.Name,
+			NewCount:    gd.NewCount,
+			More:        gd.NewCount - len(gd.NewQuotes),
+			MostReacted: toQuoteView(gd.MostReacted, loc),
+			OnThisDay:   toQuoteView(gd.OnThisDay, loc),
+		}
+		for _, q := range gd.NewQuotes {
+			view.NewQuotes = append(view.NewQuotes, toQuoteView(q, loc))
+		}
+
+		digest.Groups = append(digest.Groups, view)
+		digest.NewCount += gd.NewCount
+	}
+
+	if len(digest.Groups) == 0 {
+		return nil, nil
+	}
+	return digest, nil
+}
+
+// send renders a digest and mails it with a one-click unsubscribe link
+func (s *Scheduler) send(user *models.User, digest *Digest) error {
+	token, err := s.store.Digests().UnsubscribeToken(user.ID)
+	if err != nil {
+		return err
+	}
+	digest.UnsubscribeURL = s.baseURL + "/digest/unsubscribe?token=" + url.QueryEscape(token)
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, digest); err != nil {
+		return err
+	}
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return err
+	}
+
+	subject := "Your week in Reminiscer"
+	if digest.NewCount > 0 {
+		subject = fmt.Sprintf("Your week in Reminiscer: %s", plural(digest.NewCount, "new quote", "new quotes"))
+	}
+
+	return s.transport.Send(&mail.Message{
+		From:    s.from,
+		To:      (&netmail.Address{Name: user.Username, Address: user.Email}).String(),
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + digest.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// toQuoteView converts a digest quote for the templates
+func toQuoteView(q *models.DigestQuote, loc *time.Location) *quoteView {
+	if q == nil {
+		return nil
+	}
+	return &quoteView{
+		Text:      q.Quote.Text,
+		Author:    q.Quote.Author,
+		Uploader:  q.Uploader,
+		Reactions: q.Reactions,
+		Date:      q.Quote.CreatedAt.In(loc),
+	}
+}
+
+// plural formats a count with the right form of a noun
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,71 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// funcs are shared by both templates
+var funcs = map[string]interface{}{
+	"plural": plural,
+}
+
+// textTemplate is the plain text body
+var textTemplate = template.Must(template.New("digest.txt").Funcs(funcs).Parse(`Hi {{.Username}},
+
+Here's what your groups remembered this week.
+{{range .Groups}}
+== {{.Name}} ==
+{{if .NewCount}}
+{{plural .NewCount "new quote" "new quotes"}}:
+{{range .NewQuotes}}
+  "{{.Text}}"{{if .Author}} - {{.Author}}{{end}} (added by {{.Uploader}})
+{{end}}{{if gt .NewCount (len .NewQuotes)}}  ...and {{.More}} more in the app
+{{end}}{{end}}{{with .MostReacted}}
+Most reacted ({{plural .Reactions "reaction" "reactions"}}):
+  "{{.Text}}"{{if .Author}} - {{.Author}}{{end}}
+{{end}}{{with .OnThisDay}}
+On this day in {{.Date.Year}}:
+  "{{.Text}}"{{if .Author}} - {{.Author}}{{end}}
+{{end}}{{end}}
+--
+You get this email once a week. Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+// htmlTemplate is the HTML body, styled inline because most mail clients drop style sheets
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Your week in Reminiscer</title></head>
+<body style="margin:0;padding:24px;background:#faf7f2;color:#222;font-family:Georgia,serif;">
+<div style="max-width:560px;margin:0 auto;">
+  <p style="font-family:sans-serif;">Hi {{.Username}},</p>
+  <p style="font-family:sans-serif;">Here's what your groups remembered this week.</p>
+{{- range .Groups}}
+  <h2 style="font-family:sans-serif;color:#d96c3f;border-bottom:1px solid #e5ded3;padding-bottom:4px;">{{.Name}}</h2>
+  {{- if .NewCount}}
+  <h3 style="font-family:sans-serif;font-size:14px;color:#77726b;">{{plural .NewCount "new quote" "new quotes"}}</h3>
+  {{- range .NewQuotes}}
+  <blockquote style="margin:0 0 12px;padding-left:12px;border-left:3px solid #d96c3f;white-space:pre-line;">{{.Text}}
+    <br><span style="font-family:sans-serif;font-size:13px;color:#77726b;">{{if .Author}}{{.Author}}, {{end}}added by {{.Uploader}}</span></blockquote>
+  {{- end}}
+  {{- if gt .NewCount (len .NewQuotes)}}
+  <p style="font-family:sans-serif;font-size:13px;color:#77726b;">…and {{.More}} more in the app</p>
+  {{- end}}
+  {{- end}}
+  {{- with .MostReacted}}
+  <h3 style="font-family:sans-serif;font-size:14px;color:#77726b;">Most reacted · {{plural .Reactions "reaction" "reactions"}}</h3>
+  <blockquote style="margin:0 0 12px;padding-left:12px;border-left:3px solid #d96c3f;white-space:pre-line;">{{.Text}}{{if .Author}}
+    <br><span style="font-family:sans-serif;font-size:13px;color:#77726b;">{{.Author}}</span>{{end}}</blockquote>
+  {{- end}}
+  {{- with .OnThisDay}}
+  <h3 style="font-family:sans-serif;font-size:14px;color:#77726b;">On this day in {{.Date.Year}}</h3>
+  <blockquote style="margin:0 0 12px;padding-left:12px;border-left:3px solid #d96c3f;white-space:pre-line;">{{.Text}}{{if .Author}}
+    <br><span style="font-family:sans-serif;font-size:13px;color:#77726b;">{{.Author}}</span>{{end}}</blockquote>
+  {{- end}}
+{{- end}}
+  <p style="font-family:sans-serif;font-size:12px;color:#9a948b;margin-top:32px;">You get this email once a week.
+    <a href="{{.UnsubscribeURL}}" style="color:#9a948b;">Unsubscribe</a></p>
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,72 @@
+// Package events passes things that happen in a group to whoever wants to react to them
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies what happened
+type Type string
+
+// Event types
+const (
+	QuoteCreated    Type = "quote_created"
+	QuoteUpdated    Type = "quote_updated"
+	ReactionAdded   Type = "reaction_added"
+	GroupCreated    Type = "group_created"
+	GroupInvite     Type = "group_invite"
+	GroupRenamed    Type = "group_renamed"
+	Mention         Type = "mention"
+	CapsuleUnlocked Type = "capsule_unlocked"
+)
+
+// Event is something that happened in a group
+type Event struct {
+	Type      Type
+	GroupID   string
+	ActorID   string   // User who caused the event, empty for events the server raises itself
+	QuoteID   string   // Quote the event is about, if any
+	UserID    string   // User the event is aimed at, for invites and mentions
+	Emoji     string   // Reaction, for ReactionAdded
+	Name      string   // Group's new name, for GroupCreated and GroupRenamed
+	Mentioned []string // Users mentioned in a new quote, who hear about it through Mention events instead
+	At        time.Time
+}
+
+// Bus delivers published events to every subscriber
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+// NewBus creates an event bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn to be called with every event published from now on
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish delivers an event to the subscribers in the order they subscribed.
+// Subscribers run on the publisher's goroutine. Publishing on a nil bus does nothing.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
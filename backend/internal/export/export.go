@@ -0,0 +1,277 @@
+// Package export writes a group's quotes to a ZIP archive.
+//
+// Every archive holds a manifest.json describing the group and one quotes file in the
+// chosen format. Quotes keep their IDs, timestamps, uploaders, reply context and reactions,
+// so JSON and CSV archives can be imported into another instance. The importer is credited with
+// every quote, other uploaders are kept by name and only the importer's own reactions come
+// back, since an archive can be edited before it is uploaded. Time capsules the exporter can't
+// open yet are listed without their text, so they can't be restored from the archive.
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the archive layout version recorded in the manifest
+const Version = 2
+
+// Archive formats
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+)
+
+// csvHeader is the column order of quotes.csv
+var csvHeader = []string{"id", "text", "author", "uploader_id", "uploader", "created_at", "updated_at", "unlock_at",
+	"sealed", "reply_author", "reply_text", "reactions"}
+
+// Member is a group member as recorded in the manifest
+type Member struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// Manifest describes the exported group
+type Manifest struct {
+	Version    int       `json:"version"`
+	Format     string    `json:"format"`
+	GroupID    string    `json:"group_id"`
+	GroupName  string    `json:"group_name"`
+	Timezone   string    `json:"timezone"`
+	ExportedAt time.Time `json:"exported_at"`
+	QuoteCount int       `json:"quote_count"`
+	Members    []Member  `json:"members"`
+}
+
+// Quote is a single exported quote
+type Quote struct {
+	ID         string     `json:"id"`
+	Text       string     `json:"text"`
+	Author     string     `json:"author"`
+	UploaderID string     `json:"uploader_id"`
+	Uploader   string     `json:"uploader"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UnlockAt   *time.Time `json:"unlock_at,omitempty"`
+	Sealed     bool       `json:"sealed,omitempty"`    // Time capsule the exporter can't open yet, its text is left out
+	ReplyTo    *Reply     `json:"reply_to,omitempty"`  // Message the quote answered, from chat imports
+	Reactions  []Reaction `json:"reactions,omitempty"` // Oldest first
+}
+
+// Reply is the message a quote was said in reply to
+type Reply struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+}
+
+// Reaction is a member's emoji reaction to an exported quote
+type Reaction struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Writer streams quotes into an archive as they are written
+type Writer struct {
+	zip      *zip.Writer
+	file     io.Writer
+	csv      *csv.Writer
+	loc      *time.Location // Zone Markdown dates are shown in
+	manifest Manifest
+}
+
+// ValidFormat reports whether format is a supported archive format
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatCSV, FormatMarkdown:
+		return true
+	}
+	return false
+}
+
+// NewWriter starts an archive on w in the manifest's format
+func NewWriter(w io.Writer, manifest Manifest) (*Writer, error) {
+	if !ValidFormat(manifest.Format) {
+		return nil, fmt.Errorf("unsupported export format %q", manifest.Format)
+	}
+
+	manifest.Version = Version
+	manifest.QuoteCount = 0
+
+	loc, err := time.LoadLocation(manifest.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	aw := &Writer{
+		zip:      zip.NewWriter(w),
+		loc:      loc,
+		manifest: manifest,
+	}
+
+	file, err := aw.zip.Create("quotes." + manifest.Format)
+	if err != nil {
+		return nil, err
+	}
+	aw.file = file
+
+	switch manifest.Format {
+	case FormatJSON:
+		_, err = io.WriteString(file, "[")
+	case FormatCSV:
+		aw.csv = csv.NewWriter(file)
+		err = aw.csv.Write(csvHeader)
+	case FormatMarkdown:
+		_, err = fmt.Fprintf(file, "# %s\n\nExported %s\n\n", manifest.GroupName, manifest.ExportedAt.In(loc).Format("2 January 2006"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// Write adds a quote to the archive
+func (w *Writer) Write(q *Quote) error {
+	var err error
+	switch w.manifest.Format {
+	case FormatJSON:
+		err = w.writeJSON(q)
+	case FormatCSV:
+		err = w.writeCSV(q)
+	case FormatMarkdown:
+		err = w.writeMarkdown(q)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.manifest.QuoteCount++
+	return nil
+}
+
+// Close finishes the quotes file, adds the manifest and closes the archive.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	switch w.manifest.Format {
+	case FormatJSON:
+		if _, err := io.WriteString(w.file, "\n]\n"); err != nil {
+			return err
+		}
+	case FormatCSV:
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if w.manifest.Members == nil {
+		w.manifest.Members = []Member{}
+	}
+
+	file, err := w.zip.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(w.manifest); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
+
+// writeJSON writes one element of the quotes array
+func (w *Writer) writeJSON(q *Quote) error {
+	b, err := json.MarshalIndent(q, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if w.manifest.QuoteCount == 0 {
+		separator = "\n  "
+	}
+
+	if _, err := io.WriteString(w.file, separator); err != nil {
+		return err
+	}
+	_, err = w.file.Write(b)
+	return err
+}
+
+// writeCSV writes a quote as a row of quotes.csv. Reactions go in one cell as a JSON array.
+func (w *Writer) writeCSV(q *Quote) error {
+	var replyAuthor, replyText, reactions string
+	if q.ReplyTo != nil {
+		replyAuthor, replyText = q.ReplyTo.Author, q.ReplyTo.Text
+	}
+	if len(q.Reactions) > 0 {
+		b, err := json.Marshal(q.Reactions)
+		if err != nil {
+			return err
+		}
+		reactions = string(b)
+	}
+
+	return w.csv.Write([]string{
+		q.ID,
+		q.Text,
+		q.Author,
+		q.UploaderID,
+		q.Uploader,
+		q.CreatedAt.Format(time.RFC3339Nano),
+		q.UpdatedAt.Format(time.RFC3339Nano),
+		formatOptionalTime(q.UnlockAt),
+		strconv.FormatBool(q.Sealed),
+		replyAuthor,
+		replyText,
+		reactions,
+	})
+}
+
+// writeMarkdown writes a quote as a blockquote with its attribution
+func (w *Writer) writeMarkdown(q *Quote) error {
+	var b strings.Builder
+	if q.Sealed {
+		fmt.Fprintf(&b, "> *Sealed time capsule, opens %s*\n", q.UnlockAt.In(w.loc).Format("2 January 2006"))
+	} else {
+		for _, line := range strings.Split(q.Text, "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " "))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+
+	author := q.Author
+	if author == "" {
+		author = "Unknown"
+	}
+	fmt.Fprintf(&b, "— %s, %s", author, q.CreatedAt.In(w.loc).Format("2 January 2006"))
+	if q.Uploader != "" {
+		fmt.Fprintf(&b, " (added by %s)", q.Uploader)
+	}
+	fmt.Fprintf(&b, "\n<!-- id: %s -->\n\n", q.ID)
+
+	_, err := io.WriteString(w.file, b.String())
+	return err
+}
+
+// formatOptionalTime formats t for CSV, leaving the cell empty when there is no time
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,178 @@
+// Package feed writes quotes as Atom and RSS 2.0 documents for feed readers
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Content types of the two formats
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+const (
+	atomNS = "http://www.w3.org/2005/Atom"
+	dcNS   = "http://purl.org/dc/elements/1.1/"
+)
+
+// Feed is a list of entries along with what identifies it
+type Feed struct {
+	ID          string // Stable and globally unique, e.g. a urn:uuid
+	Title       string
+	Description string
+	SelfURL     string // Where the document itself is fetched from
+	Link        string // The site the feed belongs to
+	Updated     time.Time
+	Entries     []Entry
+}
+
+// Entry is a single item in a feed
+type Entry struct {
+	ID        string // Stable and globally unique, readers use it to tell new entries from seen ones
+	Title     string
+	Content   string // Plain text
+	Author    string
+	Published time.Time
+	Updated   time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// rss is an RSS 2.0 document. The Atom namespace carries the self link RSS has no element for,
+// and Dublin Core the author, since RSS's own author element must be an email address.
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// Atom writes the feed as an Atom 1.0 document
+func Atom(w io.Writer, f *Feed) error {
+	doc := atomFeed{
+		XMLNS:   atomNS,
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfURL},
+			{Rel: "alternate", Type: "text/html", Href: f.Link},
+		},
+		Entries: make([]atomEntry, len(f.Entries)),
+	}
+
+	for i, e := range f.Entries {
+		doc.Entries[i] = atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Author:    atomAuthor{Name: e.Author},
+			Content:   atomContent{Type: "text", Text: e.Content},
+		}
+	}
+
+	return write(w, doc)
+}
+
+// RSS writes the feed as an RSS 2.0 document. RSS has no updated time for items, so an edited
+// entry keeps its guid and only its content changes.
+func RSS(w io.Writer, f *Feed) error {
+	doc := rss{
+		Version: "2.0",
+		AtomNS:  atomNS,
+		DCNS:    dcNS,
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Self:          atomLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfURL},
+			Items:         make([]rssItem, len(f.Entries)),
+		},
+	}
+
+	for i, e := range f.Entries {
+		doc.Channel.Items[i] = rssItem{
+			Title:       e.Title,
+			Description: e.Content,
+			Creator:     e.Author,
+			GUID:        rssGUID{ID: e.ID},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+		}
+	}
+
+	return write(w, doc)
+}
+
+// write encodes a document with the XML declaration in front
+func write(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
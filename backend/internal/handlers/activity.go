@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jamoowen/reminiscer/internal/activity"
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type ActivityHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewActivityHandler(store models.Store, authMid *middleware.AuthMiddleware) *ActivityHandler {
+	return &ActivityHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the activity feed routes
+func (h *ActivityHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/groups/:id/activity", h.Feed, h.authMid.Authenticate)
+}
+
+// Feed handles retrieving a page of a group's activity, newest first. Pages are cursor based,
+// pass the previous page's next_cursor as ?before= to continue.
+func (h *ActivityHandler) Feed(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	var before int64
+	if v := c.QueryParam("before"); v != "" {
+		var err error
+		before, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || before < 1 {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid cursor")
+		}
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 30
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	activities, err := h.store.Activity().List(groupID, before, limit)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve activity")
+	}
+
+	// Users and quotes repeat a lot within a page, so each is looked up once
+	lookup := usernameLookup(h.store)
+	usernames := make(map[string]string)
+	getUsernameFn := func(userID string) string {
+		if userID == "" {
+			return ""
+		}
+		name, ok := usernames[userID]
+		if !ok {
+			name = lookup(userID)
+			usernames[userID] = name
+		}
+		return name
+	}
+
+	quotes := make(map[string]*QuoteResponse)
+	getQuoteFn := func(quoteID string) *QuoteResponse {
+		if quoteID == "" {
+			return nil
+		}
+		response, ok := quotes[quoteID]
+		if !ok {
+			// Quotes deleted since are left out, the entry keeps its quote ID
+			if q, err := h.store.Quotes().GetByID(quoteID); err == nil {
+				response = toViewerQuoteResponse(q, getUsernameFn(q.UploaderID), user.ID)
+			}
+			quotes[quoteID] = response
+		}
+		return response
+	}
+
+	entries := activity.Compact(activities)
+	feed := &ActivityFeedResponse{Items: make([]*ActivityResponse, 0, len(entries))}
+	for _, entry := range entries {
+		feed.Items = append(feed.Items, toActivityResponse(entry, getUsernameFn, getQuoteFn))
+	}
+	if len(activities) == limit {
+		next := activities[len(activities)-1].ID
+		feed.NextCursor = &next
+	}
+
+	return api.SendSuccess(c, http.StatusOK, feed)
+}
+
+// toActivityResponse converts a feed entry to an ActivityResponse
+func toActivityResponse(entry *activity.Entry, getUsernameFn func(string) string, getQuoteFn func(string) *QuoteResponse) *ActivityResponse {
+	response := &ActivityResponse{
+		ID:        entry.ID,
+		Type:      entry.FeedType(),
+		CreatedAt: entry.CreatedAt,
+		ActorID:   entry.ActorID,
+		Actor:     getUsernameFn(entry.ActorID),
+		UserID:    entry.UserID,
+		User:      getUsernameFn(entry.UserID),
+		QuoteID:   entry.QuoteID,
+		Quote:     getQuoteFn(entry.QuoteID),
+		Emoji:     entry.Emoji,
+		Name:      entry.Name,
+		Count:     entry.Count,
+	}
+
+	if response.Type == activity.TypeReactions {
+		response.Emoji = ""
+		response.Emojis = entry.Emojis
+		for _, id := range entry.ActorIDs {
+			response.Actors = append(response.Actors, getUsernameFn(id))
+		}
+	}
+
+	return response
+}
@@ -61,16 +61,17 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to create user")
 	}
 
-	// Generate token
-	token, err := h.authMid.GenerateToken(user)
+	refreshToken, _, err := h.store.RefreshTokens().Create(user.ID, h.authMid.RefreshTokenTTL())
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create refresh token")
+	}
+
+	response, err := h.authResponse(user, refreshToken)
 	if err != nil {
 		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to generate token")
 	}
 
-	return api.SendSuccess(c, http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	return api.SendSuccess(c, http.StatusCreated, response)
 }
 
 // Login handles user authentication
@@ -98,16 +99,76 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		}
 	}
 
-	// Generate token
-	token, err := h.authMid.GenerateToken(user)
+	refreshToken, _, err := h.store.RefreshTokens().Create(user.ID, h.authMid.RefreshTokenTTL())
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create refresh token")
+	}
+
+	response, err := h.authResponse(user, refreshToken)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to generate token")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, response)
+}
+
+// Refresh handles trading a refresh token in for a new access token. The refresh token is
+// rotated too, the one sent stops working and the response carries its replacement.
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	refreshToken, record, err := h.store.RefreshTokens().Rotate(req.RefreshToken, h.authMid.RefreshTokenTTL())
+	if err != nil {
+		if errors.IsCode(err, errors.CodeInvalidToken) {
+			return api.SendError(c, http.StatusUnauthorized, errors.CodeInvalidToken, errors.GetMessage(err))
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to refresh token")
+	}
+
+	user, err := h.store.Users().GetByID(record.UserID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "User not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Error verifying user")
+	}
+
+	if !user.Authenticated {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "User not authenticated")
+	}
+
+	response, err := h.authResponse(user, refreshToken)
 	if err != nil {
 		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to generate token")
 	}
 
-	return api.SendSuccess(c, http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	return api.SendSuccess(c, http.StatusOK, response)
+}
+
+// Logout handles revoking a refresh token along with every token rotated from the same login.
+// Access tokens already issued keep working until they expire, which is minutes at most.
+func (h *AuthHandler) Logout(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	if err := h.store.RefreshTokens().RevokeFamily(req.RefreshToken); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to log out")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
 }
 
 // Me returns the current authenticated user
@@ -124,5 +185,22 @@ func (h *AuthHandler) Me(c echo.Context) error {
 func (h *AuthHandler) SetupRoutes(e *echo.Echo) {
 	e.POST("/auth/register", h.Register)
 	e.POST("/auth/login", h.Login)
+	e.POST("/auth/refresh", h.Refresh)
+	e.POST("/auth/logout", h.Logout)
 	e.GET("/auth/me", h.Me, h.authMid.Authenticate)
 }
+
+// authResponse pairs a new access token for the user with their refresh token
+func (h *AuthHandler) authResponse(user *models.User, refreshToken string) (*AuthResponse, error) {
+	token, err := h.authMid.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:        token,
+		ExpiresIn:    int(h.authMid.AccessTokenTTL().Seconds()),
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
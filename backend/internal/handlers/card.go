@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/card"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// cardCacheSize is how many rendered cards are kept in memory
+const cardCacheSize = 256
+
+type CardHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+	cache   *card.Cache
+}
+
+func NewCardHandler(store models.Store, authMid *middleware.AuthMiddleware) *CardHandler {
+	return &CardHandler{
+		store:   store,
+		authMid: authMid,
+		cache:   card.NewCache(cardCacheSize),
+	}
+}
+
+// SetupRoutes sets up the quote card routes
+func (h *CardHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/quotes/:id/card.png", h.GetCard, h.authMid.Authenticate)
+}
+
+// GetCard handles rendering a quote as a shareable PNG card
+func (h *CardHandler) GetCard(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quote ID is required")
+	}
+
+	theme := strings.ToLower(c.QueryParam("theme"))
+	if theme == "" {
+		theme = card.DefaultTheme
+	}
+	if _, ok := card.Themes[theme]; !ok {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unknown card theme")
+	}
+
+	size := strings.ToLower(c.QueryParam("size"))
+	if size == "" {
+		size = card.DefaultSize
+	}
+	if _, ok := card.Sizes[size]; !ok {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unknown card size")
+	}
+
+	quote, err := h.store.Quotes().GetByID(id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quote")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(quote.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	if quote.SealedFor(user.ID, time.Now()) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Quote is sealed until it unlocks")
+	}
+
+	png, etag, err := renderCard(h.cache, quote, groups[0].Name, theme, size)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to render card")
+	}
+
+	return sendCard(c, png, etag, "private, max-age=86400")
+}
+
+// sendCard writes a rendered card, answering revalidation requests with 304 Not Modified
+func sendCard(c echo.Context, png []byte, etag, cacheControl string) error {
+	c.Response().Header().Set("ETag", etag)
+	c.Response().Header().Set("Cache-Control", cacheControl)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// renderCard returns the card for a quote, from the cache if this revision was drawn before.
+// The ETag doubles as the cache key, so an edit to the quote or a group rename draws a new card.
+func renderCard(cache *card.Cache, quote *models.Quote, groupName, theme, size string) ([]byte, string, error) {
+	revision := fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%s", quote.ID, quote.UpdatedAt.UnixNano(), groupName, theme, size)
+	sum := sha1.Sum([]byte(revision))
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	if png, ok := cache.Get(etag); ok {
+		return png, etag, nil
+	}
+
+	var buf bytes.Buffer
+	err := card.Render(&buf, card.Card{
+		Text:   quote.Text,
+		Author: quote.Author,
+		Group:  groupName,
+	}, theme, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cache.Put(etag, buf.Bytes())
+	return buf.Bytes(), etag, nil
+}
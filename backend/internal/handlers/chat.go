@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// chatLinkCodeTTL is how long a user has to send a link code from a chat platform
+const chatLinkCodeTTL = 10 * time.Minute
+
+type ChatHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewChatHandler(store models.Store, authMid *middleware.AuthMiddleware) *ChatHandler {
+	return &ChatHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the routes for linking chat platform accounts
+func (h *ChatHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/me/chat/link-code", h.CreateLinkCode, h.authMid.Authenticate)
+	e.GET("/me/chat/accounts", h.ListAccounts, h.authMid.Authenticate)
+	e.DELETE("/me/chat/accounts/:platform", h.Unlink, h.authMid.Authenticate)
+}
+
+// CreateLinkCode handles issuing a one-time code the user sends from a chat platform,
+// e.g. `/quote link CODE` in Slack, to link their account there
+func (h *ChatHandler) CreateLinkCode(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	code, err := h.store.Chat().CreateLinkCode(user.ID, chatLinkCodeTTL)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create link code")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, code)
+}
+
+// ListAccounts handles retrieving the chat accounts linked to the user
+func (h *ChatHandler) ListAccounts(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	accounts, err := h.store.Chat().ListAccounts(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve accounts")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, accounts)
+}
+
+// Unlink handles unlinking the user's accounts on a chat platform
+func (h *ChatHandler) Unlink(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	platform := c.Param("platform")
+	if platform == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Platform is required")
+	}
+
+	if err := h.store.Chat().DeleteAccounts(user.ID, platform); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "No linked accounts")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to unlink accounts")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// matchGroups finds the groups a user named when connecting a chat: the group with that ID, or
+// failing that every group with that name
+func matchGroups(memberships []*models.Group, name string) []*models.Group {
+	var matches []*models.Group
+	for _, g := range memberships {
+		if g.GroupID == name {
+			return []*models.Group{g}
+		}
+		if strings.EqualFold(g.Name, name) {
+			matches = append(matches, g)
+		}
+	}
+	return matches
+}
+
+// channelLocked checks whether a user may connect a chat channel to a group. A connected channel
+// can only be moved by the member who connected it, or by someone in its group once that member
+// has left. When the user may not, the name of the group it is connected to is returned.
+func channelLocked(store models.Store, platform, teamID, channelID, userID string) (string, error) {
+	channel, err := store.Chat().GetChannel(platform, teamID, channelID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if channel.LinkedBy == userID {
+		return "", nil
+	}
+
+	groups, err := store.Groups().GetByGroupID(channel.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if isGroupMember(groups, channel.LinkedBy) || !isGroupMember(groups, userID) {
+		return groups[0].Name, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type DailyQuoteHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewDailyQuoteHandler(store models.Store, authMid *middleware.AuthMiddleware) *DailyQuoteHandler {
+	return &DailyQuoteHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the daily quote routes
+func (h *DailyQuoteHandler) SetupRoutes(e *echo.Echo) {
+	daily := e.Group("/groups/:id/daily", h.authMid.Authenticate)
+	daily.GET("", h.Get)
+	daily.GET("/history", h.History)
+}
+
+// Get handles retrieving the quote of the day for a group
+func (h *DailyQuoteHandler) Get(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	// The day rolls over at midnight in the group's timezone
+	loc, err := time.LoadLocation(groups[0].Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day := time.Now().In(loc).Format("2006-01-02")
+
+	daily, err := h.store.DailyQuotes().GetOrPick(groupID, day)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "No quotes found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to get daily quote")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, toDailyQuoteResponse(daily, usernameLookup(h.store)))
+}
+
+// History handles browsing past quotes of the day for a group
+func (h *DailyQuoteHandler) History(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	params := PaginationParams{}
+	if err := c.Bind(&params); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid query parameters")
+	}
+
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 50 {
+		params.Limit = 10
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	history, err := h.store.DailyQuotes().ListHistory(groupID, params.Page, params.Limit)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve daily quote history")
+	}
+
+	getUsernameFn := usernameLookup(h.store)
+	responses := make([]*DailyQuoteResponse, len(history))
+	for i, d := range history {
+		responses[i] = toDailyQuoteResponse(d, getUsernameFn)
+	}
+
+	return api.SendSuccess(c, http.StatusOK, responses)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type DeviceHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewDeviceHandler(store models.Store, authMid *middleware.AuthMiddleware) *DeviceHandler {
+	return &DeviceHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the push device routes
+func (h *DeviceHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/me/devices", h.List, h.authMid.Authenticate)
+	e.PUT("/me/devices/:id", h.Register, h.authMid.Authenticate)
+	e.DELETE("/me/devices/:id", h.Delete, h.authMid.Authenticate)
+}
+
+// List handles retrieving the user's registered devices
+func (h *DeviceHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	devices, err := h.store.Devices().ListByUser(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve devices")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, devices)
+}
+
+// Register handles storing or refreshing a device's APNs token. The app picks the device ID
+// and calls this whenever iOS hands it a token.
+func (h *DeviceHandler) Register(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	deviceID := c.Param("id")
+	if deviceID == "" || len(deviceID) > 100 {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Device ID is required")
+	}
+
+	var req RegisterDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	device := &models.Device{
+		UserID:   user.ID,
+		DeviceID: deviceID,
+		Platform: "ios",
+		Token:    strings.ToLower(req.Token),
+	}
+
+	if err := h.store.Devices().Register(device); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to register device")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, device)
+}
+
+// Delete handles unregistering a device, e.g. when the user signs out on it
+func (h *DeviceHandler) Delete(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	deviceID := c.Param("id")
+	if deviceID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Device ID is required")
+	}
+
+	if err := h.store.Devices().Delete(user.ID, deviceID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Device not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to delete device")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
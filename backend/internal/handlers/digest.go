@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// unsubscribePage confirms an unsubscribe. Following the link only shows the button, so
+// mail scanners that prefetch links don't unsubscribe anyone.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <meta name="robots" content="noindex">
+  <title>Weekly digest</title>
+  <style>
+    body { margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center;
+      background: #faf7f2; color: #222; font-family: sans-serif; }
+    main { max-width: 30rem; padding: 2rem; text-align: center; }
+    button { background: #d96c3f; color: #fff; border: 0; border-radius: 4px; padding: 0.75rem 1.5rem; font-size: 1rem; cursor: pointer; }
+  </style>
+</head>
+<body>
+  <main>
+  {{- if .Done}}
+    <p>You won't get the weekly digest any more. You can turn it back on in the app.</p>
+  {{- else}}
+    <p>Stop getting the weekly Reminiscer digest?</p>
+    <form method="post"><button type="submit">Unsubscribe</button></form>
+  {{- end}}
+  </main>
+</body>
+</html>
+`))
+
+type DigestHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewDigestHandler(store models.Store, authMid *middleware.AuthMiddleware) *DigestHandler {
+	return &DigestHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the digest routes. The unsubscribe routes are public, the token is the credential.
+func (h *DigestHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/digest/unsubscribe", h.UnsubscribePage)
+	e.POST("/digest/unsubscribe", h.Unsubscribe)
+	e.GET("/me/digest", h.Get, h.authMid.Authenticate)
+	e.PUT("/me/digest", h.Update, h.authMid.Authenticate)
+}
+
+// UnsubscribePage handles the unsubscribe link in a digest email
+func (h *DigestHandler) UnsubscribePage(c echo.Context) error {
+	if c.QueryParam("token") == "" {
+		return c.HTML(http.StatusNotFound, "<!DOCTYPE html><title>Not found</title><p>This unsubscribe link isn't valid.</p>")
+	}
+	return h.renderUnsubscribe(c, false)
+}
+
+// Unsubscribe handles the unsubscribe button and mail clients' one-click unsubscribe (RFC 8058)
+func (h *DigestHandler) Unsubscribe(c echo.Context) error {
+	if err := h.store.Digests().Unsubscribe(c.QueryParam("token")); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return c.HTML(http.StatusNotFound, "<!DOCTYPE html><title>Not found</title><p>This unsubscribe link isn't valid.</p>")
+		}
+		return c.HTML(http.StatusInternalServerError, "<!DOCTYPE html><title>Error</title><p>Something went wrong.</p>")
+	}
+	return h.renderUnsubscribe(c, true)
+}
+
+func (h *DigestHandler) renderUnsubscribe(c echo.Context, done bool) error {
+	var buf bytes.Buffer
+	if err := unsubscribePage.Execute(&buf, struct{ Done bool }{done}); err != nil {
+		return c.HTML(http.StatusInternalServerError, "<!DOCTYPE html><title>Error</title><p>Something went wrong.</p>")
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
+// Get handles retrieving whether the user gets the weekly digest
+func (h *DigestHandler) Get(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	subscribed, err := h.store.Digests().Subscribed(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve digest subscription")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, &DigestSubscriptionResponse{Subscribed: subscribed})
+}
+
+// Update handles turning the weekly digest on or off
+func (h *DigestHandler) Update(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	var req DigestSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	if err := h.store.Digests().SetSubscribed(user.ID, *req.Subscribed); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update digest subscription")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, &DigestSubscriptionResponse{Subscribed: *req.Subscribed})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/book"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/export"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// exportFlushEvery is how many quotes are written between flushes to the client
+const exportFlushEvery = 200
+
+// filenameUnsafe matches runs of characters left out of download file names
+var filenameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+type ExportHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewExportHandler(store models.Store, authMid *middleware.AuthMiddleware) *ExportHandler {
+	return &ExportHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the export routes
+func (h *ExportHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/groups/:id/export", h.Export, h.authMid.Authenticate)
+	e.GET("/groups/:id/book", h.Book, h.authMid.Authenticate)
+}
+
+// Export handles streaming a ZIP archive of every quote in a group
+func (h *ExportHandler) Export(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = export.FormatJSON
+	}
+	if !export.ValidFormat(format) {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unsupported export format, use json, csv or md")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	// Usernames are resolved up front rather than once per quote streamed
+	usernames := make(map[string]string)
+	members := make([]export.Member, 0, len(groups))
+	for _, g := range groups {
+		u, err := h.store.Users().GetByID(g.MemberID)
+		if err != nil || u == nil {
+			continue
+		}
+		usernames[u.ID] = u.Username
+		members = append(members, export.Member{ID: u.ID, Username: u.Username})
+	}
+
+	reactions, err := h.store.Reactions().ListByGroup(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reactions")
+	}
+
+	now := time.Now()
+	manifest := export.Manifest{
+		Format:     format,
+		GroupID:    groupID,
+		GroupName:  groups[0].Name,
+		Timezone:   groups[0].Timezone,
+		ExportedAt: now,
+		Members:    members,
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, exportFilename(groups[0].Name, format, "zip", now)))
+	res.WriteHeader(http.StatusOK)
+
+	// Once streaming has started errors can't be reported in the body, returning them
+	// lets the server log them and the client sees a truncated archive
+	archive, err := export.NewWriter(res, manifest)
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	err = h.store.Quotes().EachByGroup(groupID, func(q *models.Quote) error {
+		quote := &export.Quote{
+			ID:         q.ID,
+			Text:       q.Text,
+			Author:     q.Author,
+			UploaderID: q.UploaderID,
+			Uploader:   usernames[q.UploaderID],
+			CreatedAt:  q.CreatedAt,
+			UpdatedAt:  q.UpdatedAt,
+			UnlockAt:   q.UnlockAt,
+		}
+		// Everything about a capsule the exporter can't open stays out of the archive but its dates
+		if q.SealedFor(user.ID, now) {
+			quote.Text = ""
+			quote.Sealed = true
+		} else {
+			if q.ReplyTo != nil {
+				quote.ReplyTo = &export.Reply{Author: q.ReplyTo.Author, Text: q.ReplyTo.Text}
+			}
+			for _, r := range reactions[q.ID] {
+				quote.Reactions = append(quote.Reactions, export.Reaction{
+					UserID:    r.UserID,
+					Username:  usernames[r.UserID],
+					Emoji:     r.Emoji,
+					CreatedAt: r.CreatedAt,
+				})
+			}
+		}
+
+		if err := archive.Write(quote); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// Book handles rendering a group's quotes as a printable PDF or EPUB book
+func (h *ExportHandler) Book(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "epub" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unsupported book format, use pdf or epub")
+	}
+
+	order := strings.ToLower(c.QueryParam("order"))
+	if order == "" {
+		order = book.OrderChronological
+	}
+	if !book.ValidOrder(order) {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unsupported order, use chronological or person")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	quotes, err := h.store.Quotes().ListByGroup(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quotes")
+	}
+	if len(quotes) == 0 {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group has no quotes to print")
+	}
+
+	// Dates are printed as the group saw them
+	loc, err := time.LoadLocation(groups[0].Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	// The book is meant to be passed around, so sealed time capsules stay out of it even for their uploader
+	now := time.Now().In(loc)
+	bookQuotes := make([]book.Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if q.Sealed(now) {
+			continue
+		}
+		bookQuotes = append(bookQuotes, book.Quote{Text: q.Text, Author: q.Author, Date: q.CreatedAt.In(loc)})
+	}
+	if len(bookQuotes) == 0 {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group has no unsealed quotes to print")
+	}
+
+	title := strings.TrimSpace(c.QueryParam("title"))
+	if title == "" {
+		title = groups[0].Name
+	}
+
+	// Editions of a group's book share an identifier so readers treat them as the same book
+	id := uuid.NewSHA1(uuid.NameSpaceURL, []byte("reminiscer:groups/"+groupID+"/book")).String()
+
+	b, err := book.New(id, title, bookQuotes, order, now)
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, err.Error())
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/pdf"
+	if format == "epub" {
+		contentType = "application/epub+zip"
+		err = book.WriteEPUB(&buf, b)
+	} else {
+		err = book.WritePDF(&buf, b)
+	}
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to render book")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, exportFilename(title, "book", format, now)))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// exportFilename builds the download name, e.g. "book-club-json-2024-05-01.zip"
+func exportFilename(groupName, label, ext string, at time.Time) string {
+	name := strings.Trim(filenameUnsafe.ReplaceAllString(strings.ToLower(groupName), "-"), "-")
+	if name == "" {
+		name = "group"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", name, label, at.Format("2006-01-02"), ext)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/feed"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// feedLength is how many of a group's latest quotes a feed lists
+	feedLength = 50
+
+	// feedTitleLength is how much of a quote is used as an entry's title
+	feedTitleLength = 80
+)
+
+type FeedHandler struct {
+	store     models.Store
+	authMid   *middleware.AuthMiddleware
+	publicURL string
+}
+
+func NewFeedHandler(store models.Store, authMid *middleware.AuthMiddleware, publicURL string) *FeedHandler {
+	return &FeedHandler{
+		store:     store,
+		authMid:   authMid,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// SetupRoutes sets up the feed routes
+func (h *FeedHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/groups/:id/feeds", h.Create, h.authMid.Authenticate)
+	e.GET("/me/feeds", h.List, h.authMid.Authenticate)
+	e.DELETE("/feeds/:id", h.Revoke, h.authMid.Authenticate)
+
+	// Public, the token is the only credential since feed readers can't log in
+	e.GET("/feeds/:token/atom.xml", h.Atom)
+	e.GET("/feeds/:token/rss.xml", h.RSS)
+}
+
+// Create handles minting a private feed URL for a group
+func (h *FeedHandler) Create(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	token := &models.FeedToken{
+		GroupID:   groupID,
+		CreatedBy: user.ID,
+	}
+
+	if err := h.store.Feeds().Create(token); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create feed")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, h.toFeedTokenResponse(c, token))
+}
+
+// List handles retrieving the feeds created by the current user
+func (h *FeedHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	tokens, err := h.store.Feeds().ListByCreator(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve feeds")
+	}
+
+	responses := make([]*FeedTokenResponse, len(tokens))
+	for i, token := range tokens {
+		responses[i] = h.toFeedTokenResponse(c, token)
+	}
+
+	return api.SendSuccess(c, http.StatusOK, responses)
+}
+
+// Revoke handles deleting a feed so its URLs stop working
+func (h *FeedHandler) Revoke(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Feed ID is required")
+	}
+
+	if err := h.store.Feeds().Delete(id, user.ID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Feed not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to revoke feed")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// Atom handles serving a group's feed as Atom
+func (h *FeedHandler) Atom(c echo.Context) error {
+	return h.serve(c, feed.Atom, feed.AtomContentType)
+}
+
+// RSS handles serving a group's feed as RSS 2.0
+func (h *FeedHandler) RSS(c echo.Context) error {
+	return h.serve(c, feed.RSS, feed.RSSContentType)
+}
+
+// serve renders the feed in the URL with the given writer, answering conditional requests
+// from readers that already have the current version with 304 Not Modified
+func (h *FeedHandler) serve(c echo.Context, write func(io.Writer, *feed.Feed) error, contentType string) error {
+	f, err := h.loadFeed(c)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Feed not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve feed")
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, f); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to render feed")
+	}
+
+	sum := sha1.Sum(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	modified := f.Updated.UTC().Truncate(time.Second)
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", modified.Format(http.TimeFormat))
+	header.Set("Cache-Control", "private, no-cache")
+
+	if notModified(c.Request(), etag, modified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// loadFeed builds the feed for the token in the URL. A feed stops working once its creator
+// leaves the group, the same as if they had revoked it.
+func (h *FeedHandler) loadFeed(c echo.Context) (*feed.Feed, error) {
+	token, err := h.store.Feeds().GetByToken(c.Param("token"))
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(token.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if !isGroupMember(groups, token.CreatedBy) {
+		return nil, errors.NotFound("Feed not found")
+	}
+
+	// Anyone with the URL can read the feed, so capsules stay sealed even to the user who uploaded them
+	quotes, err := h.store.Quotes().List(models.QuoteFilter{GroupID: token.GroupID, Unlocked: true, Limit: feedLength})
+	if err != nil {
+		return nil, err
+	}
+
+	base := h.baseURL(c)
+	f := &feed.Feed{
+		ID:          "urn:uuid:" + token.ID,
+		Title:       groups[0].Name + " · Reminiscer",
+		Description: "The latest quotes in " + groups[0].Name,
+		SelfURL:     base + c.Request().URL.Path,
+		Link:        base,
+		Updated:     token.CreatedAt,
+		Entries:     make([]feed.Entry, len(quotes)),
+	}
+
+	for i, quote := range quotes {
+		// A capsule shows up in the feed when it unlocks, so that counts as its update
+		updated := quote.UpdatedAt
+		if quote.UnlockAt != nil && quote.UnlockAt.After(updated) {
+			updated = *quote.UnlockAt
+		}
+		if updated.After(f.Updated) {
+			f.Updated = updated
+		}
+
+		author := strings.TrimSpace(quote.Author)
+		if author == "" {
+			author = "Unknown"
+		}
+
+		f.Entries[i] = feed.Entry{
+			ID:        "urn:uuid:" + quote.ID,
+			Title:     "“" + excerpt(quote.Text, feedTitleLength) + "”",
+			Content:   quote.Text,
+			Author:    author,
+			Published: quote.CreatedAt,
+			Updated:   updated,
+		}
+	}
+
+	return f, nil
+}
+
+// notModified reports whether a conditional request's copy of the feed is current. If-None-Match
+// wins when both are sent, since a deleted quote changes the ETag but not the last modified time.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
+
+// baseURL is where public links point, the request's own host if no public URL is configured
+func (h *FeedHandler) baseURL(c echo.Context) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+// toFeedTokenResponse converts a models.FeedToken to a FeedTokenResponse
+func (h *FeedHandler) toFeedTokenResponse(c echo.Context, token *models.FeedToken) *FeedTokenResponse {
+	base := h.baseURL(c) + "/feeds/" + token.Token
+	return &FeedTokenResponse{
+		ID:        token.ID,
+		Token:     token.Token,
+		GroupID:   token.GroupID,
+		AtomURL:   base + "/atom.xml",
+		RSSURL:    base + "/rss.xml",
+		CreatedAt: token.CreatedAt,
+	}
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/jamoowen/reminiscer/internal/api"
 	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
 	"github.com/jamoowen/reminiscer/internal/middleware"
 	"github.com/jamoowen/reminiscer/internal/models"
 	"github.com/labstack/echo/v4"
@@ -13,12 +15,14 @@ import (
 type GroupHandler struct {
 	store   models.Store
 	authMid *middleware.AuthMiddleware
+	bus     *events.Bus
 }
 
-func NewGroupHandler(store models.Store, authMid *middleware.AuthMiddleware) *GroupHandler {
+func NewGroupHandler(store models.Store, authMid *middleware.AuthMiddleware, bus *events.Bus) *GroupHandler {
 	return &GroupHandler{
 		store:   store,
 		authMid: authMid,
+		bus:     bus,
 	}
 }
 
@@ -38,6 +42,12 @@ func (h *GroupHandler) Create(c echo.Context) error {
 		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
 	}
 
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid timezone")
+		}
+	}
+
 	// Verify all members exist
 	for _, memberID := range req.Members {
 		member, err := h.store.Users().GetByID(memberID)
@@ -71,6 +81,7 @@ func (h *GroupHandler) Create(c echo.Context) error {
 			GroupID:  req.GroupID,
 			Name:     req.Name,
 			MemberID: memberID,
+			Timezone: req.Timezone,
 		}
 		if err := h.store.Groups().Create(group); err != nil {
 			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create group")
@@ -78,6 +89,24 @@ func (h *GroupHandler) Create(c echo.Context) error {
 		groups = append(groups, group)
 	}
 
+	h.bus.Publish(events.Event{
+		Type:    events.GroupCreated,
+		GroupID: req.GroupID,
+		ActorID: user.ID,
+		Name:    req.Name,
+	})
+	for _, g := range groups {
+		if g.MemberID == user.ID {
+			continue
+		}
+		h.bus.Publish(events.Event{
+			Type:    events.GroupInvite,
+			GroupID: g.GroupID,
+			ActorID: user.ID,
+			UserID:  g.MemberID,
+		})
+	}
+
 	// Get username lookup function
 	getUsernameFn := func(userID string) string {
 		u, err := h.store.Users().GetByID(userID)
@@ -149,6 +178,12 @@ func (h *GroupHandler) Update(c echo.Context) error {
 		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
 	}
 
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid timezone")
+		}
+	}
+
 	// Check if user is a member of the group
 	groups, err := h.store.Groups().GetByGroupID(groupID)
 	if err != nil {
@@ -183,14 +218,28 @@ func (h *GroupHandler) Update(c echo.Context) error {
 		}
 	}
 
+	renamed := req.Name != groups[0].Name
+
 	// Update each group entry
 	for _, g := range groups {
 		g.Name = req.Name
+		if req.Timezone != "" {
+			g.Timezone = req.Timezone
+		}
 		if err := h.store.Groups().Update(g); err != nil {
 			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update group")
 		}
 	}
 
+	if renamed {
+		h.bus.Publish(events.Event{
+			Type:    events.GroupRenamed,
+			GroupID: groupID,
+			ActorID: user.ID,
+			Name:    req.Name,
+		})
+	}
+
 	// Get updated group
 	updatedGroups, err := h.store.Groups().GetByGroupID(groupID)
 	if err != nil {
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/importer"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// maxImportSize is the largest import file accepted, in bytes
+const maxImportSize = 10 << 20
+
+type ImportHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewImportHandler(store models.Store, authMid *middleware.AuthMiddleware) *ImportHandler {
+	return &ImportHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the import routes
+func (h *ImportHandler) SetupRoutes(e *echo.Echo) {
+	groups := e.Group("/groups/:id", h.authMid.Authenticate)
+	groups.POST("/import", h.Import)
+	groups.POST("/import/chat", h.PreviewChat)
+	groups.GET("/import/previews/:previewId", h.GetPreview)
+	groups.POST("/import/previews/:previewId/commit", h.CommitPreview)
+	groups.DELETE("/import/previews/:previewId", h.DeletePreview)
+	groups.GET("/imports", h.List)
+	groups.DELETE("/imports/:importId", h.Undo)
+}
+
+// Import handles bulk importing quotes from a CSV or JSON file
+func (h *ImportHandler) Import(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	// Only bind the query string, the body holds the file itself
+	var params ImportParams
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &params); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid query parameters")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	body, filename, err := importBody(c)
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Import file is required")
+	}
+	defer body.Close()
+
+	format := importFormat(params.Format, c.Request().Header.Get(echo.HeaderContentType), filename)
+
+	// Read one byte past the limit so an oversize file is refused rather than cut short
+	data, err := io.ReadAll(io.LimitReader(body, maxImportSize+1))
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Failed to read import file")
+	}
+	if len(data) > maxImportSize {
+		return api.SendError(c, http.StatusRequestEntityTooLarge, errors.CodeInvalidInput,
+			fmt.Sprintf("Import file is too large, the limit is %d MB", maxImportSize>>20))
+	}
+
+	var rows []importer.Row
+	var rowErrors []importer.RowError
+	reader := bytes.NewReader(data)
+	switch format {
+	case "csv":
+		rows, rowErrors, err = importer.ParseCSV(reader, importer.CSVMapping{
+			Text:      params.TextColumn,
+			Author:    params.AuthorColumn,
+			CreatedAt: params.DateColumn,
+		})
+	case "json":
+		rows, rowErrors, err = importer.ParseJSON(reader)
+	case "zip":
+		rows, rowErrors, err = importer.ParseArchive(data)
+	default:
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unsupported import format, use csv, json or zip")
+	}
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Failed to parse import: "+err.Error())
+	}
+
+	_, err = h.runImport(c, user, groups, format, rows, rowErrors, params)
+	return err
+}
+
+// PreviewChat handles parsing a chat export so the user can pick messages to import
+func (h *ImportHandler) PreviewChat(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	var params ChatImportParams
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &params); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid query parameters")
+	}
+
+	parser, err := importer.NewChatParser(params.Format, importer.ChatOptions{
+		DateOrder: importer.DateOrder(strings.ToLower(params.DateOrder)),
+	})
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unsupported chat format")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	body, _, err := importBody(c)
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Chat export is required")
+	}
+	defer body.Close()
+
+	data, err := importer.ReadChatExport(body)
+	if err != nil {
+		if stderrors.Is(err, importer.ErrTooLarge) {
+			return api.SendError(c, http.StatusRequestEntityTooLarge, errors.CodeInvalidInput, err.Error())
+		}
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, err.Error())
+	}
+
+	// Exports carry local times without a zone, so read them in the group's timezone
+	loc, err := time.LoadLocation(groups[0].Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	messages, err := parser.Parse(data, loc)
+	if err != nil {
+		if stderrors.Is(err, importer.ErrTooLarge) {
+			return api.SendError(c, http.StatusRequestEntityTooLarge, errors.CodeInvalidInput, err.Error())
+		}
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Failed to parse chat export: "+err.Error())
+	}
+
+	payload, err := json.Marshal(messages)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to store chat export")
+	}
+
+	preview := &models.ImportPreview{
+		GroupID:   groupID,
+		Source:    parser.Name(),
+		Payload:   payload,
+		CreatedBy: user.ID,
+	}
+
+	if err := h.store.Quotes().CreateImportPreview(preview); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to store chat export")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, toImportPreviewResponse(preview, messages))
+}
+
+// GetPreview handles retrieving a parsed chat export
+func (h *ImportHandler) GetPreview(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	preview, messages, err := h.loadPreview(c, user)
+	if err != nil || preview == nil {
+		return err
+	}
+
+	return api.SendSuccess(c, http.StatusOK, toImportPreviewResponse(preview, messages))
+}
+
+// CommitPreview handles importing the chosen messages from a parsed chat export
+func (h *ImportHandler) CommitPreview(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	var req CommitImportRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	preview, messages, err := h.loadPreview(c, user)
+	if err != nil || preview == nil {
+		return err
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(preview.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	// Senders can be mapped to a free-text person or to a member of the group
+	mapping := make(importer.SenderMapping, len(req.Senders))
+	for sender, target := range req.Senders {
+		if target.UserID == "" {
+			mapping[sender] = target.Author
+			continue
+		}
+
+		if !isGroupMember(groups, target.UserID) {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Mapped user is not a member of this group")
+		}
+		member, err := h.store.Users().GetByID(target.UserID)
+		if err != nil {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to verify mapped user")
+		}
+		mapping[sender] = member.Username
+	}
+
+	rows, rowErrors := importer.SelectMessages(messages, req.MessageIDs, mapping)
+
+	committed, err := h.runImport(c, user, groups, preview.Source, rows, rowErrors, ImportParams{
+		DryRun:          req.DryRun,
+		SkipInvalid:     req.SkipInvalid,
+		AllowDuplicates: req.AllowDuplicates,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The preview has served its purpose once its quotes exist
+	if committed {
+		if err := h.store.Quotes().DeleteImportPreview(preview.ID); err != nil && !errors.IsCode(err, errors.CodeNotFound) {
+			c.Logger().Errorf("failed to delete import preview %s: %v", preview.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeletePreview handles discarding a parsed chat export
+func (h *ImportHandler) DeletePreview(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	preview, _, err := h.loadPreview(c, user)
+	if err != nil || preview == nil {
+		return err
+	}
+
+	if err := h.store.Quotes().DeleteImportPreview(preview.ID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Import preview not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to delete import preview")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// loadPreview fetches the preview in the path if it belongs to the user and group.
+// It returns a nil preview once an error response has been sent.
+func (h *ImportHandler) loadPreview(c echo.Context, user *models.User) (*models.ImportPreview, []importer.Message, error) {
+	groupID := c.Param("id")
+	previewID := c.Param("previewId")
+	if groupID == "" || previewID == "" {
+		return nil, nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID and preview ID are required")
+	}
+
+	preview, err := h.store.Quotes().GetImportPreview(previewID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Import preview not found")
+		}
+		return nil, nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve import preview")
+	}
+
+	// Previews are private to the member who uploaded the export
+	if preview.GroupID != groupID || preview.CreatedBy != user.ID {
+		return nil, nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Import preview not found")
+	}
+
+	var messages []importer.Message
+	if err := json.Unmarshal(preview.Payload, &messages); err != nil {
+		return nil, nil, api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to read import preview")
+	}
+
+	return preview, messages, nil
+}
+
+// List handles retrieving the imports made into a group
+func (h *ImportHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	imports, err := h.store.Quotes().ListImports(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve imports")
+	}
+	if imports == nil {
+		imports = []*models.Import{}
+	}
+
+	return api.SendSuccess(c, http.StatusOK, imports)
+}
+
+// Undo handles deleting every quote created by an import
+func (h *ImportHandler) Undo(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	importID := c.Param("importId")
+	if groupID == "" || importID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID and import ID are required")
+	}
+
+	imp, err := h.store.Quotes().GetImport(importID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Import not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve import")
+	}
+
+	if imp.GroupID != groupID {
+		return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Import not found")
+	}
+
+	if imp.CreatedBy != user.ID {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not authorized to undo this import")
+	}
+
+	if err := h.store.Quotes().UndoImport(importID); err != nil {
+		if errors.IsCode(err, errors.CodeInvalidInput) {
+			return api.SendError(c, http.StatusConflict, errors.CodeInvalidInput, "Import has already been undone")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to undo import")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// runImport runs the import pipeline over parsed rows and sends the response. It reports whether quotes were created.
+func (h *ImportHandler) runImport(c echo.Context, user *models.User, groups []*models.Group, source string, rows []importer.Row, rowErrors []importer.RowError, params ImportParams) (bool, error) {
+	// Quotes and reactions from an export go back to the members who made them
+	members := make(map[string]string, len(groups))
+	for _, g := range groups {
+		if u, err := h.store.Users().GetByID(g.MemberID); err == nil && u != nil {
+			members[u.ID] = u.Username
+		}
+	}
+
+	outcome, err := importer.Run(h.store.Quotes(), rows, rowErrors, importer.Options{
+		GroupID:         groups[0].GroupID,
+		UploaderID:      user.ID,
+		Members:         members,
+		Source:          source,
+		DryRun:          params.DryRun,
+		SkipInvalid:     params.SkipInvalid,
+		AllowDuplicates: params.AllowDuplicates,
+	})
+	if outcome == nil {
+		return false, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, errors.GetMessage(err))
+	}
+
+	result := &ImportResult{
+		DryRun:    params.DryRun,
+		TotalRows: len(rows) + countParseErrors(outcome.Errors, rows),
+		ValidRows: len(outcome.Quotes),
+		Errors:    outcome.Errors,
+	}
+
+	if err != nil {
+		if errors.IsCode(err, errors.CodeInvalidInput) {
+			return false, api.SendErrorWithData(c, http.StatusBadRequest, errors.CodeInvalidInput, errors.GetMessage(err), result)
+		}
+		return false, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, errors.GetMessage(err))
+	}
+
+	if params.DryRun {
+		return false, api.SendSuccess(c, http.StatusOK, result)
+	}
+
+	result.ImportID = outcome.Import.ID
+	result.Imported = outcome.Import.QuoteCount
+
+	return true, api.SendSuccess(c, http.StatusCreated, result)
+}
+
+// countParseErrors counts errors for rows that never made it through parsing
+func countParseErrors(rowErrors []importer.RowError, rows []importer.Row) int {
+	parsed := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		parsed[row.Row] = true
+	}
+
+	unparsed := make(map[int]bool)
+	for _, e := range rowErrors {
+		if !parsed[e.Row] {
+			unparsed[e.Row] = true
+		}
+	}
+
+	return len(unparsed)
+}
+
+// importBody returns the uploaded file from a multipart form, or the raw request body
+func importBody(c echo.Context) (io.ReadCloser, string, error) {
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	if strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, fileHeader.Filename, nil
+	}
+
+	if c.Request().Body == nil {
+		return nil, "", fmt.Errorf("empty body")
+	}
+
+	return c.Request().Body, "", nil
+}
+
+// importFormat picks the import format from the explicit parameter, the file name or the content type
+func importFormat(format, contentType, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); ext != "" {
+		return ext
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case echo.MIMEApplicationJSON:
+		return "json"
+	case "application/zip", "application/x-zip-compressed":
+		return "zip"
+	}
+
+	return ""
+}
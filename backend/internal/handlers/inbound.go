@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/attribution"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/inbound"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// subjectPrefixes are the markers mail clients put in front of replied and forwarded subjects
+var subjectPrefixes = regexp.MustCompile(`(?i)^((re|fwd?|aw|wg)\s*:\s*)+`)
+
+type InboundHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+	bus     *events.Bus
+	secret  string
+	domain  string
+}
+
+// NewInboundHandler creates a handler for quotes sent by email. secret is shared with the mail
+// relay that posts emails in, domain is the domain group addresses are at.
+func NewInboundHandler(store models.Store, authMid *middleware.AuthMiddleware, bus *events.Bus, secret, domain string) *InboundHandler {
+	return &InboundHandler{
+		store:   store,
+		authMid: authMid,
+		bus:     bus,
+		secret:  secret,
+		domain:  strings.ToLower(domain),
+	}
+}
+
+// SetupRoutes sets up the inbound email routes
+func (h *InboundHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/inbound/email", h.Receive)
+	e.GET("/groups/:id/inbound-address", h.GetAddress, h.authMid.Authenticate)
+	e.POST("/groups/:id/inbound-address/rotate", h.RotateAddress, h.authMid.Authenticate)
+}
+
+// Receive handles a raw MIME email posted by the mail relay. The quote is added to the group
+// whose address it was sent to, on behalf of the member whose email address sent it.
+func (h *InboundHandler) Receive(c echo.Context) error {
+	token := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Invalid relay secret")
+	}
+
+	// Read one byte past the limit so an oversize email is refused rather than cut short
+	raw, err := io.ReadAll(io.LimitReader(c.Request().Body, inbound.MaxSize+1))
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Failed to read email")
+	}
+	if len(raw) > inbound.MaxSize {
+		return api.SendError(c, http.StatusRequestEntityTooLarge, errors.CodeInvalidInput, "Email is too large")
+	}
+
+	msg, err := inbound.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid email")
+	}
+
+	groupID := ""
+	for _, recipient := range msg.Recipients {
+		token := inbound.Token(recipient, h.domain)
+		if token == "" {
+			continue
+		}
+		groupID, err = h.store.Inbound().GroupForToken(token)
+		if err == nil {
+			break
+		}
+		if !errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to look up recipient")
+		}
+	}
+	if groupID == "" {
+		return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Unknown recipient address")
+	}
+
+	if !msg.SenderVerified() {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Sender could not be verified")
+	}
+
+	user, err := h.sender(msg.From.Address)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to look up sender")
+	}
+	if user == nil {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Sender is not a Reminiscer user")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Unknown recipient address")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Sender is not a member of this group")
+	}
+
+	body, forwardedFrom := inbound.Extract(msg.Text)
+	text, author, _ := attribution.Parse(body)
+	if text == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "No quote found in email")
+	}
+
+	// Without an attribution in the body, a forward is attributed to whoever sent it on and
+	// anything else to its subject
+	if author == "" {
+		author = forwardedFrom
+	}
+	if author == "" {
+		author = strings.TrimSpace(subjectPrefixes.ReplaceAllString(msg.Subject, ""))
+	}
+	if author == "" {
+		author = "Unknown"
+	}
+
+	quote := &models.Quote{
+		Text:       text,
+		Author:     author,
+		UploaderID: user.ID,
+		GroupID:    groupID,
+	}
+
+	// Relays retry deliveries they didn't see succeed. The Message-ID is claimed along with the
+	// quote, so a retry is answered with the quote the first delivery added. This only runs once
+	// the sender checks out, so a Message-ID can't be used to read a quote back.
+	if msg.MessageID == "" {
+		err = h.store.Quotes().Create(quote)
+	} else {
+		var quoteID string
+		quoteID, err = h.store.Inbound().CreateQuote(msg.MessageID, quote)
+		if errors.IsCode(err, errors.CodeAlreadyExists) {
+			existing, err := h.store.Quotes().GetByID(quoteID)
+			if errors.IsCode(err, errors.CodeNotFound) {
+				// Deleted since, the email was still handled
+				return api.SendSuccess(c, http.StatusOK, nil)
+			}
+			if err != nil {
+				return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quote")
+			}
+			return api.SendSuccess(c, http.StatusOK, toQuoteResponse(existing, usernameLookup(h.store)(existing.UploaderID)))
+		}
+	}
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create quote")
+	}
+
+	publishCreated(h.store, h.bus, quote, groups)
+
+	return api.SendSuccess(c, http.StatusCreated, toQuoteResponse(quote, user.Username))
+}
+
+// GetAddress handles retrieving the address a group receives quotes by email at
+func (h *InboundHandler) GetAddress(c echo.Context) error {
+	groupID, err := h.memberGroup(c)
+	if groupID == "" {
+		return err
+	}
+
+	token, err := h.store.Inbound().AddressToken(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve inbound address")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, h.addressResponse(token))
+}
+
+// RotateAddress handles replacing a group's inbound address, for when the old one was shared too widely
+func (h *InboundHandler) RotateAddress(c echo.Context) error {
+	groupID, err := h.memberGroup(c)
+	if groupID == "" {
+		return err
+	}
+
+	token, err := h.store.Inbound().RotateAddressToken(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to rotate inbound address")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, h.addressResponse(token))
+}
+
+// sender looks up the user an email came from. Addresses are matched as registered, then lowercased.
+func (h *InboundHandler) sender(address string) (*models.User, error) {
+	user, err := h.store.Users().GetByEmail(address)
+	if err != nil || user != nil {
+		return user, err
+	}
+	if lower := strings.ToLower(address); lower != address {
+		return h.store.Users().GetByEmail(lower)
+	}
+	return nil, nil
+}
+
+// memberGroup returns the ID of the group named in the URL if the user is a member. When they
+// aren't, the error response is sent and "" is returned along with the result of sending it.
+func (h *InboundHandler) memberGroup(c echo.Context) (string, error) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return "", api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return "", api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return "", api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return "", api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return "", api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	return groupID, nil
+}
+
+// addressResponse builds the response for a group's inbound address token
+func (h *InboundHandler) addressResponse(token string) *InboundAddressResponse {
+	response := &InboundAddressResponse{Token: token}
+	if h.domain != "" {
+		response.Address = token + "@" + h.domain
+	}
+	return response
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewNotificationHandler(store models.Store, authMid *middleware.AuthMiddleware) *NotificationHandler {
+	return &NotificationHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the notification routes
+func (h *NotificationHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/me/notifications", h.List, h.authMid.Authenticate)
+	e.POST("/me/notifications/read", h.MarkRead, h.authMid.Authenticate)
+	e.GET("/groups/:id/notification-preferences", h.GetPreferences, h.authMid.Authenticate)
+	e.PUT("/groups/:id/notification-preferences", h.UpdatePreferences, h.authMid.Authenticate)
+}
+
+// List handles retrieving the user's notifications, newest first
+func (h *NotificationHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(c.QueryParam("unread"))
+
+	notifications, err := h.store.Notifications().List(user.ID, unreadOnly, page, limit)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve notifications")
+	}
+
+	unread, err := h.store.Notifications().UnreadCount(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to count notifications")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, &NotificationListResponse{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	})
+}
+
+// MarkRead handles marking notifications as read, every unread one when no IDs are given
+func (h *NotificationHandler) MarkRead(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	var req MarkReadRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	var marked int
+	var err error
+	if len(req.IDs) == 0 {
+		marked, err = h.store.Notifications().MarkAllRead(user.ID)
+	} else {
+		marked, err = h.store.Notifications().MarkRead(user.ID, req.IDs)
+	}
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to mark notifications read")
+	}
+
+	unread, err := h.store.Notifications().UnreadCount(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to count notifications")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, &MarkReadResponse{
+		Marked:      marked,
+		UnreadCount: unread,
+	})
+}
+
+// GetPreferences handles retrieving what the user is notified about in a group
+func (h *NotificationHandler) GetPreferences(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	prefs, err := h.store.Notifications().GetPreferences(user.ID, groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve notification preferences")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles changing what the user is notified about in a group
+func (h *NotificationHandler) UpdatePreferences(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	prefs, err := h.store.Notifications().GetPreferences(user.ID, groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve notification preferences")
+	}
+
+	if req.Quotes != nil {
+		prefs.Quotes = *req.Quotes
+	}
+	if req.Mentions != nil {
+		prefs.Mentions = *req.Mentions
+	}
+	if req.Reactions != nil {
+		prefs.Reactions = *req.Reactions
+	}
+	if req.Capsules != nil {
+		prefs.Capsules = *req.Capsules
+	}
+
+	if err := h.store.Notifications().SavePreferences(prefs); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to save notification preferences")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, prefs)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/quiz"
+	"github.com/labstack/echo/v4"
+)
+
+// quizLeaderboardLimit is the number of players shown on a group leaderboard
+const quizLeaderboardLimit = 20
+
+type QuizHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewQuizHandler(store models.Store, authMid *middleware.AuthMiddleware) *QuizHandler {
+	return &QuizHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the quiz game routes
+func (h *QuizHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/groups/:id/quiz/rounds", h.NewRound, h.authMid.Authenticate)
+	e.POST("/quiz/rounds/:id/answer", h.Answer, h.authMid.Authenticate)
+	e.GET("/groups/:id/quiz/score", h.GetScore, h.authMid.Authenticate)
+	e.GET("/groups/:id/quiz/leaderboard", h.GetLeaderboard, h.authMid.Authenticate)
+}
+
+// NewRound handles dealing the player a "Who said it?" question. A player has at most
+// one open round per group, asking again returns it so questions can't be skipped.
+func (h *QuizHandler) NewRound(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	open, err := h.store.Quiz().GetOpenRound(groupID, user.ID)
+	if err == nil {
+		return api.SendSuccess(c, http.StatusOK, toQuizRoundResponse(open))
+	}
+	if !errors.IsCode(err, errors.CodeNotFound) {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quiz round")
+	}
+
+	authors, err := h.store.Quotes().AuthorCounts(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve authors")
+	}
+	if len(authors) < 2 {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quotes from at least two authors are needed to play")
+	}
+
+	quote, err := h.store.Quotes().GetRandom(models.QuoteFilter{
+		GroupID:   groupID,
+		VisibleTo: user.ID,
+		HasAuthor: true,
+	})
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group has no quotes to play with")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to pick a quote")
+	}
+
+	choices, err := quiz.Choices(quote.Author, authors)
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quotes from at least two authors are needed to play")
+	}
+
+	round := &models.QuizRound{
+		GroupID: groupID,
+		UserID:  user.ID,
+		QuoteID: quote.ID,
+		Choices: choices,
+		Answer:  quote.Author,
+		Quote:   quote,
+	}
+
+	if err := h.store.Quiz().CreateRound(round); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create quiz round")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, toQuizRoundResponse(round))
+}
+
+// Answer handles scoring the player's choice for a round and revealing the answer
+func (h *QuizHandler) Answer(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Round ID is required")
+	}
+
+	var req QuizAnswerRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	round, err := h.store.Quiz().GetRound(id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quiz round not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quiz round")
+	}
+
+	// Other players' rounds are hidden rather than forbidden so round IDs can't be probed
+	if round.UserID != user.ID {
+		return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quiz round not found")
+	}
+
+	if round.AnsweredAt != nil {
+		return api.SendError(c, http.StatusConflict, errors.CodeAlreadyExists, "Quiz round has already been answered")
+	}
+
+	offered := false
+	for _, choice := range round.Choices {
+		if choice == req.Choice {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Choice is not one of the options")
+	}
+
+	score, err := h.store.Quiz().AnswerRound(round, req.Choice)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeInvalidInput) {
+			return api.SendError(c, http.StatusConflict, errors.CodeAlreadyExists, "Quiz round has already been answered")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to record answer")
+	}
+	score.Username = user.Username
+
+	return api.SendSuccess(c, http.StatusOK, &QuizAnswerResponse{
+		Correct: round.Correct,
+		Chosen:  round.Chosen,
+		Answer:  round.Answer,
+		Points:  round.Points,
+		Quote:   toQuoteResponse(round.Quote, usernameLookup(h.store)(round.Quote.UploaderID)),
+		Score:   score,
+	})
+}
+
+// GetScore handles retrieving the current player's quiz totals in a group
+func (h *QuizHandler) GetScore(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	score, err := h.store.Quiz().GetScore(groupID, user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quiz score")
+	}
+	score.Username = user.Username
+
+	return api.SendSuccess(c, http.StatusOK, score)
+}
+
+// GetLeaderboard handles retrieving the top quiz players in a group
+func (h *QuizHandler) GetLeaderboard(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	scores, err := h.store.Quiz().Leaderboard(groupID, quizLeaderboardLimit)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve leaderboard")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, scores)
+}
@@ -2,23 +2,30 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/jamoowen/reminiscer/internal/api"
 	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
 	"github.com/jamoowen/reminiscer/internal/middleware"
 	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/notify"
+	"github.com/jamoowen/reminiscer/internal/similarity"
 	"github.com/labstack/echo/v4"
 )
 
 type QuoteHandler struct {
 	store   models.Store
 	authMid *middleware.AuthMiddleware
+	bus     *events.Bus
 }
 
-func NewQuoteHandler(store models.Store, authMid *middleware.AuthMiddleware) *QuoteHandler {
+func NewQuoteHandler(store models.Store, authMid *middleware.AuthMiddleware, bus *events.Bus) *QuoteHandler {
 	return &QuoteHandler{
 		store:   store,
 		authMid: authMid,
+		bus:     bus,
 	}
 }
 
@@ -48,6 +55,10 @@ func (h *QuoteHandler) Create(c echo.Context) error {
 		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
 	}
 
+	if req.UnlockAt != nil && !req.UnlockAt.After(time.Now()) {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unlock time must be in the future")
+	}
+
 	// Verify group exists and user is a member
 	groups, err := h.store.Groups().GetByGroupID(req.GroupID)
 	if err != nil {
@@ -68,20 +79,114 @@ func (h *QuoteHandler) Create(c echo.Context) error {
 		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
 	}
 
+	// Check for near-duplicates in the group unless the client forces the create
+	if !req.Force {
+		duplicates, err := findDuplicates(h.store, req.GroupID, user.ID, req.Text)
+		if err != nil {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to check for duplicates")
+		}
+		if len(duplicates) > 0 {
+			return api.SendErrorWithData(c, http.StatusConflict, errors.CodeAlreadyExists, "Possible duplicate quote", duplicates)
+		}
+	}
+
 	quote := &models.Quote{
 		Text:       req.Text,
 		Author:     req.Author,
 		UploaderID: user.ID,
 		GroupID:    req.GroupID,
+		UnlockAt:   req.UnlockAt,
 	}
 
 	if err := h.store.Quotes().Create(quote); err != nil {
 		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create quote")
 	}
 
+	publishCreated(h.store, h.bus, quote, groups)
+
 	return api.SendSuccess(c, http.StatusCreated, toQuoteResponse(quote, user.Username))
 }
 
+// findDuplicates returns the quotes in a group that look like text. Capsules the viewer can't open
+// are left out, matching against them would hint at what they say. An empty viewerID leaves out
+// every sealed capsule, for matches shown to a whole chat rather than to the uploader.
+func findDuplicates(store models.Store, groupID, viewerID, text string) ([]*DuplicateQuoteResponse, error) {
+	quotes, err := store.Quotes().ListByGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	existing := make([]*models.Quote, 0, len(quotes))
+	for _, q := range quotes {
+		hidden := q.Sealed(now)
+		if viewerID != "" {
+			hidden = q.SealedFor(viewerID, now)
+		}
+		if !hidden {
+			existing = append(existing, q)
+		}
+	}
+
+	texts := make([]string, len(existing))
+	for i, q := range existing {
+		texts[i] = q.Text
+	}
+
+	matches := similarity.NewIndex(texts).Find(text)
+	getUsernameFn := usernameLookup(store)
+	duplicates := make([]*DuplicateQuoteResponse, len(matches))
+	for i, m := range matches {
+		q := existing[m.Index]
+		duplicates[i] = &DuplicateQuoteResponse{
+			Quote:      toQuoteResponse(q, getUsernameFn(q.UploaderID)),
+			Similarity: m.Score,
+		}
+	}
+
+	return duplicates, nil
+}
+
+// publishCreated announces a new quote, with a mention event for each member @mentioned in it
+func publishCreated(store models.Store, bus *events.Bus, quote *models.Quote, groups []*models.Group) {
+	var mentioned []string
+	if handles := notify.ParseMentions(quote.Text); len(handles) > 0 {
+		wanted := make(map[string]bool, len(handles))
+		for _, handle := range handles {
+			wanted[handle] = true
+		}
+		for _, g := range groups {
+			if g.MemberID == quote.UploaderID {
+				continue
+			}
+			u, err := store.Users().GetByID(g.MemberID)
+			if err != nil || u == nil {
+				continue
+			}
+			if wanted[strings.ToLower(u.Username)] {
+				mentioned = append(mentioned, u.ID)
+			}
+		}
+	}
+
+	bus.Publish(events.Event{
+		Type:      events.QuoteCreated,
+		GroupID:   quote.GroupID,
+		ActorID:   quote.UploaderID,
+		QuoteID:   quote.ID,
+		Mentioned: mentioned,
+	})
+	for _, userID := range mentioned {
+		bus.Publish(events.Event{
+			Type:    events.Mention,
+			GroupID: quote.GroupID,
+			ActorID: quote.UploaderID,
+			QuoteID: quote.ID,
+			UserID:  userID,
+		})
+	}
+}
+
 // List handles retrieving quotes with optional filtering
 func (h *QuoteHandler) List(c echo.Context) error {
 	user := middleware.GetUserFromContext(c)
@@ -124,7 +229,7 @@ func (h *QuoteHandler) List(c echo.Context) error {
 		return u.Username
 	}
 
-	return api.SendSuccess(c, http.StatusOK, toQuoteResponses(quotes, getUsernameFn))
+	return api.SendSuccess(c, http.StatusOK, toQuoteResponses(quotes, getUsernameFn, user.ID))
 }
 
 // GetRandom handles retrieving a random quote
@@ -134,8 +239,10 @@ func (h *QuoteHandler) GetRandom(c echo.Context) error {
 		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
 	}
 
+	// A sealed capsule makes a poor random pick, so only quotes the user can read are drawn
 	filter := models.QuoteFilter{
-		Author: c.QueryParam("author"),
+		Author:    c.QueryParam("author"),
+		VisibleTo: user.ID,
 	}
 
 	quote, err := h.store.Quotes().GetRandom(filter)
@@ -194,6 +301,13 @@ func (h *QuoteHandler) Update(c echo.Context) error {
 		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update quote")
 	}
 
+	h.bus.Publish(events.Event{
+		Type:    events.QuoteUpdated,
+		GroupID: quote.GroupID,
+		ActorID: user.ID,
+		QuoteID: quote.ID,
+	})
+
 	return api.SendSuccess(c, http.StatusOK, toQuoteResponse(quote, user.Username))
 }
 
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type ReactionHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+	bus     *events.Bus
+}
+
+func NewReactionHandler(store models.Store, authMid *middleware.AuthMiddleware, bus *events.Bus) *ReactionHandler {
+	return &ReactionHandler{
+		store:   store,
+		authMid: authMid,
+		bus:     bus,
+	}
+}
+
+// SetupRoutes sets up the reaction routes
+func (h *ReactionHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/quotes/:id/reactions", h.List, h.authMid.Authenticate)
+	e.POST("/quotes/:id/reactions", h.Add, h.authMid.Authenticate)
+	e.DELETE("/quotes/:id/reactions/:emoji", h.Remove, h.authMid.Authenticate)
+}
+
+// List handles retrieving the reactions to a quote
+func (h *ReactionHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	quote, err := readableQuote(c, h.store, user.ID)
+	if quote == nil {
+		return err
+	}
+
+	return h.sendSummary(c, quote.ID, user.ID)
+}
+
+// Add handles reacting to a quote. Reacted quotes join the user's reminisce queue.
+func (h *ReactionHandler) Add(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	var req ReactionRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+	req.Emoji = strings.TrimSpace(req.Emoji)
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	quote, err := readableQuote(c, h.store, user.ID)
+	if quote == nil {
+		return err
+	}
+
+	added, err := h.store.Reactions().Add(quote.ID, user.ID, req.Emoji)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to add reaction")
+	}
+
+	if err := h.store.Reminisce().Enroll(user.ID, quote.ID); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to queue quote")
+	}
+
+	// Repeating a reaction the user already left isn't news to anyone
+	if added {
+		h.bus.Publish(events.Event{
+			Type:    events.ReactionAdded,
+			GroupID: quote.GroupID,
+			ActorID: user.ID,
+			QuoteID: quote.ID,
+			Emoji:   req.Emoji,
+		})
+	}
+
+	return h.sendSummary(c, quote.ID, user.ID)
+}
+
+// Remove handles taking back a reaction
+func (h *ReactionHandler) Remove(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	emoji, err := url.PathUnescape(c.Param("emoji"))
+	if err != nil || emoji == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Emoji is required")
+	}
+
+	quote, err := readableQuote(c, h.store, user.ID)
+	if quote == nil {
+		return err
+	}
+
+	if err := h.store.Reactions().Remove(quote.ID, user.ID, emoji); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Reaction not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to remove reaction")
+	}
+
+	if err := h.store.Reminisce().Unenroll(user.ID, quote.ID); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update reminisce queue")
+	}
+
+	return h.sendSummary(c, quote.ID, user.ID)
+}
+
+// sendSummary responds with the reaction counts for a quote
+func (h *ReactionHandler) sendSummary(c echo.Context, quoteID, userID string) error {
+	summary, err := h.store.Reactions().Summary(quoteID, userID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reactions")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, summary)
+}
+
+// readableQuote loads the quote named in the URL if the user can read it. When they can't, the
+// error response is sent and a nil quote is returned along with the result of sending it.
+func readableQuote(c echo.Context, store models.Store, userID string) (*models.Quote, error) {
+	id := c.Param("id")
+	if id == "" {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quote ID is required")
+	}
+
+	quote, err := store.Quotes().GetByID(id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quote")
+	}
+
+	groups, err := store.Groups().GetByGroupID(quote.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, userID) {
+		return nil, api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	if quote.SealedFor(userID, time.Now()) {
+		return nil, api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Quote is sealed until it unlocks")
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/reminisce"
+	"github.com/labstack/echo/v4"
+)
+
+type ReminisceHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewReminisceHandler(store models.Store, authMid *middleware.AuthMiddleware) *ReminisceHandler {
+	return &ReminisceHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the reminisce queue routes
+func (h *ReminisceHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/me/reminisce/next", h.Next, h.authMid.Authenticate)
+	e.POST("/me/reminisce/:id/grade", h.Grade, h.authMid.Authenticate)
+	e.PUT("/quotes/:id/love", h.Love, h.authMid.Authenticate)
+	e.DELETE("/quotes/:id/love", h.Unlove, h.authMid.Authenticate)
+}
+
+// Next handles retrieving the quote the user is due to look back on
+func (h *ReminisceHandler) Next(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	item, err := h.store.Reminisce().Next(user.ID, time.Now())
+	if err != nil {
+		if !errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reminisce queue")
+		}
+
+		nextDue, err := h.store.Reminisce().NextDue(user.ID)
+		if err != nil {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reminisce queue")
+		}
+		return api.SendErrorWithData(c, http.StatusNotFound, errors.CodeNotFound, "Nothing to reminisce right now", &ReminisceEmptyResponse{NextDueAt: nextDue})
+	}
+
+	return api.SendSuccess(c, http.StatusOK, toReminisceResponse(item, usernameLookup(h.store)(item.Quote.UploaderID)))
+}
+
+// Grade handles rating how well the user remembered a quote, which sets when it comes back
+func (h *ReminisceHandler) Grade(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quote ID is required")
+	}
+
+	var req GradeRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Grade must be between 0 and 5")
+	}
+
+	item, err := h.store.Reminisce().Get(user.ID, id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote is not in the reminisce queue")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reminisce item")
+	}
+
+	// Grading again before the quote comes back would reset or stretch its schedule, as happens
+	// when a grade is sent twice
+	now := time.Now()
+	if item.DueAt.After(now) {
+		return api.SendError(c, http.StatusConflict, errors.CodeAlreadyExists, "Quote isn't due for review yet")
+	}
+
+	schedule := reminisce.Review(reminisce.Schedule{
+		Ease:        item.Ease,
+		Interval:    item.Interval,
+		Repetitions: item.Repetitions,
+	}, *req.Grade)
+
+	item.Ease = schedule.Ease
+	item.Interval = schedule.Interval
+	item.Repetitions = schedule.Repetitions
+	item.DueAt = schedule.Due(now)
+	item.ReviewedAt = &now
+
+	if err := h.store.Reminisce().UpdateSchedule(item); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to save grade")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, toReminisceResponse(item, usernameLookup(h.store)(item.Quote.UploaderID)))
+}
+
+// Love handles marking a quote as loved, which adds it to the user's reminisce queue
+func (h *ReminisceHandler) Love(c echo.Context) error {
+	return h.setLoved(c, true)
+}
+
+// Unlove handles unmarking a loved quote. It leaves the queue unless the user has reacted to it.
+func (h *ReminisceHandler) Unlove(c echo.Context) error {
+	return h.setLoved(c, false)
+}
+
+// setLoved marks or unmarks the quote in the URL as loved by the current user
+func (h *ReminisceHandler) setLoved(c echo.Context, loved bool) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	quote, err := readableQuote(c, h.store, user.ID)
+	if quote == nil {
+		return err
+	}
+
+	if err := h.store.Reminisce().SetLoved(user.ID, quote.ID, loved); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update loved quote")
+	}
+
+	if !loved {
+		if err := h.store.Reminisce().Unenroll(user.ID, quote.ID); err != nil {
+			return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to update reminisce queue")
+		}
+		return api.SendSuccess(c, http.StatusOK, nil)
+	}
+
+	item, err := h.store.Reminisce().Get(user.ID, quote.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve reminisce item")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, toReminisceResponse(item, usernameLookup(h.store)(item.Quote.UploaderID)))
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/card"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// shareTitleLength is how much of a quote is used as the preview title
+const shareTitleLength = 90
+
+// sharePage is the public page behind a share link. Crawlers read the meta tags to build previews.
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <meta name="robots" content="noindex">
+  <title>{{.Title}}</title>
+  <meta name="description" content="{{.Description}}">
+  <meta property="og:type" content="article">
+  <meta property="og:site_name" content="Reminiscer">
+  <meta property="og:title" content="{{.Title}}">
+  <meta property="og:description" content="{{.Description}}">
+{{- if .URL}}
+  <meta property="og:url" content="{{.URL}}">
+  <meta property="og:image" content="{{.ImageURL}}">
+  <meta property="og:image:width" content="{{.ImageWidth}}">
+  <meta property="og:image:height" content="{{.ImageHeight}}">
+  <meta name="twitter:card" content="summary_large_image">
+  <meta name="twitter:image" content="{{.ImageURL}}">
+{{- else}}
+  <meta name="twitter:card" content="summary">
+{{- end}}
+  <meta name="twitter:title" content="{{.Title}}">
+  <meta name="twitter:description" content="{{.Description}}">
+  <style>
+    body { margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center;
+      background: #faf7f2; color: #222; font-family: Georgia, serif; }
+    main { max-width: 40rem; padding: 2rem; }
+    blockquote { margin: 0; font-size: 1.6rem; font-style: italic; line-height: 1.4; white-space: pre-line;
+      border-left: 4px solid #d96c3f; padding-left: 1.25rem; }
+    p { font-family: sans-serif; color: #77726b; margin-top: 1.5rem; }
+    strong { color: #d96c3f; }
+  </style>
+</head>
+<body>
+  <main>
+    <blockquote>{{.Text}}</blockquote>
+    <p><strong>— {{.Author}}</strong>{{if .Group}}<br>{{.Group}}{{end}}</p>
+  </main>
+</body>
+</html>
+`))
+
+// sharePageData fills in sharePage
+type sharePageData struct {
+	Title       string
+	Description string
+	URL         string
+	ImageURL    string
+	ImageWidth  int
+	ImageHeight int
+	Text        string
+	Author      string
+	Group       string
+}
+
+type ShareHandler struct {
+	store     models.Store
+	authMid   *middleware.AuthMiddleware
+	publicURL string
+	cache     *card.Cache
+}
+
+func NewShareHandler(store models.Store, authMid *middleware.AuthMiddleware, publicURL string) *ShareHandler {
+	return &ShareHandler{
+		store:     store,
+		authMid:   authMid,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		cache:     card.NewCache(cardCacheSize),
+	}
+}
+
+// SetupRoutes sets up the share link routes
+func (h *ShareHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/quotes/:id/share", h.Create, h.authMid.Authenticate)
+	e.GET("/me/shares", h.List, h.authMid.Authenticate)
+	e.DELETE("/shares/:id", h.Revoke, h.authMid.Authenticate)
+
+	// Public, the token is the only credential
+	e.GET("/s/:token", h.Page)
+	e.GET("/s/:token/card.png", h.Card)
+}
+
+// Create handles minting a public link to a quote
+func (h *ShareHandler) Create(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Quote ID is required")
+	}
+
+	quote, err := h.store.Quotes().GetByID(id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve quote")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(quote.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Quote not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	if quote.Sealed(time.Now()) {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Time capsules can't be shared until they unlock")
+	}
+
+	link := &models.ShareLink{
+		QuoteID:   quote.ID,
+		CreatedBy: user.ID,
+		Quote:     quote,
+	}
+
+	if err := h.store.Shares().Create(link); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create share link")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, toShareLinkResponse(link, h.shareURL(c, link.Token), usernameLookup(h.store)))
+}
+
+// List handles retrieving the share links created by the current user
+func (h *ShareHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	links, err := h.store.Shares().ListByCreator(user.ID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve share links")
+	}
+
+	getUsernameFn := usernameLookup(h.store)
+	responses := make([]*ShareLinkResponse, len(links))
+	for i, link := range links {
+		responses[i] = toShareLinkResponse(link, h.shareURL(c, link.Token), getUsernameFn)
+	}
+
+	return api.SendSuccess(c, http.StatusOK, responses)
+}
+
+// Revoke handles deleting a share link so its URL stops working
+func (h *ShareHandler) Revoke(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Share link ID is required")
+	}
+
+	if err := h.store.Shares().Delete(id, user.ID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Share link not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to revoke share link")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// Page handles rendering the public page for a share link
+func (h *ShareHandler) Page(c echo.Context) error {
+	link, groupName, err := h.loadLink(c)
+	if err != nil {
+		return c.HTML(http.StatusNotFound, "<!DOCTYPE html><title>Not found</title><p>This link doesn't exist or has been revoked.</p>")
+	}
+
+	author := strings.TrimSpace(link.Quote.Author)
+	if author == "" {
+		author = "Unknown"
+	}
+
+	description := "— " + author
+	if groupName != "" {
+		description += ", shared from " + groupName
+	}
+
+	size := card.Sizes["wide"]
+	data := sharePageData{
+		Title:       "“" + excerpt(link.Quote.Text, shareTitleLength) + "”",
+		Description: description,
+		ImageWidth:  size.X,
+		ImageHeight: size.Y,
+		Text:        link.Quote.Text,
+		Author:      author,
+		Group:       groupName,
+	}
+
+	// Previews are cached by whoever fetches the page, so their URLs only come from the
+	// configured public URL and never from the request's Host header
+	if h.publicURL != "" {
+		data.URL = h.publicURL + "/s/" + link.Token
+		data.ImageURL = data.URL + "/card.png"
+	}
+
+	var buf bytes.Buffer
+	if err := sharePage.Execute(&buf, data); err != nil {
+		return c.HTML(http.StatusInternalServerError, "<!DOCTYPE html><title>Error</title><p>Something went wrong.</p>")
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	return c.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
+// Card handles rendering the preview image for a share link
+func (h *ShareHandler) Card(c echo.Context) error {
+	link, groupName, err := h.loadLink(c)
+	if err != nil {
+		return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Share link not found")
+	}
+
+	theme := strings.ToLower(c.QueryParam("theme"))
+	if _, ok := card.Themes[theme]; !ok {
+		theme = card.DefaultTheme
+	}
+
+	png, etag, err := renderCard(h.cache, link.Quote, groupName, theme, "wide")
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeInternalError, "Failed to render card")
+	}
+
+	// Kept short so a revoked link's image drops out of shared caches quickly
+	return sendCard(c, png, etag, "public, max-age=3600")
+}
+
+// loadLink looks up the share link in the URL and the name of its quote's group. Links stop
+// working once the member who made them leaves the group.
+func (h *ShareHandler) loadLink(c echo.Context) (*models.ShareLink, string, error) {
+	link, err := h.store.Shares().GetByToken(c.Param("token"))
+	if err != nil {
+		return nil, "", err
+	}
+	if link.Quote.Sealed(time.Now()) {
+		return nil, "", errors.NotFound("Share link not found")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(link.Quote.GroupID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isGroupMember(groups, link.CreatedBy) {
+		return nil, "", errors.NotFound("Share link not found")
+	}
+
+	return link, groups[0].Name, nil
+}
+
+// shareURL builds the absolute public URL of a share link
+func (h *ShareHandler) shareURL(c echo.Context, token string) string {
+	base := h.publicURL
+	if base == "" {
+		base = c.Scheme() + "://" + c.Request().Host
+	}
+	return base + "/s/" + token
+}
+
+// excerpt shortens text to at most n characters on a single line, breaking at a word where possible
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	runes := []rune(text)[:n]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/attribution"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/slack"
+	"github.com/labstack/echo/v4"
+)
+
+// Action IDs of the buttons on the app's Slack messages
+const (
+	slackActionRandom = "random_quote"
+	slackActionSave   = "save_quote"
+	slackActionCancel = "cancel"
+)
+
+const (
+	// maxSlackBody bounds the requests Slack sends, commands and interactions are a few KB at most
+	maxSlackBody = 1 << 20
+
+	// maxSlackValue is the most a button value can hold
+	maxSlackValue = 2000
+)
+
+const slackHelp = "*Reminiscer for Slack*\n" +
+	"`/quote \"text\" - author` adds a quote to the group connected to this channel\n" +
+	"`/quote random` posts a random quote from the group\n" +
+	"`/quote connect &lt;group&gt;` connects this channel to one of your groups, by name or ID\n" +
+	"`/quote link &lt;code&gt;` links your Slack account, get a code from Reminiscer's settings"
+
+// slackDraft is a quote waiting on the user to confirm it isn't a duplicate, carried in a button's value
+type slackDraft struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+}
+
+type SlackHandler struct {
+	store    models.Store
+	bus      *events.Bus
+	verifier *slack.Verifier
+	client   *http.Client
+}
+
+// NewSlackHandler creates a handler for the Slack app's slash command and interactivity requests.
+// client posts replies to response URLs, a client with a short timeout is used if it is nil.
+func NewSlackHandler(store models.Store, bus *events.Bus, verifier *slack.Verifier, client *http.Client) *SlackHandler {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &SlackHandler{
+		store:    store,
+		bus:      bus,
+		verifier: verifier,
+		client:   client,
+	}
+}
+
+// SetupRoutes sets up the Slack routes. Requests are authenticated by their Slack signature
+// rather than a session, the Slack account is mapped to a user through its link.
+func (h *SlackHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/slack/commands", h.Command)
+	e.POST("/slack/interactions", h.Interaction)
+}
+
+// Command handles the /quote slash command
+func (h *SlackHandler) Command(c echo.Context) error {
+	form, err := h.readForm(c)
+	if form == nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, h.runCommand(slack.ParseCommand(form)))
+}
+
+// Interaction handles clicks on the buttons of the app's messages. Slack ignores the response
+// body, replies are posted to the interaction's response URL instead.
+func (h *SlackHandler) Interaction(c echo.Context) error {
+	form, err := h.readForm(c)
+	if form == nil {
+		return err
+	}
+
+	interaction, err := slack.ParseInteraction(form)
+	if err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid interaction payload")
+	}
+
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 {
+		return c.NoContent(http.StatusOK)
+	}
+
+	teamID, userID, channelID := interaction.Team.ID, interaction.User.ID, interaction.Channel.ID
+	var replies []*slack.Message
+
+	switch action := interaction.Actions[0]; action.ActionID {
+	case slackActionRandom:
+		reply := h.random(teamID, channelID, userID)
+		reply.ReplaceOriginal = reply.ResponseType == slack.ResponseInChannel
+		replies = append(replies, reply)
+
+	case slackActionSave:
+		var draft slackDraft
+		if err := json.Unmarshal([]byte(action.Value), &draft); err != nil || draft.Text == "" {
+			return c.NoContent(http.StatusOK)
+		}
+		reply := h.add(teamID, channelID, userID, draft.Text, draft.Author, true)
+		if reply.ResponseType == slack.ResponseInChannel {
+			// The duplicate warning only the user could see makes way for the quote everyone sees
+			replies = append(replies, &slack.Message{DeleteOriginal: true})
+		} else {
+			reply.ReplaceOriginal = true
+		}
+		replies = append(replies, reply)
+
+	case slackActionCancel:
+		replies = append(replies, &slack.Message{DeleteOriginal: true})
+
+	default:
+		return c.NoContent(http.StatusOK)
+	}
+
+	for _, reply := range replies {
+		if err := slack.Respond(c.Request().Context(), h.client, interaction.ResponseURL, reply); err != nil {
+			log.Printf("Failed to reply to Slack interaction: %v", err)
+			break
+		}
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// readForm verifies a request came from Slack and parses its form encoded body. When it didn't
+// or can't be parsed, the error response is sent and a nil form is returned along with the
+// result of sending it.
+func (h *SlackHandler) readForm(c echo.Context) (url.Values, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxSlackBody))
+	if err != nil {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Failed to read request")
+	}
+
+	if err := h.verifier.Verify(c.Request().Header, body); err != nil {
+		return nil, api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Invalid request signature")
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	return form, nil
+}
+
+// runCommand carries out a slash command and returns the reply
+func (h *SlackHandler) runCommand(cmd *slack.Command) *slack.Message {
+	text, author, ok := attribution.Parse(cmd.Text)
+	if ok && author != "" {
+		return h.add(cmd.TeamID, cmd.ChannelID, cmd.UserID, text, author, false)
+	}
+
+	verb, arg, _ := strings.Cut(cmd.Text, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToLower(verb) {
+	case "", "help":
+		return slack.Ephemeral(slackHelp)
+	case "random":
+		return h.random(cmd.TeamID, cmd.ChannelID, cmd.UserID)
+	case "link":
+		if arg != "" {
+			return h.link(cmd.TeamID, cmd.UserID, arg)
+		}
+	case "connect":
+		if arg != "" {
+			return h.connect(cmd.TeamID, cmd.ChannelID, cmd.UserID, arg)
+		}
+	}
+
+	if ok {
+		return slack.Ephemeral("Who said it? Add the author after the quote: `/quote \"text\" - author`")
+	}
+	return slack.Ephemeral(slackHelp)
+}
+
+// link links a Slack account to the user who issued the link code
+func (h *SlackHandler) link(teamID, slackUserID, code string) *slack.Message {
+	account := &models.ChatAccount{
+		Platform:   slack.Platform,
+		TeamID:     teamID,
+		ExternalID: slackUserID,
+	}
+
+	if err := h.store.Chat().RedeemLinkCode(code, account); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return slack.Ephemeral("That code is wrong or has expired, get a new one from Reminiscer's settings.")
+		}
+		return slack.Ephemeral("Something went wrong linking your account, try again in a moment.")
+	}
+
+	username := usernameLookup(h.store)(account.UserID)
+	return slack.Ephemeral(fmt.Sprintf("Your Slack account is linked to *%s* on Reminiscer.", slack.Escape(username)))
+}
+
+// connect connects a channel to one of the user's groups, named by ID or name
+func (h *SlackHandler) connect(teamID, channelID, slackUserID, name string) *slack.Message {
+	user, reply := h.slackUser(teamID, slackUserID)
+	if user == nil {
+		return reply
+	}
+
+	memberships, err := h.store.Groups().GetByMemberID(user.ID)
+	if err != nil {
+		return slack.Ephemeral("Something went wrong finding your groups, try again in a moment.")
+	}
+
+	matches := matchGroups(memberships, name)
+	switch {
+	case len(matches) == 0:
+		return slack.Ephemeral(fmt.Sprintf("You're not in a group called *%s*.", slack.Escape(name)))
+	case len(matches) > 1:
+		return slack.Ephemeral(fmt.Sprintf("You're in more than one group called *%s*, connect by the group's ID instead.", slack.Escape(name)))
+	}
+
+	locked, err := channelLocked(h.store, slack.Platform, teamID, channelID, user.ID)
+	if err != nil {
+		return slack.Ephemeral("Something went wrong finding this channel's group, try again in a moment.")
+	}
+	if locked != "" {
+		return slack.Ephemeral(fmt.Sprintf("This channel is connected to *%s*. Only the member who connected it can connect it to another group.", slack.Escape(locked)))
+	}
+
+	channel := &models.ChatChannel{
+		Platform:  slack.Platform,
+		TeamID:    teamID,
+		ChannelID: channelID,
+		GroupID:   matches[0].GroupID,
+		LinkedBy:  user.ID,
+	}
+	if err := h.store.Chat().SetChannel(channel); err != nil {
+		return slack.Ephemeral("Something went wrong connecting this channel, try again in a moment.")
+	}
+
+	text := fmt.Sprintf("%s connected this channel to *%s*. Add quotes with `/quote \"text\" - author`.",
+		slack.Escape(user.Username), slack.Escape(matches[0].Name))
+	return &slack.Message{
+		ResponseType: slack.ResponseInChannel,
+		Text:         text,
+		Blocks:       []slack.Block{slack.Section(text)},
+	}
+}
+
+// add adds a quote to the channel's group. Unless force is set, a quote that looks like one the
+// group already has is held back with buttons to add it anyway or drop it.
+func (h *SlackHandler) add(teamID, channelID, slackUserID, text, author string, force bool) *slack.Message {
+	user, reply := h.slackUser(teamID, slackUserID)
+	if user == nil {
+		return reply
+	}
+
+	groups, reply := h.channelGroup(teamID, channelID, user)
+	if groups == nil {
+		return reply
+	}
+
+	if !force {
+		duplicates, err := findDuplicates(h.store, groups[0].GroupID, user.ID, text)
+		if err != nil {
+			return slack.Ephemeral("Something went wrong checking for duplicates, try again in a moment.")
+		}
+		if len(duplicates) > 0 {
+			return duplicateMessage(duplicates[0].Quote, groups[0].Name, text, author)
+		}
+	}
+
+	quote := &models.Quote{
+		Text:       text,
+		Author:     author,
+		UploaderID: user.ID,
+		GroupID:    groups[0].GroupID,
+	}
+
+	if err := h.store.Quotes().Create(quote); err != nil {
+		return slack.Ephemeral("Something went wrong adding the quote, try again in a moment.")
+	}
+
+	publishCreated(h.store, h.bus, quote, groups)
+
+	fallback := fmt.Sprintf("%s added a quote to %s", user.Username, groups[0].Name)
+	return &slack.Message{
+		ResponseType: slack.ResponseInChannel,
+		Text:         fallback,
+		Blocks: []slack.Block{
+			slack.Section(slack.Quote(quote.Text, quote.Author)),
+			slack.Context(fmt.Sprintf("Added to *%s* by %s", slack.Escape(groups[0].Name), slack.Escape(user.Username))),
+		},
+	}
+}
+
+// random picks a quote from the channel's group for everyone in the channel to see
+func (h *SlackHandler) random(teamID, channelID, slackUserID string) *slack.Message {
+	user, reply := h.slackUser(teamID, slackUserID)
+	if user == nil {
+		return reply
+	}
+
+	groups, reply := h.channelGroup(teamID, channelID, user)
+	if groups == nil {
+		return reply
+	}
+
+	// The whole channel sees the pick, so capsules stay sealed even to the user who uploaded them
+	quote, err := h.store.Quotes().GetRandom(models.QuoteFilter{GroupID: groups[0].GroupID, Unlocked: true})
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return slack.Ephemeral(fmt.Sprintf("*%s* has no quotes yet.", slack.Escape(groups[0].Name)))
+		}
+		return slack.Ephemeral("Something went wrong picking a quote, try again in a moment.")
+	}
+
+	uploader := usernameLookup(h.store)(quote.UploaderID)
+	return &slack.Message{
+		ResponseType: slack.ResponseInChannel,
+		Text:         fmt.Sprintf("A quote from %s", groups[0].Name),
+		Blocks: []slack.Block{
+			slack.Section(slack.Quote(quote.Text, quote.Author)),
+			slack.Context(fmt.Sprintf("From *%s*, added by %s on %s",
+				slack.Escape(groups[0].Name), slack.Escape(uploader), quote.CreatedAt.Format("Jan 2, 2006"))),
+			slack.Actions(slack.Button("Another one", slackActionRandom, "", "")),
+		},
+	}
+}
+
+// slackUser returns the user a Slack account is linked to. When it isn't linked, a nil user is
+// returned with a reply explaining how to link it.
+func (h *SlackHandler) slackUser(teamID, slackUserID string) (*models.User, *slack.Message) {
+	account, err := h.store.Chat().GetAccount(slack.Platform, teamID, slackUserID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, slack.Ephemeral("Link your Slack account first: get a code from Reminiscer's settings and run `/quote link &lt;code&gt;`.")
+		}
+		return nil, slack.Ephemeral("Something went wrong finding your account, try again in a moment.")
+	}
+
+	user, err := h.store.Users().GetByID(account.UserID)
+	if err != nil {
+		return nil, slack.Ephemeral("Something went wrong finding your account, try again in a moment.")
+	}
+
+	return user, nil
+}
+
+// channelGroup returns the memberships of the group a channel is connected to. When the channel
+// isn't connected or the user isn't in its group, nil is returned with a reply saying so.
+func (h *SlackHandler) channelGroup(teamID, channelID string, user *models.User) ([]*models.Group, *slack.Message) {
+	channel, err := h.store.Chat().GetChannel(slack.Platform, teamID, channelID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, slack.Ephemeral("This channel isn't connected to a group yet, run `/quote connect &lt;group&gt;` to connect it.")
+		}
+		return nil, slack.Ephemeral("Something went wrong finding this channel's group, try again in a moment.")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(channel.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, slack.Ephemeral("The group this channel was connected to no longer exists, run `/quote connect &lt;group&gt;` to connect another.")
+		}
+		return nil, slack.Ephemeral("Something went wrong finding this channel's group, try again in a moment.")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return nil, slack.Ephemeral(fmt.Sprintf("You're not a member of *%s*, the group this channel is connected to.", slack.Escape(groups[0].Name)))
+	}
+
+	return groups, nil
+}
+
+// duplicateMessage asks the user whether to add a quote that looks like one the group already has
+func duplicateMessage(existing *QuoteResponse, groupName, text, author string) *slack.Message {
+	blocks := []slack.Block{
+		slack.Section(fmt.Sprintf("*%s* already has a quote like this:", slack.Escape(groupName))),
+		slack.Section(slack.Quote(existing.Text, existing.Author)),
+	}
+
+	buttons := []slack.Element{slack.Button("Cancel", slackActionCancel, "", "")}
+	if value, err := json.Marshal(&slackDraft{Text: text, Author: author}); err == nil && len(value) <= maxSlackValue {
+		buttons = append([]slack.Element{slack.Button("Add anyway", slackActionSave, string(value), "primary")}, buttons...)
+	}
+	blocks = append(blocks, slack.Actions(buttons...))
+
+	return &slack.Message{
+		ResponseType: slack.ResponseEphemeral,
+		Text:         fmt.Sprintf("%s already has a quote like this", groupName),
+		Blocks:       blocks,
+	}
+}
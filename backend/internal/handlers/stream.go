@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/realtime"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// streamHeartbeat is how often an idle stream sends a comment so proxies keep it open
+	streamHeartbeat = 20 * time.Second
+
+	// streamRetry is how long clients wait before reconnecting, in milliseconds
+	streamRetry = 3000
+)
+
+type StreamHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+	hub     *realtime.Hub
+}
+
+func NewStreamHandler(store models.Store, authMid *middleware.AuthMiddleware, hub *realtime.Hub) *StreamHandler {
+	return &StreamHandler{
+		store:   store,
+		authMid: authMid,
+		hub:     hub,
+	}
+}
+
+// SetupRoutes sets up the real-time stream routes
+func (h *StreamHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/stream", h.Stream, h.authMid.Authenticate)
+}
+
+// Stream handles a Server-Sent Events stream of what happens in the user's groups, or in one
+// group with ?group_id=. Clients resume after a dropped connection by sending the Last-Event-ID
+// header. When the events they missed are no longer remembered they get a "reset" event and
+// should reload instead.
+func (h *StreamHandler) Stream(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	memberships, err := h.store.Groups().GetByMemberID(user.ID)
+	if err != nil && !errors.IsCode(err, errors.CodeNotFound) {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve groups")
+	}
+
+	var groupIDs []string
+	only := c.QueryParam("group_id")
+	for _, g := range memberships {
+		if only == "" || g.GroupID == only {
+			groupIDs = append(groupIDs, g.GroupID)
+		}
+	}
+	if only != "" && len(groupIDs) == 0 {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	// Subscribe before replaying so nothing published in between is missed. A stream of one
+	// group keeps to it, the others pick up groups the user joins while connected.
+	var sub *realtime.Subscription
+	if only != "" {
+		sub = h.hub.SubscribeGroup(user.ID, only)
+	} else {
+		sub = h.hub.Subscribe(user.ID, groupIDs)
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", streamRetry); err != nil {
+		return nil
+	}
+
+	var sent uint64
+	if lastEventID != "" {
+		lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+		missed, ok := h.hub.Since(lastID, groupIDs)
+		if err != nil || !ok {
+			if _, err := fmt.Fprint(res, "event: reset\ndata: {}\n\n"); err != nil {
+				return nil
+			}
+		}
+		for _, msg := range missed {
+			if err := h.write(c, user.ID, msg); err != nil {
+				return nil
+			}
+			sent = msg.ID
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case msg, ok := <-sub.C:
+			// A closed channel means the client fell too far behind, it reconnects and resumes
+			if !ok {
+				return nil
+			}
+			if msg.ID <= sent {
+				continue
+			}
+			if err := h.write(c, user.ID, msg); err != nil {
+				return nil
+			}
+			sent = msg.ID
+			res.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// write sends one message as the user is allowed to see it
+func (h *StreamHandler) write(c echo.Context, userID string, msg *realtime.Message) error {
+	data := &StreamEventResponse{
+		GroupID: msg.Event.GroupID,
+		QuoteID: msg.Event.QuoteID,
+		ActorID: msg.Event.ActorID,
+		Actor:   msg.Actor,
+		Emoji:   msg.Event.Emoji,
+	}
+	if msg.Quote != nil {
+		data.Quote = toViewerQuoteResponse(msg.Quote, msg.Uploader, userID)
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Response(), "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Name, body)
+	return err
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/telegram"
+	"github.com/labstack/echo/v4"
+)
+
+// maxTelegramBody bounds the updates Telegram sends, a message update is a few KB at most
+const maxTelegramBody = 1 << 20
+
+const telegramHelp = "<b>Reminiscer</b>\n" +
+	"Reply to a message with /quote to save it to the group connected to this chat\n" +
+	"/random posts a random quote from the group\n" +
+	"/connect &lt;group&gt; connects this chat to one of your groups, by name or ID\n" +
+	"/link &lt;code&gt; links your Telegram account, get a code from Reminiscer's settings"
+
+type TelegramHandler struct {
+	store  models.Store
+	bus    *events.Bus
+	client *telegram.Client
+	secret string
+}
+
+// NewTelegramHandler creates a handler for the bot's webhook. secret is the token the webhook was
+// registered with, updates without it are rejected.
+func NewTelegramHandler(store models.Store, bus *events.Bus, client *telegram.Client, secret string) *TelegramHandler {
+	return &TelegramHandler{
+		store:  store,
+		bus:    bus,
+		client: client,
+		secret: secret,
+	}
+}
+
+// SetupRoutes sets up the Telegram routes. Updates are authenticated by the webhook's secret
+// token rather than a session, the sender is mapped to a user through their linked account.
+func (h *TelegramHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/telegram/webhook", h.Webhook)
+}
+
+// Webhook handles an update from Telegram, answering commands with a reply in the chat. Telegram
+// retries updates that get an error, so anything past verification is acknowledged.
+func (h *TelegramHandler) Webhook(c echo.Context) error {
+	if !telegram.VerifySecret(c.Request().Header, h.secret) {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Invalid secret token")
+	}
+
+	var update telegram.Update
+	if err := json.NewDecoder(io.LimitReader(c.Request().Body, maxTelegramBody)).Decode(&update); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid update")
+	}
+
+	msg := update.Message
+	if msg == nil || msg.From == nil {
+		return c.NoContent(http.StatusOK)
+	}
+
+	reply := h.runCommand(msg)
+	if reply == "" {
+		return c.NoContent(http.StatusOK)
+	}
+
+	err := h.client.SendMessage(c.Request().Context(), &telegram.OutgoingMessage{
+		ChatID:           msg.Chat.ID,
+		Text:             reply,
+		ReplyToMessageID: msg.MessageID,
+	})
+	if err != nil {
+		log.Printf("Failed to reply in Telegram chat %d: %v", msg.Chat.ID, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// runCommand carries out the command in a message and returns the reply, or "" for messages that
+// aren't commands for the bot
+func (h *TelegramHandler) runCommand(msg *telegram.Message) string {
+	command, args, ok := telegram.ParseCommand(msg.Text)
+	if !ok {
+		return ""
+	}
+
+	switch command {
+	case "start", "help":
+		return telegramHelp
+	case "link":
+		if args == "" {
+			return "Get a code from Reminiscer's settings and send <code>/link &lt;code&gt;</code>."
+		}
+		return h.link(msg.From, args)
+	case "connect":
+		if args == "" {
+			return "Name the group to connect: <code>/connect &lt;group&gt;</code>."
+		}
+		return h.connect(msg.From, msg.Chat, args)
+	case "quote":
+		return h.quote(msg, strings.EqualFold(args, "anyway"))
+	case "random":
+		return h.random(msg.From, msg.Chat)
+	}
+
+	// Commands meant for other bots in the chat
+	return ""
+}
+
+// link links a Telegram account to the user who issued the link code
+func (h *TelegramHandler) link(from *telegram.User, code string) string {
+	account := &models.ChatAccount{
+		Platform:   telegram.Platform,
+		ExternalID: strconv.FormatInt(from.ID, 10),
+	}
+
+	if err := h.store.Chat().RedeemLinkCode(code, account); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return "That code is wrong or has expired, get a new one from Reminiscer's settings."
+		}
+		return "Something went wrong linking your account, try again in a moment."
+	}
+
+	username := usernameLookup(h.store)(account.UserID)
+	return fmt.Sprintf("Your Telegram account is linked to <b>%s</b> on Reminiscer.", html.EscapeString(username))
+}
+
+// connect connects a chat to one of the user's groups, named by ID or name
+func (h *TelegramHandler) connect(from *telegram.User, chat telegram.Chat, name string) string {
+	user, reply := h.telegramUser(from)
+	if user == nil {
+		return reply
+	}
+
+	memberships, err := h.store.Groups().GetByMemberID(user.ID)
+	if err != nil {
+		return "Something went wrong finding your groups, try again in a moment."
+	}
+
+	matches := matchGroups(memberships, name)
+	switch {
+	case len(matches) == 0:
+		return fmt.Sprintf("You're not in a group called <b>%s</b>.", html.EscapeString(name))
+	case len(matches) > 1:
+		return fmt.Sprintf("You're in more than one group called <b>%s</b>, connect by the group's ID instead.", html.EscapeString(name))
+	}
+
+	chatID := strconv.FormatInt(chat.ID, 10)
+	locked, err := channelLocked(h.store, telegram.Platform, "", chatID, user.ID)
+	if err != nil {
+		return "Something went wrong finding this chat's group, try again in a moment."
+	}
+	if locked != "" {
+		return fmt.Sprintf("This chat is connected to <b>%s</b>. Only the member who connected it can connect it to another group.", html.EscapeString(locked))
+	}
+
+	channel := &models.ChatChannel{
+		Platform:  telegram.Platform,
+		ChannelID: chatID,
+		GroupID:   matches[0].GroupID,
+		LinkedBy:  user.ID,
+	}
+	if err := h.store.Chat().SetChannel(channel); err != nil {
+		return "Something went wrong connecting this chat, try again in a moment."
+	}
+
+	return fmt.Sprintf("This chat is connected to <b>%s</b>. Reply to a message with /quote to save it.",
+		html.EscapeString(matches[0].Name))
+}
+
+// quote saves the message replied to as a quote by its sender. Unless force is set, a quote that
+// looks like one the group already has is held back.
+func (h *TelegramHandler) quote(msg *telegram.Message, force bool) string {
+	original := msg.ReplyToMessage
+	if original == nil || strings.TrimSpace(original.Content()) == "" {
+		return "Reply to a message with /quote to save it."
+	}
+	if original.From != nil && original.From.IsBot {
+		return "Messages from bots can't be saved as quotes."
+	}
+
+	user, reply := h.telegramUser(msg.From)
+	if user == nil {
+		return reply
+	}
+
+	groups, reply := h.chatGroup(msg.Chat, user)
+	if groups == nil {
+		return reply
+	}
+
+	text := strings.TrimSpace(original.Content())
+	author := "Unknown"
+	if original.From != nil && original.From.Name() != "" {
+		author = original.From.Name()
+	}
+
+	if !force {
+		// The warning is posted to the whole chat, so capsules stay sealed even to the user who uploaded them
+		duplicates, err := findDuplicates(h.store, groups[0].GroupID, "", text)
+		if err != nil {
+			return "Something went wrong checking for duplicates, try again in a moment."
+		}
+		if len(duplicates) > 0 {
+			existing := duplicates[0].Quote
+			return fmt.Sprintf("<b>%s</b> already has a quote like this:\n%s\n\nReply with <code>/quote anyway</code> to save it regardless.",
+				html.EscapeString(groups[0].Name), formatTelegramQuote(existing.Text, existing.Author))
+		}
+	}
+
+	quote := &models.Quote{
+		Text:       text,
+		Author:     author,
+		UploaderID: user.ID,
+		GroupID:    groups[0].GroupID,
+	}
+
+	if err := h.store.Quotes().Create(quote); err != nil {
+		return "Something went wrong saving the quote, try again in a moment."
+	}
+
+	publishCreated(h.store, h.bus, quote, groups)
+
+	return fmt.Sprintf("Saved to <b>%s</b>:\n%s", html.EscapeString(groups[0].Name), formatTelegramQuote(quote.Text, quote.Author))
+}
+
+// random picks a quote from the chat's group
+func (h *TelegramHandler) random(from *telegram.User, chat telegram.Chat) string {
+	user, reply := h.telegramUser(from)
+	if user == nil {
+		return reply
+	}
+
+	groups, reply := h.chatGroup(chat, user)
+	if groups == nil {
+		return reply
+	}
+
+	// Everyone in the chat sees the pick, so capsules stay sealed even to the user who uploaded them
+	quote, err := h.store.Quotes().GetRandom(models.QuoteFilter{GroupID: groups[0].GroupID, Unlocked: true})
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return fmt.Sprintf("<b>%s</b> has no quotes yet.", html.EscapeString(groups[0].Name))
+		}
+		return "Something went wrong picking a quote, try again in a moment."
+	}
+
+	uploader := usernameLookup(h.store)(quote.UploaderID)
+	return fmt.Sprintf("%s\n\nFrom <b>%s</b>, added by %s on %s", formatTelegramQuote(quote.Text, quote.Author),
+		html.EscapeString(groups[0].Name), html.EscapeString(uploader), quote.CreatedAt.Format("Jan 2, 2006"))
+}
+
+// telegramUser returns the user a Telegram account is linked to. When it isn't linked, a nil user
+// is returned with a reply explaining how to link it.
+func (h *TelegramHandler) telegramUser(from *telegram.User) (*models.User, string) {
+	account, err := h.store.Chat().GetAccount(telegram.Platform, "", strconv.FormatInt(from.ID, 10))
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, "Link your Telegram account first: get a code from Reminiscer's settings and send <code>/link &lt;code&gt;</code>."
+		}
+		return nil, "Something went wrong finding your account, try again in a moment."
+	}
+
+	user, err := h.store.Users().GetByID(account.UserID)
+	if err != nil {
+		return nil, "Something went wrong finding your account, try again in a moment."
+	}
+
+	return user, ""
+}
+
+// chatGroup returns the memberships of the group a chat is connected to. When the chat isn't
+// connected or the user isn't in its group, nil is returned with a reply saying so.
+func (h *TelegramHandler) chatGroup(chat telegram.Chat, user *models.User) ([]*models.Group, string) {
+	channel, err := h.store.Chat().GetChannel(telegram.Platform, "", strconv.FormatInt(chat.ID, 10))
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, "This chat isn't connected to a group yet, send <code>/connect &lt;group&gt;</code> to connect it."
+		}
+		return nil, "Something went wrong finding this chat's group, try again in a moment."
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(channel.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, "The group this chat was connected to no longer exists, send <code>/connect &lt;group&gt;</code> to connect another."
+		}
+		return nil, "Something went wrong finding this chat's group, try again in a moment."
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return nil, fmt.Sprintf("You're not a member of <b>%s</b>, the group this chat is connected to.", html.EscapeString(groups[0].Name))
+	}
+
+	return groups, ""
+}
+
+// formatTelegramQuote formats a quote with its attribution as Bot API HTML
+func formatTelegramQuote(text, author string) string {
+	formatted := "<i>“" + html.EscapeString(text) + "”</i>"
+	if author == "" {
+		return formatted
+	}
+	return formatted + "\n— " + html.EscapeString(author)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"time"
 
+	"github.com/jamoowen/reminiscer/internal/importer"
 	"github.com/jamoowen/reminiscer/internal/models"
 )
 
@@ -21,15 +22,24 @@ type RegisterRequest struct {
 
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  *models.User `json:"user"`
+	Token        string       `json:"token"`
+	ExpiresIn    int          `json:"expires_in"` // Seconds until the token has to be refreshed
+	RefreshToken string       `json:"refresh_token"`
+	User         *models.User `json:"user"`
+}
+
+// RefreshRequest represents a refresh token traded in for new tokens, or revoked on logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // CreateQuoteRequest represents the request to create a quote
 type CreateQuoteRequest struct {
-	Text    string `json:"text" validate:"required"`
-	Author  string `json:"author" validate:"required"`
-	GroupID string `json:"group_id" validate:"required"`
+	Text     string     `json:"text" validate:"required"`
+	Author   string     `json:"author" validate:"required"`
+	GroupID  string     `json:"group_id" validate:"required"`
+	Force    bool       `json:"force"`     // Create even if likely duplicates exist
+	UnlockAt *time.Time `json:"unlock_at"` // Seal the quote as a time capsule until then
 }
 
 // UpdateQuoteRequest represents the request to update a quote
@@ -40,27 +50,41 @@ type UpdateQuoteRequest struct {
 
 // CreateGroupRequest represents the request to create a group
 type CreateGroupRequest struct {
-	Name    string   `json:"name" validate:"required"`
-	GroupID string   `json:"group_id" validate:"required"`
-	Members []string `json:"members" validate:"required,min=1"`
+	Name     string   `json:"name" validate:"required"`
+	GroupID  string   `json:"group_id" validate:"required"`
+	Members  []string `json:"members" validate:"required,min=1"`
+	Timezone string   `json:"timezone"` // IANA name, defaults to UTC
 }
 
 // UpdateGroupRequest represents the request to update a group
 type UpdateGroupRequest struct {
-	Name    string   `json:"name" validate:"required"`
-	Members []string `json:"members" validate:"required,min=1"`
+	Name     string   `json:"name" validate:"required"`
+	Members  []string `json:"members" validate:"required,min=1"`
+	Timezone string   `json:"timezone"` // IANA name, unchanged if empty
 }
 
 // QuoteResponse represents a quote with additional metadata
 type QuoteResponse struct {
-	ID         string    `json:"id"`
-	Text       string    `json:"text"`
-	Author     string    `json:"author"`
-	UploaderID string    `json:"uploader_id"`
-	GroupID    string    `json:"group_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Uploader   string    `json:"uploader"` // Username of uploader
+	ID         string        `json:"id"`
+	Text       string        `json:"text"`
+	Author     string        `json:"author"`
+	UploaderID string        `json:"uploader_id"`
+	GroupID    string        `json:"group_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Uploader   string        `json:"uploader"` // Username of uploader
+	UnlockAt   *time.Time    `json:"unlock_at,omitempty"`
+	Sealed     bool          `json:"sealed,omitempty"`   // Time capsule the viewer can't open yet, its text is left out
+	ReplyTo    *models.Reply `json:"reply_to,omitempty"` // Message the quote answered, from chat imports
+
+	// OriginalUploader names who uploaded the quote before it was exported and imported here
+	OriginalUploader string `json:"original_uploader,omitempty"`
+}
+
+// DuplicateQuoteResponse represents an existing quote that resembles a new one
+type DuplicateQuoteResponse struct {
+	Quote      *QuoteResponse `json:"quote"`
+	Similarity float64        `json:"similarity"`
 }
 
 // GroupResponse represents a group with additional metadata
@@ -69,10 +93,18 @@ type GroupResponse struct {
 	GroupID   string    `json:"group_id"`
 	Name      string    `json:"name"`
 	Members   []string  `json:"members"` // List of member usernames
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// DailyQuoteResponse represents the quote of the day for a group
+type DailyQuoteResponse struct {
+	Day   string         `json:"day"`
+	Cycle int            `json:"cycle"`
+	Quote *QuoteResponse `json:"quote"`
+}
+
 // ListQuotesParams represents the query parameters for listing quotes
 type ListQuotesParams struct {
 	Author string `query:"author"`
@@ -80,6 +112,282 @@ type ListQuotesParams struct {
 	Limit  int    `query:"limit"`
 }
 
+// PaginationParams represents the common page and limit query parameters
+type PaginationParams struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+// usernameLookup returns a function that resolves user IDs to usernames, falling back to "Unknown"
+func usernameLookup(store models.Store) func(string) string {
+	return func(userID string) string {
+		u, err := store.Users().GetByID(userID)
+		if err != nil || u == nil {
+			return "Unknown"
+		}
+		return u.Username
+	}
+}
+
+// ImportParams represents the query parameters for importing quotes
+type ImportParams struct {
+	Format          string `query:"format"` // csv, json or a zip export archive, inferred from the upload if empty
+	DryRun          bool   `query:"dry_run"`
+	SkipInvalid     bool   `query:"skip_invalid"`
+	AllowDuplicates bool   `query:"allow_duplicates"`
+	TextColumn      string `query:"text_column"`
+	AuthorColumn    string `query:"author_column"`
+	DateColumn      string `query:"date_column"`
+}
+
+// ImportResult reports the outcome of an import or dry run
+type ImportResult struct {
+	ImportID  string              `json:"import_id,omitempty"`
+	DryRun    bool                `json:"dry_run"`
+	TotalRows int                 `json:"total_rows"`
+	ValidRows int                 `json:"valid_rows"`
+	Imported  int                 `json:"imported"`
+	Errors    []importer.RowError `json:"errors"`
+}
+
+// ChatImportParams represents the query parameters for uploading a chat export
+type ChatImportParams struct {
+	Format    string `query:"format"`     // Chat export format: whatsapp, telegram or discord
+	DateOrder string `query:"date_order"` // dmy, mdy or ymd, detected from the export if empty
+}
+
+// SenderTarget maps a chat sender to a free-text author or to a group member
+type SenderTarget struct {
+	Author string `json:"author"`
+	UserID string `json:"user_id"`
+}
+
+// CommitImportRequest represents the request to import messages picked from a preview
+type CommitImportRequest struct {
+	MessageIDs      []int                   `json:"message_ids" validate:"required,min=1"`
+	Senders         map[string]SenderTarget `json:"senders"`
+	DryRun          bool                    `json:"dry_run"`
+	SkipInvalid     bool                    `json:"skip_invalid"`
+	AllowDuplicates bool                    `json:"allow_duplicates"`
+}
+
+// ImportPreviewResponse represents a parsed chat export waiting to be committed
+type ImportPreviewResponse struct {
+	ID        string             `json:"id"`
+	Source    string             `json:"source"`
+	Senders   []importer.Sender  `json:"senders"`
+	Messages  []importer.Message `json:"messages"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// toImportPreviewResponse converts a models.ImportPreview and its messages to an ImportPreviewResponse
+func toImportPreviewResponse(p *models.ImportPreview, messages []importer.Message) *ImportPreviewResponse {
+	return &ImportPreviewResponse{
+		ID:        p.ID,
+		Source:    p.Source,
+		Senders:   importer.Senders(messages),
+		Messages:  messages,
+		ExpiresAt: p.ExpiresAt,
+	}
+}
+
+// ShareLinkResponse represents a public link to a quote
+type ShareLinkResponse struct {
+	ID        string         `json:"id"`
+	Token     string         `json:"token"`
+	URL       string         `json:"url"`
+	CreatedAt time.Time      `json:"created_at"`
+	Quote     *QuoteResponse `json:"quote"`
+}
+
+// FeedTokenResponse represents a private feed of a group's quotes
+type FeedTokenResponse struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	GroupID   string    `json:"group_id"`
+	AtomURL   string    `json:"atom_url"`
+	RSSURL    string    `json:"rss_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuizAnswerRequest represents a player's answer to a quiz round
+type QuizAnswerRequest struct {
+	Choice string `json:"choice" validate:"required"`
+}
+
+// QuizRoundResponse is a quiz question as shown to the player, without its answer
+type QuizRoundResponse struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Text      string    `json:"text"`
+	QuotedAt  time.Time `json:"quoted_at"`
+	Choices   []string  `json:"choices"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuizAnswerResponse reveals the answer to a round and the player's updated totals
+type QuizAnswerResponse struct {
+	Correct bool              `json:"correct"`
+	Chosen  string            `json:"chosen"`
+	Answer  string            `json:"answer"`
+	Points  int               `json:"points"`
+	Quote   *QuoteResponse    `json:"quote"`
+	Score   *models.QuizScore `json:"score"`
+}
+
+// toQuizRoundResponse converts a models.QuizRound to a QuizRoundResponse
+func toQuizRoundResponse(r *models.QuizRound) *QuizRoundResponse {
+	return &QuizRoundResponse{
+		ID:        r.ID,
+		GroupID:   r.GroupID,
+		Text:      r.Quote.Text,
+		QuotedAt:  r.Quote.CreatedAt,
+		Choices:   r.Choices,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// ReactionRequest represents a reaction to a quote
+type ReactionRequest struct {
+	Emoji string `json:"emoji" validate:"required,max=32"`
+}
+
+// GradeRequest represents how well a user remembered a resurfaced quote, from 0 to 5
+type GradeRequest struct {
+	Grade *int `json:"grade" validate:"required,min=0,max=5"`
+}
+
+// ReminisceResponse represents a quote in the user's reminisce queue
+type ReminisceResponse struct {
+	Quote        *QuoteResponse `json:"quote"`
+	Loved        bool           `json:"loved"`
+	Repetitions  int            `json:"repetitions"`
+	IntervalDays int            `json:"interval_days"`
+	DueAt        time.Time      `json:"due_at"`
+	ReviewedAt   *time.Time     `json:"reviewed_at,omitempty"`
+}
+
+// ReminisceEmptyResponse tells the client when to check the queue again
+type ReminisceEmptyResponse struct {
+	NextDueAt *time.Time `json:"next_due_at"`
+}
+
+// NotificationListResponse represents a page of the user's inbox
+type NotificationListResponse struct {
+	Notifications []*models.Notification `json:"notifications"`
+	UnreadCount   int                    `json:"unread_count"`
+}
+
+// MarkReadRequest represents notifications to mark as read, all of them when IDs is empty
+type MarkReadRequest struct {
+	IDs []string `json:"ids" validate:"max=100"`
+}
+
+// MarkReadResponse reports how many notifications were marked and how many remain unread
+type MarkReadResponse struct {
+	Marked      int `json:"marked"`
+	UnreadCount int `json:"unread_count"`
+}
+
+// NotificationPreferencesRequest represents changes to a member's notification preferences,
+// fields left out keep their current value
+type NotificationPreferencesRequest struct {
+	Quotes    *bool `json:"quotes"`
+	Mentions  *bool `json:"mentions"`
+	Reactions *bool `json:"reactions"`
+	Capsules  *bool `json:"capsules"`
+}
+
+// RegisterDeviceRequest represents a device's APNs token
+type RegisterDeviceRequest struct {
+	Token string `json:"token" validate:"required,hexadecimal,max=200"`
+}
+
+// DigestSubscriptionRequest turns the weekly digest email on or off
+type DigestSubscriptionRequest struct {
+	Subscribed *bool `json:"subscribed" validate:"required"`
+}
+
+// DigestSubscriptionResponse represents whether the user gets the weekly digest
+type DigestSubscriptionResponse struct {
+	Subscribed bool `json:"subscribed"`
+}
+
+// CreateWebhookRequest represents a new webhook subscription for a group
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2000"`
+	Events []string `json:"events" validate:"max=10,dive,required"` // Leave empty for every event
+}
+
+// WebhookCreatedResponse is a new webhook with its signing secret, which is only ever shown once
+type WebhookCreatedResponse struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// StreamEventResponse is the data of an event on the real-time stream
+type StreamEventResponse struct {
+	GroupID string         `json:"group_id"`
+	QuoteID string         `json:"quote_id,omitempty"`
+	Quote   *QuoteResponse `json:"quote,omitempty"`
+	ActorID string         `json:"actor_id,omitempty"`
+	Actor   string         `json:"actor,omitempty"`
+	Emoji   string         `json:"emoji,omitempty"`
+}
+
+// ActivityResponse represents an entry in a group's activity feed
+type ActivityResponse struct {
+	ID        int64          `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	ActorID   string         `json:"actor_id,omitempty"`
+	Actor     string         `json:"actor,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	User      string         `json:"user,omitempty"`
+	QuoteID   string         `json:"quote_id,omitempty"`
+	Quote     *QuoteResponse `json:"quote,omitempty"`
+	Emoji     string         `json:"emoji,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Count     int            `json:"count"`            // Reactions folded into a "reactions" entry, 1 otherwise
+	Actors    []string       `json:"actors,omitempty"` // Everyone who reacted, for "reactions" entries
+	Emojis    []string       `json:"emojis,omitempty"`
+}
+
+// ActivityFeedResponse represents a page of a group's activity feed
+type ActivityFeedResponse struct {
+	Items      []*ActivityResponse `json:"items"`
+	NextCursor *int64              `json:"next_cursor,omitempty"` // Pass as ?before= for the next page
+}
+
+// InboundAddressResponse represents the address a group receives quotes by email at
+type InboundAddressResponse struct {
+	Address string `json:"address,omitempty"` // Omitted when no inbound domain is configured
+	Token   string `json:"token"`
+}
+
+// toReminisceResponse converts a models.ReminisceItem to a ReminisceResponse
+func toReminisceResponse(item *models.ReminisceItem, uploaderUsername string) *ReminisceResponse {
+	return &ReminisceResponse{
+		Quote:        toQuoteResponse(item.Quote, uploaderUsername),
+		Loved:        item.Loved,
+		Repetitions:  item.Repetitions,
+		IntervalDays: item.Interval,
+		DueAt:        item.DueAt,
+		ReviewedAt:   item.ReviewedAt,
+	}
+}
+
+// toShareLinkResponse converts a models.ShareLink to a ShareLinkResponse
+func toShareLinkResponse(l *models.ShareLink, url string, getUsernameFn func(string) string) *ShareLinkResponse {
+	return &ShareLinkResponse{
+		ID:        l.ID,
+		Token:     l.Token,
+		URL:       url,
+		CreatedAt: l.CreatedAt,
+		Quote:     toQuoteResponse(l.Quote, getUsernameFn(l.Quote.UploaderID)),
+	}
+}
+
 // toQuoteResponse converts a models.Quote to a QuoteResponse
 func toQuoteResponse(q *models.Quote, uploaderUsername string) *QuoteResponse {
 	return &QuoteResponse{
@@ -91,18 +399,53 @@ func toQuoteResponse(q *models.Quote, uploaderUsername string) *QuoteResponse {
 		CreatedAt:  q.CreatedAt,
 		UpdatedAt:  q.UpdatedAt,
 		Uploader:   uploaderUsername,
+		UnlockAt:   q.UnlockAt,
+		ReplyTo:    q.ReplyTo,
+
+		OriginalUploader: q.OriginalUploader,
+	}
+}
+
+// toViewerQuoteResponse converts a models.Quote to a QuoteResponse for a viewer, hiding the text of
+// time capsules they can't open yet
+func toViewerQuoteResponse(q *models.Quote, uploaderUsername, viewerID string) *QuoteResponse {
+	response := toQuoteResponse(q, uploaderUsername)
+	if q.SealedFor(viewerID, time.Now()) {
+		response.Text = ""
+		response.ReplyTo = nil
+		response.Sealed = true
 	}
+	return response
 }
 
-// toQuoteResponses converts a slice of models.Quote to QuoteResponses
-func toQuoteResponses(quotes []*models.Quote, getUsernameFn func(string) string) []*QuoteResponse {
+// toQuoteResponses converts a slice of models.Quote to QuoteResponses as seen by a viewer
+func toQuoteResponses(quotes []*models.Quote, getUsernameFn func(string) string, viewerID string) []*QuoteResponse {
 	responses := make([]*QuoteResponse, len(quotes))
 	for i, q := range quotes {
-		responses[i] = toQuoteResponse(q, getUsernameFn(q.UploaderID))
+		responses[i] = toViewerQuoteResponse(q, getUsernameFn(q.UploaderID), viewerID)
 	}
 	return responses
 }
 
+// toDailyQuoteResponse converts a models.DailyQuote to a DailyQuoteResponse
+func toDailyQuoteResponse(d *models.DailyQuote, getUsernameFn func(string) string) *DailyQuoteResponse {
+	return &DailyQuoteResponse{
+		Day:   d.Day,
+		Cycle: d.Cycle,
+		Quote: toQuoteResponse(d.Quote, getUsernameFn(d.Quote.UploaderID)),
+	}
+}
+
+// isGroupMember reports whether the user is one of the members of the group
+func isGroupMember(groups []*models.Group, userID string) bool {
+	for _, g := range groups {
+		if g.MemberID == userID {
+			return true
+		}
+	}
+	return false
+}
+
 // toGroupResponse converts models.Group slice to a GroupResponse
 func toGroupResponse(groups []*models.Group, getUsernameFn func(string) string) *GroupResponse {
 	if len(groups) == 0 {
@@ -121,6 +464,7 @@ func toGroupResponse(groups []*models.Group, getUsernameFn func(string) string)
 		GroupID:   first.GroupID,
 		Name:      first.Name,
 		Members:   members,
+		Timezone:  first.Timezone,
 		CreatedAt: first.CreatedAt,
 		UpdatedAt: first.UpdatedAt,
 	}
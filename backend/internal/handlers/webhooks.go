@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/jamoowen/reminiscer/internal/webhook"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	store      models.Store
+	authMid    *middleware.AuthMiddleware
+	dispatcher *webhook.Dispatcher
+
+	// allowInsecure accepts plain http and local addresses, for trying webhooks out in development
+	allowInsecure bool
+}
+
+func NewWebhookHandler(store models.Store, authMid *middleware.AuthMiddleware, dispatcher *webhook.Dispatcher, allowInsecure bool) *WebhookHandler {
+	return &WebhookHandler{
+		store:         store,
+		authMid:       authMid,
+		dispatcher:    dispatcher,
+		allowInsecure: allowInsecure,
+	}
+}
+
+// SetupRoutes sets up the webhook routes
+func (h *WebhookHandler) SetupRoutes(e *echo.Echo) {
+	e.POST("/groups/:id/webhooks", h.Create, h.authMid.Authenticate)
+	e.GET("/groups/:id/webhooks", h.List, h.authMid.Authenticate)
+	e.DELETE("/webhooks/:id", h.Delete, h.authMid.Authenticate)
+	e.GET("/webhooks/:id/deliveries", h.Deliveries, h.authMid.Authenticate)
+	e.POST("/webhooks/:id/test", h.Test, h.authMid.Authenticate)
+}
+
+// Create handles subscribing a URL to a group's events
+func (h *WebhookHandler) Create(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request format")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid request data")
+	}
+
+	if msg := h.checkURL(req.URL); msg != "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, msg)
+	}
+
+	for _, event := range req.Events {
+		if !webhook.ValidEvent(event) {
+			return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Unknown webhook event "+strconv.Quote(event))
+		}
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	w := &models.Webhook{
+		GroupID:   groupID,
+		URL:       req.URL,
+		Events:    req.Events,
+		CreatedBy: user.ID,
+	}
+
+	if err := h.store.Webhooks().Create(w); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create webhook")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, &WebhookCreatedResponse{Webhook: w, Secret: w.Secret})
+}
+
+// List handles retrieving a group's webhooks
+func (h *WebhookHandler) List(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	webhooks, err := h.store.Webhooks().ListByGroup(groupID)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve webhooks")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, webhooks)
+}
+
+// Delete handles removing a webhook
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	w, err := memberWebhook(c, h.store, user.ID)
+	if w == nil {
+		return err
+	}
+
+	if err := h.store.Webhooks().Delete(w.ID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Webhook not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to delete webhook")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// Deliveries handles retrieving a webhook's recent deliveries, newest first
+func (h *WebhookHandler) Deliveries(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	w, err := memberWebhook(c, h.store, user.ID)
+	if w == nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	deliveries, err := h.store.Webhooks().ListDeliveries(w.ID, limit)
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve deliveries")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, deliveries)
+}
+
+// Test handles queueing a ping event so members can check their receiver
+func (h *WebhookHandler) Test(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	w, err := memberWebhook(c, h.store, user.ID)
+	if w == nil {
+		return err
+	}
+
+	delivery, err := h.dispatcher.Enqueue(w, webhook.EventPing, h.dispatcher.Ping(user.ID))
+	if err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to queue test event")
+	}
+
+	return api.SendSuccess(c, http.StatusAccepted, delivery)
+}
+
+// memberWebhook loads the webhook named in the path if the user belongs to its group.
+// When it can't, it sends the error response and returns a nil webhook.
+func memberWebhook(c echo.Context, store models.Store, userID string) (*models.Webhook, error) {
+	id := c.Param("id")
+	if id == "" {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Webhook ID is required")
+	}
+
+	w, err := store.Webhooks().GetByID(id)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Webhook not found")
+		}
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve webhook")
+	}
+
+	groups, err := store.Groups().GetByGroupID(w.GroupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Webhook not found")
+		}
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	// Webhooks of other groups are reported missing rather than forbidden so IDs can't be probed
+	if !isGroupMember(groups, userID) {
+		return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Webhook not found")
+	}
+
+	return w, nil
+}
+
+// checkURL returns why a webhook URL can't be subscribed, or "" if it can.
+// Hostnames are only resolved when a delivery is sent, where the dispatcher's client checks them.
+func (h *WebhookHandler) checkURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return "Invalid webhook URL"
+	}
+
+	if h.allowInsecure {
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return "Webhook URL must be http or https"
+		}
+		return ""
+	}
+
+	if u.Scheme != "https" {
+		return "Webhook URL must be https"
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if addr, err := netip.ParseAddr(host); err == nil && !webhook.Routable(addr) {
+		return "Webhook URL must be a public address"
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "Webhook URL must be a public address"
+	}
+	return ""
+}
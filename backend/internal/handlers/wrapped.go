@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/api"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/middleware"
+	"github.com/jamoowen/reminiscer/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+type WrappedHandler struct {
+	store   models.Store
+	authMid *middleware.AuthMiddleware
+}
+
+func NewWrappedHandler(store models.Store, authMid *middleware.AuthMiddleware) *WrappedHandler {
+	return &WrappedHandler{
+		store:   store,
+		authMid: authMid,
+	}
+}
+
+// SetupRoutes sets up the year-in-review routes
+func (h *WrappedHandler) SetupRoutes(e *echo.Echo) {
+	e.GET("/groups/:id/wrapped", h.GetGroupReport, h.authMid.Authenticate)
+	e.POST("/groups/:id/wrapped/snapshots", h.SnapshotGroupReport, h.authMid.Authenticate)
+	e.GET("/me/wrapped", h.GetUserReport, h.authMid.Authenticate)
+	e.POST("/me/wrapped/snapshots", h.SnapshotUserReport, h.authMid.Authenticate)
+	e.DELETE("/wrapped/snapshots/:id", h.DeleteSnapshot, h.authMid.Authenticate)
+
+	// Shared snapshots are public
+	e.GET("/wrapped/:token", h.GetSnapshot)
+}
+
+// GetGroupReport handles computing a group's year-in-review report
+func (h *WrappedHandler) GetGroupReport(c echo.Context) error {
+	report, err := h.groupReport(c)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	return api.SendSuccess(c, http.StatusOK, report)
+}
+
+// SnapshotGroupReport handles storing a shareable copy of a group's report
+func (h *WrappedHandler) SnapshotGroupReport(c echo.Context) error {
+	report, err := h.groupReport(c)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	return h.snapshot(c, report)
+}
+
+// GetUserReport handles computing the current user's year-in-review report
+func (h *WrappedHandler) GetUserReport(c echo.Context) error {
+	report, err := h.userReport(c)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	return api.SendSuccess(c, http.StatusOK, report)
+}
+
+// SnapshotUserReport handles storing a shareable copy of the current user's report
+func (h *WrappedHandler) SnapshotUserReport(c echo.Context) error {
+	report, err := h.userReport(c)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	return h.snapshot(c, report)
+}
+
+// GetSnapshot handles retrieving a shared report by its token
+func (h *WrappedHandler) GetSnapshot(c echo.Context) error {
+	token := c.Param("token")
+	if token == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Token is required")
+	}
+
+	snapshot, err := h.store.Wrapped().GetSnapshot(token)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Snapshot not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve snapshot")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, snapshot)
+}
+
+// DeleteSnapshot handles revoking a shared report
+func (h *WrappedHandler) DeleteSnapshot(c echo.Context) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Snapshot ID is required")
+	}
+
+	if err := h.store.Wrapped().DeleteSnapshot(id, user.ID); err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Snapshot not found")
+		}
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to delete snapshot")
+	}
+
+	return api.SendSuccess(c, http.StatusOK, nil)
+}
+
+// groupReport computes the report for the group in the path.
+// It returns a nil report once an error response has been sent.
+func (h *WrappedHandler) groupReport(c echo.Context) (*models.WrappedReport, error) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return nil, api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	groupID := c.Param("id")
+	if groupID == "" {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Group ID is required")
+	}
+
+	year, ok := reportYear(c)
+	if !ok {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid year")
+	}
+
+	groups, err := h.store.Groups().GetByGroupID(groupID)
+	if err != nil {
+		if errors.IsCode(err, errors.CodeNotFound) {
+			return nil, api.SendError(c, http.StatusNotFound, errors.CodeNotFound, "Group not found")
+		}
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to retrieve group")
+	}
+
+	if !isGroupMember(groups, user.ID) {
+		return nil, api.SendError(c, http.StatusForbidden, errors.CodeForbidden, "Not a member of this group")
+	}
+
+	report, err := h.store.Wrapped().GetReport(models.WrappedFilter{GroupID: groupID, Year: year})
+	if err != nil {
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to compute report")
+	}
+
+	return report, nil
+}
+
+// userReport computes the report for the current user's uploads.
+// It returns a nil report once an error response has been sent.
+func (h *WrappedHandler) userReport(c echo.Context) (*models.WrappedReport, error) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return nil, api.SendError(c, http.StatusUnauthorized, errors.CodeUnauthorized, "Authentication required")
+	}
+
+	year, ok := reportYear(c)
+	if !ok {
+		return nil, api.SendError(c, http.StatusBadRequest, errors.CodeInvalidInput, "Invalid year")
+	}
+
+	report, err := h.store.Wrapped().GetReport(models.WrappedFilter{UploaderID: user.ID, Year: year})
+	if err != nil {
+		return nil, api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to compute report")
+	}
+
+	return report, nil
+}
+
+// snapshot stores a report and responds with the shareable snapshot
+func (h *WrappedHandler) snapshot(c echo.Context, report *models.WrappedReport) error {
+	user := middleware.GetUserFromContext(c)
+
+	snapshot := &models.WrappedSnapshot{
+		GroupID:   report.GroupID,
+		UserID:    report.UserID,
+		Year:      report.Year,
+		Report:    report,
+		CreatedBy: user.ID,
+	}
+
+	if err := h.store.Wrapped().CreateSnapshot(snapshot); err != nil {
+		return api.SendError(c, http.StatusInternalServerError, errors.CodeDatabaseError, "Failed to create snapshot")
+	}
+
+	return api.SendSuccess(c, http.StatusCreated, snapshot)
+}
+
+// reportYear reads the year query parameter, defaulting to the current year
+func reportYear(c echo.Context) (int, bool) {
+	param := c.QueryParam("year")
+	if param == "" {
+		return time.Now().Year(), true
+	}
+
+	year, err := strconv.Atoi(param)
+	if err != nil || year < 1970 || year > 9999 {
+		return 0, false
+	}
+
+	return year, true
+}
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+)
+
+// MaxArchiveSize is the most an export archive may unpack to, in bytes. Archives are uploaded
+// compressed, so without it a small upload could expand to fill memory.
+const MaxArchiveSize = 100 << 20
+
+// ParseArchive reads the quotes from a Reminiscer export archive, preferring quotes.json over quotes.csv
+func ParseArchive(data []byte) ([]Row, []RowError, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[path.Base(f.Name)] = f
+	}
+
+	budget := &unpackBudget{remaining: MaxArchiveSize}
+
+	if f, ok := files["quotes.json"]; ok {
+		rc, err := budget.open(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rc.Close()
+		return ParseJSON(rc)
+	}
+
+	if f, ok := files["quotes.csv"]; ok {
+		rc, err := budget.open(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rc.Close()
+		return ParseCSV(rc, DefaultCSVMapping)
+	}
+
+	return nil, nil, fmt.Errorf("archive has no quotes.json or quotes.csv, Markdown exports can't be imported")
+}
+
+// unpackBudget caps how many bytes are read from the entries of one zip archive in total
+type unpackBudget struct {
+	remaining int64
+}
+
+// open opens a zip entry, failing reads once the budget is spent. Sizes in the zip's headers
+// can't be trusted, so they are only used to refuse entries early.
+func (b *unpackBudget) open(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > uint64(b.remaining) {
+		return nil, fmt.Errorf("%s is too large, archives can unpack to at most %d MB", f.Name, MaxArchiveSize>>20)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+
+	return &budgetReader{ReadCloser: rc, budget: b, name: f.Name}, nil
+}
+
+// budgetReader reads a zip entry within an unpackBudget
+type budgetReader struct {
+	io.ReadCloser
+	budget *unpackBudget
+	name   string
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	// One byte over the budget is allowed through so an entry of exactly the budget still reads to EOF
+	if int64(len(p)) > r.budget.remaining+1 {
+		p = p[:r.budget.remaining+1]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.budget.remaining -= int64(n)
+	if r.budget.remaining < 0 {
+		return n, fmt.Errorf("%s is too large, archives can unpack to at most %d MB", r.name, MaxArchiveSize>>20)
+	}
+	return n, err
+}
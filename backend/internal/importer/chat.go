@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxChatExportSize is the largest chat export accepted, in bytes
+const MaxChatExportSize = 50 << 20
+
+// ErrTooLarge is returned for chat exports, or the chat file inside one, over MaxChatExportSize
+var ErrTooLarge = errors.New("export is too large")
+
+// Message is a single message parsed from a chat export
+type Message struct {
+	ID        int       `json:"id"` // 1-based position in the export
+	Sender    string    `json:"sender"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
+	Media     bool      `json:"media,omitempty"`    // Placeholder for an attachment or deleted message, not quotable
+	ReplyTo   int       `json:"reply_to,omitempty"` // ID of the message this one replies to, if it is in the export
+}
+
+// Sender summarises the messages sent by one chat participant
+type Sender struct {
+	Name     string `json:"name"`
+	Messages int    `json:"messages"`
+}
+
+// SenderMapping maps a chat sender name to the author used for their quotes
+type SenderMapping map[string]string
+
+// author returns the author for a sender's quotes
+func (m SenderMapping) author(sender string) string {
+	if mapped := strings.TrimSpace(m[sender]); mapped != "" {
+		return mapped
+	}
+	return sender
+}
+
+// ChatParser parses a chat export into messages.
+// Timestamps without a zone in the export are interpreted in loc.
+type ChatParser interface {
+	Name() string
+	Parse(data []byte, loc *time.Location) ([]Message, error)
+}
+
+// ChatOptions tunes how chat exports are parsed
+type ChatOptions struct {
+	DateOrder DateOrder // Only used by formats with locale-dependent dates
+}
+
+// NewChatParser returns the parser for a chat export format
+func NewChatParser(format string, opts ChatOptions) (ChatParser, error) {
+	switch strings.ToLower(format) {
+	case "whatsapp":
+		return &WhatsAppParser{DateOrder: opts.DateOrder}, nil
+	case "telegram":
+		return &TelegramParser{}, nil
+	case "discord":
+		return &DiscordParser{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported chat format %q", format)
+	}
+}
+
+// ReadChatExport reads a chat export, refusing exports over MaxChatExportSize
+func ReadChatExport(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxChatExportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	if len(data) > MaxChatExportSize {
+		return nil, fmt.Errorf("%w, the limit is %d MB", ErrTooLarge, MaxChatExportSize>>20)
+	}
+	return data, nil
+}
+
+// exportFile returns the chat file from an export, unpacking it from a .zip if needed.
+// Zips are searched for a file with the given extension, preferring one named preferred.
+func exportFile(data []byte, ext, preferred string) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return data, nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	var chat *zip.File
+	for _, f := range archive.File {
+		if strings.EqualFold(path.Ext(f.Name), ext) {
+			if chat == nil || path.Base(f.Name) == preferred {
+				chat = f
+			}
+		}
+	}
+	if chat == nil {
+		return nil, fmt.Errorf("zip archive has no chat %s file", ext)
+	}
+
+	rc, err := chat.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", chat.Name, err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(io.LimitReader(rc, MaxChatExportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", chat.Name, err)
+	}
+	if len(b) > MaxChatExportSize {
+		return nil, fmt.Errorf("%w, %s unpacks to more than %d MB", ErrTooLarge, chat.Name, MaxChatExportSize>>20)
+	}
+
+	return b, nil
+}
+
+// Senders lists the participants of a chat, most active first
+func Senders(messages []Message) []Sender {
+	counts := make(map[string]int)
+	for _, m := range messages {
+		if !m.Media {
+			counts[m.Sender]++
+		}
+	}
+
+	senders := make([]Sender, 0, len(counts))
+	for name, n := range counts {
+		senders = append(senders, Sender{Name: name, Messages: n})
+	}
+
+	sort.Slice(senders, func(i, j int) bool {
+		if senders[i].Messages != senders[j].Messages {
+			return senders[i].Messages > senders[j].Messages
+		}
+		return senders[i].Name < senders[j].Name
+	})
+
+	return senders
+}
+
+// SelectMessages turns the chosen messages into import rows, keeping their original timestamps
+// and the message each one replied to. Senders missing from the mapping keep their chat name as the author.
+func SelectMessages(messages []Message, ids []int, senders SenderMapping) ([]Row, []RowError) {
+	byID := make(map[int]Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	var rows []Row
+	var rowErrors []RowError
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		m, ok := byID[id]
+		if !ok {
+			rowErrors = append(rowErrors, RowError{Row: id, Message: "Message not found"})
+			continue
+		}
+		if m.Media {
+			rowErrors = append(rowErrors, RowError{Row: id, Message: "Message has no text"})
+			continue
+		}
+
+		row := Row{
+			Row:       id,
+			Text:      strings.TrimSpace(m.Text),
+			Author:    senders.author(m.Sender),
+			CreatedAt: m.Timestamp,
+		}
+		if parent, ok := byID[m.ReplyTo]; ok && !parent.Media {
+			row.ReplyTo = &Reply{Author: senders.author(parent.Sender), Text: strings.TrimSpace(parent.Text)}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors
+}
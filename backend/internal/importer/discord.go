@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DiscordParser parses a channel exported to JSON with DiscordChatExporter
+type DiscordParser struct{}
+
+type discordExport struct {
+	Messages []discordMessage `json:"messages"`
+}
+
+type discordMessage struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Content   string `json:"content"`
+	Author    struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Nickname string `json:"nickname"`
+	} `json:"author"`
+	Attachments []json.RawMessage `json:"attachments"`
+	Embeds      []json.RawMessage `json:"embeds"`
+	Stickers    []json.RawMessage `json:"stickers"`
+	Reference   *struct {
+		MessageID string `json:"messageId"`
+	} `json:"reference"`
+}
+
+// Name returns the format name
+func (p *DiscordParser) Name() string {
+	return "discord"
+}
+
+// Parse reads a DiscordChatExporter export. Its timestamps carry an offset, so loc
+// only sets the zone they are presented in.
+func (p *DiscordParser) Parse(data []byte, loc *time.Location) ([]Message, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	data, err := exportFile(data, ".json", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var export discordExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Discord export: %w", err)
+	}
+
+	// Replies point at Discord's message IDs, which are mapped to positions once all messages are read
+	var messages []Message
+	var replies []string
+	ids := make(map[string]int)
+	for _, m := range export.Messages {
+		// Pins, joins, thread starts and the like are system messages
+		if m.Type != "Default" && m.Type != "Reply" {
+			continue
+		}
+
+		sender := strings.TrimSpace(m.Author.Nickname)
+		if sender == "" {
+			sender = strings.TrimSpace(m.Author.Name)
+		}
+		if sender == "" {
+			continue
+		}
+
+		timestamp, err := time.Parse(time.RFC3339, m.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("message %s: invalid timestamp %q", m.ID, m.Timestamp)
+		}
+
+		text := strings.TrimSpace(m.Content)
+		hasMedia := len(m.Attachments) > 0 || len(m.Embeds) > 0 || len(m.Stickers) > 0
+
+		id := len(messages) + 1
+		ids[m.ID] = id
+		messages = append(messages, Message{
+			ID:        id,
+			Sender:    sender,
+			Text:      text,
+			Timestamp: timestamp.In(loc),
+			Media:     text == "" && hasMedia,
+		})
+
+		var replyTo string
+		if m.Reference != nil {
+			replyTo = m.Reference.MessageID
+		}
+		replies = append(replies, replyTo)
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no Discord messages found")
+	}
+
+	for i, replyTo := range replies {
+		messages[i].ReplyTo = ids[replyTo]
+	}
+
+	return messages, nil
+}
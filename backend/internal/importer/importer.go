@@ -0,0 +1,393 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/similarity"
+)
+
+// MaxRows is the largest number of rows accepted in a single import
+const MaxRows = 10000
+
+// Row is a single quote parsed from an import file
+type Row struct {
+	Row       int        `json:"row"`          // 1-based position in the source, excluding headers
+	ID        string     `json:"id,omitempty"` // Quote ID from a Reminiscer export, kept when restoring
+	Text      string     `json:"text"`
+	Author    string     `json:"author"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	UnlockAt  *time.Time `json:"unlock_at,omitempty"` // Time capsule unlock time from a Reminiscer export
+	ReplyTo   *Reply     `json:"reply_to,omitempty"`  // Message a chat message replied to
+
+	// Restored from a Reminiscer export when the people they name are members of the group
+	UploaderID string        `json:"uploader_id,omitempty"`
+	Uploader   string        `json:"uploader,omitempty"`
+	Reactions  []RowReaction `json:"reactions,omitempty"`
+
+	Sealed bool `json:"sealed,omitempty"` // Time capsule exported by someone who couldn't open it, so without its text
+}
+
+// RowReaction is a reaction to a quote from a Reminiscer export
+type RowReaction struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reply is the message a row was said in reply to
+type Reply struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+}
+
+// RowError describes why a row can't be imported
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// CSVMapping names the CSV header columns holding each quote field
+type CSVMapping struct {
+	Text      string
+	Author    string
+	CreatedAt string
+}
+
+// DefaultCSVMapping is used for any column that isn't mapped explicitly
+var DefaultCSVMapping = CSVMapping{
+	Text:      "text",
+	Author:    "author",
+	CreatedAt: "created_at",
+}
+
+// dateLayouts are the timestamp formats accepted in import files
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+// ParseCSV reads quotes from CSV with a header row, using the mapping to find columns
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]Row, []RowError, error) {
+	if mapping.Text == "" {
+		mapping.Text = DefaultCSVMapping.Text
+	}
+	if mapping.Author == "" {
+		mapping.Author = DefaultCSVMapping.Author
+	}
+	if mapping.CreatedAt == "" {
+		mapping.CreatedAt = DefaultCSVMapping.CreatedAt
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	textCol, ok := columns[strings.ToLower(mapping.Text)]
+	if !ok {
+		return nil, nil, fmt.Errorf("missing text column %q", mapping.Text)
+	}
+	authorCol, hasAuthor := columns[strings.ToLower(mapping.Author)]
+	dateCol, hasDate := columns[strings.ToLower(mapping.CreatedAt)]
+	idCol, hasID := columns["id"]
+	updatedCol, hasUpdated := columns["updated_at"]
+	unlockCol, hasUnlock := columns["unlock_at"]
+	uploaderIDCol, hasUploaderID := columns["uploader_id"]
+	uploaderCol, hasUploader := columns["uploader"]
+	sealedCol, hasSealed := columns["sealed"]
+	replyAuthorCol, hasReplyAuthor := columns["reply_author"]
+	replyTextCol, hasReplyText := columns["reply_text"]
+	reactionsCol, hasReactions := columns["reactions"]
+
+	var rows []Row
+	var rowErrors []RowError
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: n, Message: "Malformed CSV row"})
+			continue
+		}
+		if n > MaxRows {
+			return nil, nil, fmt.Errorf("too many rows, the limit is %d", MaxRows)
+		}
+
+		row := Row{Row: n, Text: field(record, textCol)}
+		if hasID {
+			row.ID = field(record, idCol)
+		}
+		if hasAuthor {
+			row.Author = field(record, authorCol)
+		}
+		if hasDate {
+			if value := field(record, dateCol); value != "" {
+				createdAt, err := ParseDate(value)
+				if err != nil {
+					rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + value})
+					continue
+				}
+				row.CreatedAt = createdAt
+			}
+		}
+		if hasUpdated {
+			if value := field(record, updatedCol); value != "" {
+				updatedAt, err := ParseDate(value)
+				if err != nil {
+					rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + value})
+					continue
+				}
+				row.UpdatedAt = updatedAt
+			}
+		}
+		if hasUnlock {
+			if value := field(record, unlockCol); value != "" {
+				unlockAt, err := ParseDate(value)
+				if err != nil {
+					rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + value})
+					continue
+				}
+				row.UnlockAt = &unlockAt
+			}
+		}
+		if hasUploaderID {
+			row.UploaderID = field(record, uploaderIDCol)
+		}
+		if hasUploader {
+			row.Uploader = field(record, uploaderCol)
+		}
+		if hasSealed {
+			row.Sealed = field(record, sealedCol) == "true"
+		}
+		if hasReplyText {
+			if text := field(record, replyTextCol); text != "" {
+				row.ReplyTo = &Reply{Text: text}
+				if hasReplyAuthor {
+					row.ReplyTo.Author = field(record, replyAuthorCol)
+				}
+			}
+		}
+		if hasReactions {
+			// Reactions are written as a JSON array in a single cell
+			if value := field(record, reactionsCol); value != "" {
+				var reactions []jsonReaction
+				if err := json.Unmarshal([]byte(value), &reactions); err != nil {
+					rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid reactions"})
+					continue
+				}
+				row.Reactions = parseReactions(reactions)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// jsonReaction is a reaction as it appears in an export
+type jsonReaction struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Emoji     string `json:"emoji"`
+	CreatedAt string `json:"created_at"`
+}
+
+// parseReactions reads exported reactions, leaving the time unset where it can't be read
+func parseReactions(items []jsonReaction) []RowReaction {
+	reactions := make([]RowReaction, 0, len(items))
+	for _, r := range items {
+		if r.Emoji == "" {
+			continue
+		}
+		reaction := RowReaction{UserID: r.UserID, Username: r.Username, Emoji: r.Emoji}
+		if createdAt, err := ParseDate(r.CreatedAt); err == nil {
+			reaction.CreatedAt = createdAt
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions
+}
+
+// jsonRow is a quote as it appears in a JSON import
+type jsonRow struct {
+	ID         string         `json:"id"`
+	Text       string         `json:"text"`
+	Author     string         `json:"author"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"updated_at"`
+	UnlockAt   string         `json:"unlock_at"`
+	UploaderID string         `json:"uploader_id"`
+	Uploader   string         `json:"uploader"`
+	Sealed     bool           `json:"sealed"`
+	ReplyTo    *Reply         `json:"reply_to"`
+	Reactions  []jsonReaction `json:"reactions"`
+}
+
+// ParseJSON reads quotes from a JSON array of {text, author, created_at} objects.
+// The rest of the fields written by a Reminiscer export are read too.
+func ParseJSON(r io.Reader) ([]Row, []RowError, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON, expected an array of quotes: %w", err)
+	}
+	if len(items) > MaxRows {
+		return nil, nil, fmt.Errorf("too many rows, the limit is %d", MaxRows)
+	}
+
+	var rows []Row
+	var rowErrors []RowError
+	for i, item := range items {
+		n := i + 1
+
+		var parsed jsonRow
+		if err := json.Unmarshal(item, &parsed); err != nil {
+			rowErrors = append(rowErrors, RowError{Row: n, Message: "Row is not a quote object"})
+			continue
+		}
+
+		row := Row{
+			Row:        n,
+			ID:         strings.TrimSpace(parsed.ID),
+			Text:       strings.TrimSpace(parsed.Text),
+			Author:     strings.TrimSpace(parsed.Author),
+			UploaderID: strings.TrimSpace(parsed.UploaderID),
+			Uploader:   strings.TrimSpace(parsed.Uploader),
+			Sealed:     parsed.Sealed,
+			Reactions:  parseReactions(parsed.Reactions),
+		}
+		if parsed.ReplyTo != nil && strings.TrimSpace(parsed.ReplyTo.Text) != "" {
+			row.ReplyTo = parsed.ReplyTo
+		}
+		if parsed.CreatedAt != "" {
+			createdAt, err := ParseDate(parsed.CreatedAt)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + parsed.CreatedAt})
+				continue
+			}
+			row.CreatedAt = createdAt
+		}
+		if parsed.UpdatedAt != "" {
+			updatedAt, err := ParseDate(parsed.UpdatedAt)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + parsed.UpdatedAt})
+				continue
+			}
+			row.UpdatedAt = updatedAt
+		}
+		if parsed.UnlockAt != "" {
+			unlockAt, err := ParseDate(parsed.UnlockAt)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: n, Message: "Invalid date " + parsed.UnlockAt})
+				continue
+			}
+			row.UnlockAt = &unlockAt
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// Validate checks parsed rows and returns the errors for rows that can't become quotes
+func Validate(rows []Row) []RowError {
+	var rowErrors []RowError
+	now := time.Now()
+	for _, row := range rows {
+		switch {
+		case row.Sealed:
+			rowErrors = append(rowErrors, RowError{Row: row.Row, Message: "Sealed time capsule, its text wasn't exported"})
+		case row.Text == "":
+			rowErrors = append(rowErrors, RowError{Row: row.Row, Message: "Text is required"})
+		case row.Author == "":
+			rowErrors = append(rowErrors, RowError{Row: row.Row, Message: "Author is required"})
+		case row.CreatedAt.After(now):
+			rowErrors = append(rowErrors, RowError{Row: row.Row, Message: "Date is in the future"})
+		}
+	}
+	return rowErrors
+}
+
+// ParseDate parses a timestamp in any of the accepted import formats
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// field returns a trimmed CSV field, or an empty string if the record is too short
+func field(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// Existing is a quote already stored in the group being imported into
+type Existing struct {
+	ID   string
+	Text string
+}
+
+// FindDuplicates returns errors for rows that closely match an existing quote or an earlier row.
+// Rows marked in skip are already invalid and are neither checked nor compared against.
+func FindDuplicates(rows []Row, existing []Existing, skip map[int]bool) []RowError {
+	texts := make([]string, len(existing))
+	for i, q := range existing {
+		texts[i] = q.Text
+	}
+
+	// Rows are added to the index as they pass so duplicates within the file are caught too
+	index := similarity.NewIndex(texts)
+	var indexedRows []int
+	var rowErrors []RowError
+	for _, row := range rows {
+		if skip[row.Row] {
+			continue
+		}
+
+		if matches := index.Find(row.Text); len(matches) > 0 {
+			var message string
+			if m := matches[0].Index; m < len(existing) {
+				message = "Likely duplicate of quote " + existing[m].ID
+			} else {
+				message = fmt.Sprintf("Likely duplicate of row %d", indexedRows[m-len(existing)])
+			}
+			rowErrors = append(rowErrors, RowError{Row: row.Row, Message: message})
+			continue
+		}
+
+		index.Add(row.Text)
+		indexedRows = append(indexedRows, row.Row)
+	}
+
+	return rowErrors
+}
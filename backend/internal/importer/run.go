@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// Options controls how Run turns parsed rows into quotes
+type Options struct {
+	GroupID         string
+	UploaderID      string            // Member doing the import, every quote is added by them
+	Members         map[string]string // IDs and usernames of the group's members, to recognise the importer's own uploads and reactions
+	Source          string            // Format the rows came from, recorded on the import
+	DryRun          bool              // Check the rows without creating anything
+	SkipInvalid     bool              // Import the valid rows when some are invalid, rather than none
+	AllowDuplicates bool              // Keep rows that look like quotes the group already has
+}
+
+// Result is the outcome of Run
+type Result struct {
+	Quotes    []*models.Quote    // Quotes created, or that would be on a dry run
+	Reactions []*models.Reaction // Reactions restored to them
+	Errors    []RowError         // Why rows were left out, in row order
+	Import    *models.Import     // nil unless quotes were created
+}
+
+// Run validates parsed rows, checks them for duplicates and, unless it's a dry run, creates the
+// quotes in a single import. rowErrors are the errors from parsing. When the rows can't be
+// imported an invalid input error is returned along with the result explaining why.
+func Run(store models.QuoteStore, rows []Row, rowErrors []RowError, opts Options) (*Result, error) {
+	rowErrors = append(rowErrors, Validate(rows)...)
+
+	invalid := make(map[int]bool)
+	for _, e := range rowErrors {
+		invalid[e.Row] = true
+	}
+
+	if !opts.AllowDuplicates {
+		quotes, err := store.ListByGroup(opts.GroupID)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to check for duplicates")
+		}
+
+		// Capsules the importer can't open are left out, a match would hint at what they say
+		now := time.Now()
+		existing := make([]Existing, 0, len(quotes))
+		for _, q := range quotes {
+			if !q.SealedFor(opts.UploaderID, now) {
+				existing = append(existing, Existing{ID: q.ID, Text: q.Text})
+			}
+		}
+
+		for _, e := range FindDuplicates(rows, existing, invalid) {
+			rowErrors = append(rowErrors, e)
+			invalid[e.Row] = true
+		}
+	}
+
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+
+	result := &Result{Errors: rowErrors}
+	if result.Errors == nil {
+		result.Errors = []RowError{}
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		if invalid[row.Row] {
+			continue
+		}
+		quote := &models.Quote{
+			ID:         row.ID,
+			Text:       row.Text,
+			Author:     row.Author,
+			UploaderID: opts.UploaderID,
+			GroupID:    opts.GroupID,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+		}
+		// An archive can be edited before it is uploaded, so quotes are never credited to another
+		// member. Whoever uploaded them before is kept by name only.
+		if uploader := member(opts.Members, row.UploaderID, row.Uploader); uploader != opts.UploaderID {
+			quote.OriginalUploader = row.Uploader
+			if quote.OriginalUploader == "" {
+				quote.OriginalUploader = opts.Members[uploader]
+			}
+		}
+		if row.ReplyTo != nil {
+			quote.ReplyTo = &models.Reply{Author: row.ReplyTo.Author, Text: row.ReplyTo.Text}
+		}
+		// Only IDs that look like ours are kept, anything else gets a fresh one. Every quote has
+		// one before it is stored so its reactions can refer to it.
+		if _, err := uuid.Parse(row.ID); err != nil {
+			quote.ID = uuid.New().String()
+		}
+		// Capsules that have already opened come back as ordinary quotes rather than being announced again
+		if row.UnlockAt != nil && row.UnlockAt.After(now) {
+			quote.UnlockAt = row.UnlockAt
+		}
+		result.Quotes = append(result.Quotes, quote)
+
+		// Only the importer's own reactions are restored, the archive can't speak for anyone else
+		for _, r := range row.Reactions {
+			if userID := member(opts.Members, r.UserID, r.Username); userID != "" && userID == opts.UploaderID {
+				result.Reactions = append(result.Reactions, &models.Reaction{
+					QuoteID:   quote.ID,
+					UserID:    userID,
+					Emoji:     r.Emoji,
+					CreatedAt: r.CreatedAt,
+				})
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if len(rowErrors) > 0 && !opts.SkipInvalid {
+		return result, errors.InvalidInput("Import has invalid rows")
+	}
+
+	if len(result.Quotes) == 0 {
+		return result, errors.InvalidInput("No quotes to import")
+	}
+
+	imp := &models.Import{
+		GroupID:   opts.GroupID,
+		Source:    opts.Source,
+		CreatedBy: opts.UploaderID,
+	}
+
+	if err := store.Import(imp, result.Quotes, result.Reactions); err != nil {
+		return result, errors.DatabaseError("Failed to import quotes")
+	}
+	result.Import = imp
+
+	return result, nil
+}
+
+// member returns the ID of the group member an exported user refers to, matching by ID and then
+// by username so exports can move between instances. It returns "" when neither matches.
+func member(members map[string]string, userID, username string) string {
+	if _, ok := members[userID]; ok && userID != "" {
+		return userID
+	}
+	if username == "" {
+		return ""
+	}
+	for id, name := range members {
+		if name == username {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramParser parses the result.json written by Telegram Desktop's "Export chat history",
+// either on its own or zipped with the export folder
+type TelegramParser struct{}
+
+// telegramExport is a single chat export, or a full account export holding a list of chats
+type telegramExport struct {
+	Messages []telegramMessage `json:"messages"`
+	Chats    *struct {
+		List []struct {
+			Messages []telegramMessage `json:"messages"`
+		} `json:"list"`
+	} `json:"chats"`
+}
+
+type telegramMessage struct {
+	ID           int             `json:"id"`
+	Type         string          `json:"type"`
+	Date         string          `json:"date"`
+	DateUnix     string          `json:"date_unixtime"`
+	From         *string         `json:"from"`
+	FromID       string          `json:"from_id"`
+	ReplyTo      int             `json:"reply_to_message_id"`
+	Text         json.RawMessage `json:"text"`
+	Photo        string          `json:"photo"`
+	File         string          `json:"file"`
+	MediaType    string          `json:"media_type"`
+	Poll         json.RawMessage `json:"poll"`
+	Location     json.RawMessage `json:"location_information"`
+	ContactPhone json.RawMessage `json:"contact_information"`
+}
+
+// Name returns the format name
+func (p *TelegramParser) Name() string {
+	return "telegram"
+}
+
+// Parse reads a Telegram export
+func (p *TelegramParser) Parse(data []byte, loc *time.Location) ([]Message, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	data, err := exportFile(data, ".json", "result.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var export telegramExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Telegram export: %w", err)
+	}
+
+	source := export.Messages
+	if source == nil && export.Chats != nil {
+		if len(export.Chats.List) != 1 {
+			return nil, fmt.Errorf("export contains %d chats, export a single chat instead", len(export.Chats.List))
+		}
+		source = export.Chats.List[0].Messages
+	}
+
+	// Replies point at Telegram's message IDs, which are mapped to positions once all messages are read
+	var messages []Message
+	var replies []int
+	ids := make(map[int]int)
+	for _, m := range source {
+		// Service messages such as joins, pins and calls have no sender
+		if m.Type != "message" {
+			continue
+		}
+
+		sender := m.FromID
+		if m.From != nil && strings.TrimSpace(*m.From) != "" {
+			sender = strings.TrimSpace(*m.From)
+		}
+		if sender == "" {
+			continue
+		}
+
+		timestamp, err := m.timestamp(loc)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", m.ID, err)
+		}
+
+		text, err := telegramText(m.Text)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", m.ID, err)
+		}
+
+		id := len(messages) + 1
+		ids[m.ID] = id
+		messages = append(messages, Message{
+			ID:        id,
+			Sender:    sender,
+			Text:      strings.TrimSpace(text),
+			Timestamp: timestamp,
+			// Captioned photos and files keep their caption as the text
+			Media: strings.TrimSpace(text) == "" && m.hasMedia(),
+		})
+		replies = append(replies, m.ReplyTo)
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no Telegram messages found")
+	}
+
+	for i, replyTo := range replies {
+		messages[i].ReplyTo = ids[replyTo]
+	}
+
+	return messages, nil
+}
+
+// timestamp prefers the Unix time, which newer exports include, over the local date
+func (m telegramMessage) timestamp(loc *time.Location) (time.Time, error) {
+	if m.DateUnix != "" {
+		seconds, err := strconv.ParseInt(m.DateUnix, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", m.DateUnix)
+		}
+		return time.Unix(seconds, 0).In(loc), nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", m.Date, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", m.Date)
+	}
+	return t, nil
+}
+
+// hasMedia reports whether the message carries an attachment
+func (m telegramMessage) hasMedia() bool {
+	return m.Photo != "" || m.File != "" || m.MediaType != "" ||
+		len(m.Poll) > 0 || len(m.Location) > 0 || len(m.ContactPhone) > 0
+}
+
+// telegramText flattens a message's text, which is either a string or a list of
+// plain strings and formatted {type, text} entities
+func telegramText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("unrecognised text")
+	}
+
+	var b strings.Builder
+	for _, part := range parts {
+		var plain string
+		if err := json.Unmarshal(part, &plain); err == nil {
+			b.WriteString(plain)
+			continue
+		}
+
+		var entity struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(part, &entity); err != nil {
+			return "", fmt.Errorf("unrecognised text")
+		}
+		b.WriteString(entity.Text)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,239 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateOrder is the order of day and month in exported timestamps
+type DateOrder string
+
+const (
+	DateOrderAuto DateOrder = ""
+	DateOrderDMY  DateOrder = "dmy"
+	DateOrderMDY  DateOrder = "mdy"
+	DateOrderYMD  DateOrder = "ymd"
+)
+
+// WhatsAppParser parses WhatsApp "Export chat" files, either the plain .txt or the .zip with media.
+// Exports use the phone's locale, so both iOS "[d/m/y, h:m:s] Name: text" and Android
+// "d/m/y, h:m - Name: text" lines are accepted, with 12 or 24 hour clocks and any of / . - as separators.
+type WhatsAppParser struct {
+	// DateOrder forces how ambiguous dates are read; by default it is detected from the export
+	DateOrder DateOrder
+}
+
+// whatsAppLine matches the timestamp that starts each message
+var whatsAppLine = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?\s*([AaPp]\.?\s?[Mm]\.?)?\]?\s*(?:-\s+)?(.*)$`)
+
+// whatsAppMedia are the placeholders WhatsApp writes instead of attachments and deleted messages
+var whatsAppMedia = []string{
+	"<media omitted>",
+	"image omitted",
+	"video omitted",
+	"audio omitted",
+	"sticker omitted",
+	"gif omitted",
+	"document omitted",
+	"contact card omitted",
+	"<attached:",
+	"(file attached)",
+	"this message was deleted",
+	"you deleted this message",
+	"missed voice call",
+	"missed video call",
+	"null",
+}
+
+// Name returns the format name
+func (p *WhatsAppParser) Name() string {
+	return "whatsapp"
+}
+
+type whatsAppStamp struct {
+	a, b, c      string
+	hour, minute int
+	second       int
+	meridiem     string
+}
+
+// Parse reads a WhatsApp export
+func (p *WhatsAppParser) Parse(data []byte, loc *time.Location) ([]Message, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// The chat is "_chat.txt" on iOS and "WhatsApp Chat with <name>.txt" on Android
+	chat, err := exportFile(data, ".txt", "_chat.txt")
+	if err != nil {
+		return nil, err
+	}
+	text := string(chat)
+
+	type pending struct {
+		stamp  whatsAppStamp
+		sender string
+		lines  []string
+	}
+
+	var entries []*pending
+	var current *pending
+	for _, line := range strings.Split(text, "\n") {
+		line = cleanWhatsAppLine(line)
+
+		match := whatsAppLine.FindStringSubmatch(line)
+		if match == nil {
+			// Lines without a timestamp continue the previous message
+			if current != nil {
+				current.lines = append(current.lines, line)
+			}
+			continue
+		}
+
+		hour, _ := strconv.Atoi(match[4])
+		minute, _ := strconv.Atoi(match[5])
+		second, _ := strconv.Atoi(match[6])
+		stamp := whatsAppStamp{
+			a: match[1], b: match[2], c: match[3],
+			hour: hour, minute: minute, second: second,
+			meridiem: strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(match[7])),
+		}
+
+		// System messages such as "Messages are end-to-end encrypted" have no sender
+		sender, body, ok := strings.Cut(match[8], ": ")
+		if !ok || strings.TrimSpace(sender) == "" {
+			current = nil
+			continue
+		}
+
+		current = &pending{stamp: stamp, sender: strings.TrimSpace(sender), lines: []string{body}}
+		entries = append(entries, current)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no WhatsApp messages found")
+	}
+
+	order := p.DateOrder
+	if order == DateOrderAuto {
+		stamps := make([]whatsAppStamp, len(entries))
+		for i, e := range entries {
+			stamps[i] = e.stamp
+		}
+		order = detectDateOrder(stamps)
+	}
+
+	messages := make([]Message, 0, len(entries))
+	for i, e := range entries {
+		timestamp, err := e.stamp.toTime(order, loc)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+
+		body := strings.TrimSpace(strings.Join(e.lines, "\n"))
+		body = strings.TrimSpace(strings.TrimSuffix(body, "<This message was edited>"))
+
+		messages = append(messages, Message{
+			ID:        i + 1,
+			Sender:    e.sender,
+			Text:      body,
+			Timestamp: timestamp,
+			Media:     isWhatsAppMedia(body),
+		})
+	}
+
+	return messages, nil
+}
+
+// cleanWhatsAppLine strips the byte order mark, direction marks and odd spaces WhatsApp inserts
+func cleanWhatsAppLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	line = strings.NewReplacer(
+		"\ufeff", "",
+		"\u200e", "",
+		"\u200f", "",
+		"\u00a0", " ",
+		"\u202f", " ",
+	).Replace(line)
+	return line
+}
+
+// isWhatsAppMedia reports whether a message is only a media or deletion placeholder
+func isWhatsAppMedia(body string) bool {
+	lower := strings.ToLower(body)
+	for _, placeholder := range whatsAppMedia {
+		if lower == placeholder || (strings.HasPrefix(placeholder, "<") && strings.HasPrefix(lower, placeholder)) {
+			return true
+		}
+	}
+	return strings.HasSuffix(lower, "(file attached)")
+}
+
+// detectDateOrder works out whether dates are day or month first from values that can only be one
+func detectDateOrder(stamps []whatsAppStamp) DateOrder {
+	for _, s := range stamps {
+		if len(s.a) == 4 {
+			return DateOrderYMD
+		}
+	}
+
+	for _, s := range stamps {
+		a, _ := strconv.Atoi(s.a)
+		b, _ := strconv.Atoi(s.b)
+		if a > 12 {
+			return DateOrderDMY
+		}
+		if b > 12 {
+			return DateOrderMDY
+		}
+	}
+
+	// Nothing disambiguates, so assume the more common day-first order
+	return DateOrderDMY
+}
+
+// toTime builds the timestamp in the given date order and location
+func (s whatsAppStamp) toTime(order DateOrder, loc *time.Location) (time.Time, error) {
+	var dayStr, monthStr, yearStr string
+	switch order {
+	case DateOrderMDY:
+		monthStr, dayStr, yearStr = s.a, s.b, s.c
+	case DateOrderYMD:
+		yearStr, monthStr, dayStr = s.a, s.b, s.c
+	default:
+		dayStr, monthStr, yearStr = s.a, s.b, s.c
+	}
+
+	day, _ := strconv.Atoi(dayStr)
+	month, _ := strconv.Atoi(monthStr)
+	year, _ := strconv.Atoi(yearStr)
+	if len(yearStr) <= 2 {
+		year += 2000
+	}
+
+	hour := s.hour
+	switch s.meridiem {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || s.minute > 59 || s.second > 59 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %s/%s/%s", s.a, s.b, s.c)
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, s.minute, s.second, 0, loc)
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date %s/%s/%s", s.a, s.b, s.c)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,119 @@
+package inbound
+
+import (
+	"strings"
+)
+
+// authResult is one check in an Authentication-Results header, e.g. "dkim=pass header.d=example.com"
+type authResult struct {
+	method string
+	result string
+	props  map[string]string // e.g. header.d, smtp.mailfrom, header.from
+}
+
+// SenderVerified reports whether the relay that received the email vouched for its From address,
+// going by the Authentication-Results it added. A DMARC pass is enough, as is a DKIM signature or
+// SPF check that passed for the From address's domain or one aligned with it. Emails failing
+// DMARC are refused whatever else passed, and so are emails without results: a relay that doesn't
+// check can't vouch for anyone.
+func (m *Message) SenderVerified() bool {
+	domain := domainOf(m.From.Address)
+	if domain == "" {
+		return false
+	}
+
+	verified := false
+	for _, r := range parseAuthResults(m.AuthResults) {
+		switch r.method {
+		case "dmarc":
+			if r.result == "fail" {
+				return false
+			}
+			from := domainOf(r.props["header.from"])
+			if r.result == "pass" && (from == "" || aligned(from, domain)) {
+				verified = true
+			}
+		case "dkim":
+			signer := domainOf(r.props["header.d"])
+			if signer == "" {
+				signer = domainOf(r.props["header.i"])
+			}
+			if r.result == "pass" && aligned(signer, domain) {
+				verified = true
+			}
+		case "spf":
+			if r.result == "pass" && aligned(domainOf(r.props["smtp.mailfrom"]), domain) {
+				verified = true
+			}
+		}
+	}
+	return verified
+}
+
+// parseAuthResults reads the checks in an Authentication-Results header value. The first
+// section names the server that ran them and is skipped.
+func parseAuthResults(header string) []authResult {
+	sections := strings.Split(stripComments(header), ";")
+	if len(sections) < 2 {
+		return nil
+	}
+
+	var results []authResult
+	for _, section := range sections[1:] {
+		fields := strings.Fields(section)
+		if len(fields) == 0 {
+			continue
+		}
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+
+		r := authResult{
+			method: strings.ToLower(method),
+			result: strings.ToLower(result),
+			props:  make(map[string]string),
+		}
+		for _, field := range fields[1:] {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				r.props[strings.ToLower(key)] = strings.Trim(value, `"`)
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// stripComments removes the parenthesised comments relays add to header values, which can nest
+func stripComments(value string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// domainOf returns the lowercased domain of an address, or the value itself if it is a bare domain
+func domainOf(value string) string {
+	if at := strings.LastIndex(value, "@"); at >= 0 {
+		value = value[at+1:]
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+}
+
+// aligned reports whether two domains are the same or one is a subdomain of the other, as DMARC's
+// relaxed alignment allows
+func aligned(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
@@ -0,0 +1,123 @@
+package inbound
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+var (
+	// forwardMarker starts a message forwarded inline, e.g. Gmail's "---------- Forwarded message ---------"
+	// or Apple Mail's "Begin forwarded message:"
+	forwardMarker = regexp.MustCompile(`(?i)^\s*(-{2,}\s*forwarded message\s*-{2,}|begin forwarded message:)\s*$`)
+
+	// originalMarker starts the message being answered or forwarded in Outlook and Thunderbird
+	originalMarker = regexp.MustCompile(`(?i)^\s*-{2,}\s*original message\s*-{2,}\s*$`)
+
+	// replyHeader introduces a quoted reply, e.g. "On Mon, 1 Jan 2024 at 10:00, Sam <sam@example.com> wrote:"
+	replyHeader = regexp.MustCompile(`(?is)^\s*on\s.+\swrote:\s*$`)
+
+	// outlookSeparator is the rule Outlook draws above the message being answered
+	outlookSeparator = regexp.MustCompile(`^\s*_{10,}\s*$`)
+
+	// mobileSignature matches the lines mail apps sign messages with
+	mobileSignature = regexp.MustCompile(`(?i)^\s*(sent from my \S|sent from (mail|outlook|yahoo|gmail)\b|get outlook for \S)`)
+
+	// headerLine matches the header block at the top of a forwarded message
+	headerLine = regexp.MustCompile(`(?i)^\s*\*?(from|sent|date|to|cc|subject|reply-to)\*?:`)
+)
+
+// Extract finds the quote in an email's text body. For a forwarded message it is the message
+// forwarded, along with the name of who sent it. Otherwise it is the body written above any
+// quoted reply or signature.
+func Extract(text string) (quote, forwardedFrom string) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for i, line := range lines {
+		isForward := forwardMarker.MatchString(line)
+		// A forwarded message usually comes with nothing above it, where a reply always does
+		if !isForward && !(originalMarker.MatchString(line) && strings.TrimSpace(topOf(lines[:i])) == "") {
+			continue
+		}
+
+		body, from := splitForwardHeaders(lines[i+1:])
+		quote, _ := Extract(body)
+		return quote, from
+	}
+
+	return topOf(lines), ""
+}
+
+// topOf returns the lines written above any quoted reply or signature
+func topOf(lines []string) string {
+	var kept []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if line == "-- " || trimmed == "--" || mobileSignature.MatchString(line) ||
+			outlookSeparator.MatchString(line) || originalMarker.MatchString(line) {
+			break
+		}
+		if replyHeader.MatchString(line) {
+			break
+		}
+		// Reply headers are often wrapped over two lines
+		if i+1 < len(lines) && strings.HasPrefix(strings.ToLower(trimmed), "on ") &&
+			replyHeader.MatchString(line+" "+lines[i+1]) {
+			break
+		}
+		// Outlook puts the answered message's headers under a blank line, with no marker
+		if headerLine.MatchString(line) && strings.HasPrefix(strings.ToLower(trimmed), "from:") &&
+			i+1 < len(lines) && headerLine.MatchString(lines[i+1]) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+
+	return tidyLines(kept)
+}
+
+// splitForwardHeaders splits a forwarded message into its body and the name of its sender
+func splitForwardHeaders(lines []string) (body, from string) {
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			// Blank lines can come before the headers, the first after them ends them
+			if from != "" {
+				break
+			}
+			continue
+		}
+		if !headerLine.MatchString(line) {
+			break
+		}
+
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(strings.Trim(name, "* "), "from") {
+			from = senderName(strings.TrimSpace(value))
+		}
+	}
+
+	return strings.Join(lines[i:], "\n"), from
+}
+
+// senderName returns the display name in a forwarded From header, or the address if there is none
+func senderName(value string) string {
+	value = strings.ReplaceAll(value, "*", "")
+	if addr, err := mail.ParseAddress(value); err == nil {
+		if addr.Name != "" {
+			return addr.Name
+		}
+		return addr.Address
+	}
+	// Outlook gives just the name, or a name and an address in brackets
+	if i := strings.IndexAny(value, "<["); i > 0 {
+		value = value[:i]
+	}
+	return strings.Trim(strings.TrimSpace(value), `"`)
+}
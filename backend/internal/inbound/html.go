@@ -0,0 +1,153 @@
+package inbound
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedTags hold no text worth keeping
+var skippedTags = map[string]bool{"head": true, "style": true, "script": true, "title": true}
+
+// signatureMarkers are the classes and IDs mail clients wrap signatures in
+var signatureMarkers = []string{"gmail_signature", "moz-signature", "Signature"}
+
+// blockTags start their content on a new line
+var blockTags = map[string]bool{
+	"address": true, "blockquote": true, "br": true, "div": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "hr": true, "li": true, "p": true, "pre": true, "table": true, "tr": true,
+}
+
+// voidTags never have an end tag
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// HTMLToText converts an HTML email body to plain text. Blockquotes become "> " quoted lines so
+// quoted replies can be told apart the same way as in plain text bodies, and signatures are dropped.
+func HTMLToText(body string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+
+	var (
+		lines      []string
+		line       strings.Builder
+		quoteDepth int
+		lineDepth  int
+		open       []string // Open elements
+		skipping   []bool   // Whether each open element's content is skipped
+	)
+
+	flush := func() {
+		text := strings.TrimSpace(line.String())
+		lines = append(lines, strings.Repeat("> ", lineDepth)+text)
+		line.Reset()
+		lineDepth = quoteDepth
+	}
+	skipped := func() bool {
+		return len(skipping) > 0 && skipping[len(skipping)-1]
+	}
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if line.Len() > 0 {
+				flush()
+			}
+			return tidyLines(lines)
+
+		case html.TextToken:
+			if skipped() {
+				continue
+			}
+			text := strings.Join(strings.Fields(string(tokenizer.Text())), " ")
+			if text == "" {
+				continue
+			}
+			if line.Len() == 0 {
+				lineDepth = quoteDepth
+			} else {
+				line.WriteString(" ")
+			}
+			line.WriteString(text)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if skipped() {
+				if !voidTags[token.Data] && token.Type == html.StartTagToken {
+					open = append(open, token.Data)
+					skipping = append(skipping, true)
+				}
+				continue
+			}
+			if blockTags[token.Data] && (line.Len() > 0 || token.Data == "br") {
+				flush()
+			}
+			if voidTags[token.Data] || token.Type == html.SelfClosingTagToken {
+				continue
+			}
+			if token.Data == "blockquote" {
+				quoteDepth++
+				lineDepth = quoteDepth
+			}
+			open = append(open, token.Data)
+			skipping = append(skipping, skippedTags[token.Data] || isSignature(token))
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			// Close everything up to the matching element, browsers forgive unclosed tags
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tag {
+					continue
+				}
+				wasSkipped := skipping[i]
+				open, skipping = open[:i], skipping[:i]
+				if wasSkipped {
+					break
+				}
+				if blockTags[tag] && line.Len() > 0 {
+					flush()
+				}
+				if tag == "blockquote" && quoteDepth > 0 {
+					quoteDepth--
+					lineDepth = quoteDepth
+				}
+				break
+			}
+		}
+	}
+}
+
+// isSignature reports whether an element is one a mail client wraps a signature in
+func isSignature(token html.Token) bool {
+	for _, attr := range token.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		for _, value := range strings.Fields(attr.Val) {
+			for _, marker := range signatureMarkers {
+				if value == marker {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// tidyLines joins lines, keeping at most one blank line between paragraphs
+func tidyLines(lines []string) string {
+	var kept []string
+	for _, l := range lines {
+		blank := strings.TrimSpace(strings.Trim(l, "> ")) == ""
+		if blank && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		if blank {
+			l = ""
+		}
+		kept = append(kept, l)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
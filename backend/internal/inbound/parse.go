@@ -0,0 +1,222 @@
+// Package inbound reads emails sent to a group's inbound address and finds the quote in them
+package inbound
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	// MaxSize is the largest email accepted. Quotes are short and attachments are skipped, but
+	// they still arrive with the message.
+	MaxSize = 25 << 20
+
+	// maxTextSize bounds how much of a text part is read
+	maxTextSize = 1 << 20
+
+	// maxDepth bounds how deeply multipart bodies can nest
+	maxDepth = 5
+)
+
+// ErrNoSender is returned for emails without a usable From address
+var ErrNoSender = errors.New("inbound: message has no sender")
+
+// Message is an email reduced to what is needed to turn it into a quote
+type Message struct {
+	MessageID   string
+	From        *mail.Address
+	Recipients  []string // Lowercased, from the envelope headers the relay added first, then To and Cc
+	Subject     string
+	Text        string // The plain text body, converted from HTML when there is no plain part
+	AuthResults string // Authentication-Results added by the relay that received the email
+}
+
+// Parse reads a raw MIME email
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := raw.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, ErrNoSender
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	subject, err := decoder.DecodeHeader(raw.Header.Get("Subject"))
+	if err != nil {
+		subject = raw.Header.Get("Subject")
+	}
+
+	msg := &Message{
+		MessageID:   strings.Trim(strings.TrimSpace(raw.Header.Get("Message-Id")), "<>"),
+		From:        from[0],
+		Subject:     strings.TrimSpace(subject),
+		AuthResults: raw.Header.Get("Authentication-Results"),
+	}
+
+	for _, key := range []string{"Delivered-To", "X-Original-To", "To", "Cc"} {
+		for _, value := range raw.Header[textproto.CanonicalMIMEHeaderKey(key)] {
+			addresses, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+			for _, a := range addresses {
+				msg.Recipients = append(msg.Recipients, strings.ToLower(a.Address))
+			}
+		}
+	}
+
+	plain, html, err := readPart(textproto.MIMEHeader(raw.Header), raw.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	msg.Text = plain
+	if strings.TrimSpace(msg.Text) == "" && html != "" {
+		msg.Text = HTMLToText(html)
+	}
+
+	return msg, nil
+}
+
+// readPart returns the first plain text and HTML bodies in a part, looking through multipart
+// parts and skipping attachments
+func readPart(header textproto.MIMEHeader, body io.Reader, depth int) (plain, html string, err error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// Parts without a usable content type are plain ASCII text
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxDepth || params["boundary"] == "" {
+			return "", "", nil
+		}
+
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+
+			partPlain, partHTML, err := readPart(part.Header, part, depth+1)
+			if err != nil {
+				return "", "", err
+			}
+			if plain == "" {
+				plain = partPlain
+			}
+			if html == "" {
+				html = partHTML
+			}
+		}
+		return plain, html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	text, err := readText(header.Get("Content-Transfer-Encoding"), params["charset"], body)
+	if err != nil {
+		return "", "", err
+	}
+
+	if mediaType == "text/html" {
+		return "", text, nil
+	}
+	if strings.EqualFold(params["format"], "flowed") {
+		text = unflow(text, strings.EqualFold(params["delsp"], "yes"))
+	}
+	return text, "", nil
+}
+
+// readText decodes a text part's transfer encoding and charset into UTF-8
+func readText(transferEncoding, charset string, body io.Reader) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	if charset != "" {
+		if r, err := charsetReader(charset, body); err == nil {
+			body = r
+		}
+	}
+
+	b, err := io.ReadAll(io.LimitReader(body, maxTextSize))
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.ToValidUTF8(string(b), "�")
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
+
+// charsetReader decodes text in any charset a browser would understand
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// unflow joins the lines of a format=flowed body (RFC 3676) that were only wrapped for sending
+func unflow(text string, delsp bool) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		// A space stuffed in front of a line keeps it from being read as quoted or as a signature
+		line = strings.TrimPrefix(line, " ")
+
+		if strings.HasSuffix(line, " ") && line != "-- " {
+			if delsp {
+				line = strings.TrimSuffix(line, " ")
+			}
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Token returns the group token in a recipient address, either its local part or what follows
+// a "+" in it, e.g. abc123 in abc123@in.example.com or quotes+abc123@example.com. If domain is
+// set, addresses at other domains have no token.
+func Token(recipient, domain string) string {
+	at := strings.LastIndex(recipient, "@")
+	if at <= 0 {
+		return ""
+	}
+	if domain != "" && !strings.EqualFold(recipient[at+1:], domain) {
+		return ""
+	}
+
+	local := recipient[:at]
+	if plus := strings.LastIndex(local, "+"); plus >= 0 {
+		local = local[plus+1:]
+	}
+	return strings.ToLower(local)
+}
@@ -0,0 +1,141 @@
+// Package mail builds MIME messages and hands them to a transport for delivery
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text body and an optional HTML alternative
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // Extra headers, e.g. List-Unsubscribe
+}
+
+// Transport delivers messages
+type Transport interface {
+	Send(msg *Message) error
+}
+
+// SMTPTransport delivers messages through an SMTP server, upgrading to TLS when the server offers it
+type SMTPTransport struct {
+	Addr     string // host:port
+	Username string // Leave empty for servers that don't need auth
+	Password string
+}
+
+// NewSMTPTransport creates a transport for the SMTP server at addr
+func NewSMTPTransport(addr, username, password string) *SMTPTransport {
+	return &SMTPTransport{Addr: addr, Username: username, Password: password}
+}
+
+// Send delivers msg
+func (t *SMTPTransport) Send(msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.Username != "" {
+		host := t.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", t.Username, t.Password, host)
+	}
+
+	return smtp.SendMail(t.Addr, auth, from.Address, []string{to.Address}, body)
+}
+
+// Bytes renders the message as MIME, multipart/alternative when it has an HTML body
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := map[string]string{
+		"From":         m.From,
+		"To":           m.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+	}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+
+	boundary := ""
+	if m.HTML != "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		boundary = hex.EncodeToString(b)
+		headers["Content-Type"] = fmt.Sprintf(`multipart/alternative; boundary="%s"`, boundary)
+	} else {
+		headers["Content-Type"] = "text/plain; charset=utf-8"
+		headers["Content-Transfer-Encoding"] = "quoted-printable"
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, headers[k])
+	}
+	buf.WriteString("\r\n")
+
+	if boundary == "" {
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\nContent-Type: %s\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", boundary, part.contentType)
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable encodes body with CRLF line endings
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	w := quotedprintable.NewWriter(buf)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -32,13 +32,23 @@ func NewAuthMiddleware(cfg *config.Config, store models.Store) *AuthMiddleware {
 	}
 }
 
-// GenerateToken creates a new JWT token for a user
+// AccessTokenTTL returns how long the tokens from GenerateToken last
+func (m *AuthMiddleware) AccessTokenTTL() time.Duration {
+	return time.Duration(m.config.JWT.AccessTokenMinutes) * time.Minute
+}
+
+// RefreshTokenTTL returns how long a refresh token lasts before it has to be used
+func (m *AuthMiddleware) RefreshTokenTTL() time.Duration {
+	return time.Duration(m.config.JWT.RefreshTokenDays) * 24 * time.Hour
+}
+
+// GenerateToken creates a new short lived JWT access token for a user
 func (m *AuthMiddleware) GenerateToken(user *models.User) (string, error) {
 	claims := &Claims{
 		UserID: user.ID,
 		Email:  user.Email,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(m.config.JWT.ExpirationHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.AccessTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteActivityStore implements ActivityStore interface
+type SQLiteActivityStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteActivityStore creates a new SQLite activity store
+func NewSQLiteActivityStore(db *sql.DB) *SQLiteActivityStore {
+	return &SQLiteActivityStore{db: db}
+}
+
+// Append adds an entry to a group's activity log
+func (s *SQLiteActivityStore) Append(a *Activity) error {
+	a.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO activities (group_id, type, actor_id, quote_id, user_id, emoji, name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(query,
+		a.GroupID,
+		a.Type,
+		nullString(a.ActorID),
+		nullString(a.QuoteID),
+		nullString(a.UserID),
+		nullString(a.Emoji),
+		nullString(a.Name),
+		a.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to record activity")
+	}
+
+	a.ID, err = result.LastInsertId()
+	if err != nil {
+		return errors.DatabaseError("Failed to record activity")
+	}
+
+	return nil
+}
+
+// List retrieves a group's activity newest first, starting before beforeID, or from the newest if it is 0
+func (s *SQLiteActivityStore) List(groupID string, beforeID int64, limit int) ([]*Activity, error) {
+	if limit < 1 {
+		limit = 20
+	}
+
+	where := "WHERE group_id = ?"
+	args := []interface{}{groupID}
+	if beforeID > 0 {
+		where += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT id, group_id, type, actor_id, quote_id, user_id, emoji, name, created_at
+		FROM activities
+	` + where + `
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list activity")
+	}
+	defer rows.Close()
+
+	activities := []*Activity{}
+	for rows.Next() {
+		var a Activity
+		var actorID, quoteID, userID, emoji, name sql.NullString
+		err := rows.Scan(
+			&a.ID,
+			&a.GroupID,
+			&a.Type,
+			&actorID,
+			&quoteID,
+			&userID,
+			&emoji,
+			&name,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan activity data")
+		}
+
+		a.ActorID = actorID.String
+		a.QuoteID = quoteID.String
+		a.UserID = userID.String
+		a.Emoji = emoji.String
+		a.Name = name.String
+		activities = append(activities, &a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through activity")
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// linkCodeAlphabet leaves out characters that are easily mistaken for one another when typed
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// linkCodeLength is short enough to type, codes expire within minutes so guessing one is impractical
+const linkCodeLength = 8
+
+// SQLiteChatStore implements ChatStore interface
+type SQLiteChatStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteChatStore creates a new SQLite chat store
+func NewSQLiteChatStore(db *sql.DB) *SQLiteChatStore {
+	return &SQLiteChatStore{db: db}
+}
+
+// CreateLinkCode issues a code the user can send from a chat platform to link their account
+func (s *SQLiteChatStore) CreateLinkCode(userID string, ttl time.Duration) (*ChatLinkCode, error) {
+	b := make([]byte, linkCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.InternalError("Failed to generate link code")
+	}
+	for i := range b {
+		b[i] = linkCodeAlphabet[int(b[i])%len(linkCodeAlphabet)]
+	}
+
+	code := &ChatLinkCode{
+		Code:      string(b),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	// Expired codes are cleared out as new ones are made
+	if _, err := s.db.Exec(`DELETE FROM chat_link_codes WHERE expires_at <= ?`, time.Now().UTC()); err != nil {
+		return nil, errors.DatabaseError("Failed to create link code")
+	}
+
+	_, err := s.db.Exec(`INSERT INTO chat_link_codes (code, user_id, expires_at) VALUES (?, ?, ?)`,
+		code.Code, code.UserID, code.ExpiresAt.UTC())
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to create link code")
+	}
+
+	return code, nil
+}
+
+// RedeemLinkCode links a chat account to the user who issued the code, replacing any user it was
+// linked to before. Codes can only be used once.
+func (s *SQLiteChatStore) RedeemLinkCode(code string, account *ChatAccount) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	var userID string
+	err = tx.QueryRow(`SELECT user_id FROM chat_link_codes WHERE code = ? AND expires_at > ?`,
+		code, time.Now().UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("Link code not found or expired")
+	}
+	if err != nil {
+		return errors.DatabaseError("Failed to retrieve link code")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM chat_link_codes WHERE code = ?`, code); err != nil {
+		return errors.DatabaseError("Failed to redeem link code")
+	}
+
+	account.UserID = userID
+	account.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO chat_accounts (platform, team_id, external_id, user_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (platform, team_id, external_id) DO UPDATE SET
+			user_id = excluded.user_id,
+			created_at = excluded.created_at
+	`
+
+	_, err = tx.Exec(query,
+		account.Platform,
+		account.TeamID,
+		account.ExternalID,
+		account.UserID,
+		account.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to link account")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit account link")
+	}
+
+	return nil
+}
+
+// GetAccount retrieves the link for an account on a chat platform
+func (s *SQLiteChatStore) GetAccount(platform, teamID, externalID string) (*ChatAccount, error) {
+	query := `
+		SELECT platform, team_id, external_id, user_id, created_at
+		FROM chat_accounts
+		WHERE platform = ? AND team_id = ? AND external_id = ?
+	`
+
+	var a ChatAccount
+	err := s.db.QueryRow(query, platform, teamID, externalID).Scan(
+		&a.Platform,
+		&a.TeamID,
+		&a.ExternalID,
+		&a.UserID,
+		&a.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Account not linked")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to retrieve account")
+	}
+
+	return &a, nil
+}
+
+// ListAccounts retrieves every chat account linked to a user
+func (s *SQLiteChatStore) ListAccounts(userID string) ([]*ChatAccount, error) {
+	query := `
+		SELECT platform, team_id, external_id, user_id, created_at
+		FROM chat_accounts
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list accounts")
+	}
+	defer rows.Close()
+
+	accounts := []*ChatAccount{}
+	for rows.Next() {
+		var a ChatAccount
+		err := rows.Scan(
+			&a.Platform,
+			&a.TeamID,
+			&a.ExternalID,
+			&a.UserID,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan account data")
+		}
+		accounts = append(accounts, &a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through accounts")
+	}
+
+	return accounts, nil
+}
+
+// DeleteAccounts unlinks every account a user has on a chat platform
+func (s *SQLiteChatStore) DeleteAccounts(userID, platform string) error {
+	result, err := s.db.Exec(`DELETE FROM chat_accounts WHERE user_id = ? AND platform = ?`, userID, platform)
+	if err != nil {
+		return errors.DatabaseError("Failed to unlink accounts")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("No linked accounts")
+	}
+
+	return nil
+}
+
+// SetChannel connects a channel to a group, replacing the group it was connected to before
+func (s *SQLiteChatStore) SetChannel(channel *ChatChannel) error {
+	channel.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO chat_channels (platform, team_id, channel_id, group_id, linked_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (platform, team_id, channel_id) DO UPDATE SET
+			group_id = excluded.group_id,
+			linked_by = excluded.linked_by,
+			created_at = excluded.created_at
+	`
+
+	_, err := s.db.Exec(query,
+		channel.Platform,
+		channel.TeamID,
+		channel.ChannelID,
+		channel.GroupID,
+		channel.LinkedBy,
+		channel.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to connect channel")
+	}
+
+	return nil
+}
+
+// GetChannel retrieves the group a chat channel is connected to
+func (s *SQLiteChatStore) GetChannel(platform, teamID, channelID string) (*ChatChannel, error) {
+	query := `
+		SELECT platform, team_id, channel_id, group_id, linked_by, created_at
+		FROM chat_channels
+		WHERE platform = ? AND team_id = ? AND channel_id = ?
+	`
+
+	var c ChatChannel
+	err := s.db.QueryRow(query, platform, teamID, channelID).Scan(
+		&c.Platform,
+		&c.TeamID,
+		&c.ChannelID,
+		&c.GroupID,
+		&c.LinkedBy,
+		&c.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Channel not connected")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to retrieve channel")
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"database/sql"
+	"hash/fnv"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteDailyQuoteStore implements DailyQuoteStore interface
+type SQLiteDailyQuoteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteDailyQuoteStore creates a new SQLite daily quote store
+func NewSQLiteDailyQuoteStore(db *sql.DB) *SQLiteDailyQuoteStore {
+	return &SQLiteDailyQuoteStore{db: db}
+}
+
+const dailyQuoteSelect = `
+	SELECT dq.group_id, dq.day, dq.quote_id, dq.cycle, dq.created_at,
+		q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at
+	FROM daily_quotes dq
+	JOIN quotes q ON q.id = dq.quote_id
+`
+
+// GetOrPick returns the quote of the day for a group, picking one if none has been recorded yet.
+// The pick is seeded by the group and day so every member sees the same quote, and quotes are
+// not repeated until every quote in the group has been shown in the current cycle.
+func (s *SQLiteDailyQuoteStore) GetOrPick(groupID, day string) (*DailyQuote, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	daily, err := scanDailyQuote(tx.QueryRow(dailyQuoteSelect+"WHERE dq.group_id = ? AND dq.day = ?", groupID, day))
+	if err == nil {
+		return daily, nil
+	}
+	if !errors.IsCode(err, errors.CodeNotFound) {
+		return nil, err
+	}
+
+	// The cascade from quotes can't be relied on, foreign keys are only enabled on one of the
+	// pool's connections. Drop a pick whose quote has been deleted so another is chosen.
+	_, err = tx.Exec(`
+		DELETE FROM daily_quotes
+		WHERE group_id = ? AND day = ? AND quote_id NOT IN (SELECT id FROM quotes)
+	`, groupID, day)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to clear daily quote")
+	}
+
+	var cycle int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(cycle), 1) FROM daily_quotes WHERE group_id = ?`, groupID).Scan(&cycle)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get daily quote cycle")
+	}
+
+	candidates, err := dailyCandidates(tx, groupID, cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every quote has been shown in this cycle, so start a new one
+	if len(candidates) == 0 {
+		cycle++
+		candidates, err = dailyCandidates(tx, groupID, cycle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.NotFound("No quotes found")
+	}
+
+	quoteID := candidates[dailySeed(groupID, day)%uint64(len(candidates))]
+
+	query := `
+		INSERT OR IGNORE INTO daily_quotes (group_id, day, quote_id, cycle, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	if _, err := tx.Exec(query, groupID, day, quoteID, cycle, time.Now()); err != nil {
+		return nil, errors.DatabaseError("Failed to record daily quote")
+	}
+
+	daily, err = scanDailyQuote(tx.QueryRow(dailyQuoteSelect+"WHERE dq.group_id = ? AND dq.day = ?", groupID, day))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.DatabaseError("Failed to commit daily quote")
+	}
+
+	return daily, nil
+}
+
+// ListHistory retrieves past daily quotes for a group, most recent first
+func (s *SQLiteDailyQuoteStore) ListHistory(groupID string, page, limit int) ([]*DailyQuote, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	rows, err := s.db.Query(dailyQuoteSelect+`
+		WHERE dq.group_id = ?
+		ORDER BY dq.day DESC
+		LIMIT ? OFFSET ?
+	`, groupID, limit, offset)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list daily quotes")
+	}
+	defer rows.Close()
+
+	var history []*DailyQuote
+	for rows.Next() {
+		daily, err := scanDailyQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, daily)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through daily quotes")
+	}
+
+	return history, nil
+}
+
+// dailyCandidates returns the IDs of group quotes not yet shown in the given cycle, in a stable order.
+// Sealed time capsules wait until they unlock.
+func dailyCandidates(tx *sql.Tx, groupID string, cycle int) ([]string, error) {
+	query := `
+		SELECT id
+		FROM quotes
+		WHERE group_id = ?
+		AND (unlock_at IS NULL OR unlock_at <= ?)
+		AND id NOT IN (SELECT quote_id FROM daily_quotes WHERE group_id = ? AND cycle = ?)
+		ORDER BY id
+	`
+
+	rows, err := tx.Query(query, groupID, time.Now().UTC(), groupID, cycle)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get daily quote candidates")
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.DatabaseError("Failed to scan quote id")
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through quotes")
+	}
+
+	return ids, nil
+}
+
+// dailySeed derives a stable seed from the group and day
+func dailySeed(groupID, day string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(groupID))
+	h.Write([]byte{0})
+	h.Write([]byte(day))
+	return h.Sum64()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDailyQuote(row rowScanner) (*DailyQuote, error) {
+	var daily DailyQuote
+	var quote Quote
+	err := row.Scan(
+		&daily.GroupID,
+		&daily.Day,
+		&daily.QuoteID,
+		&daily.Cycle,
+		&daily.CreatedAt,
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Daily quote not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to scan daily quote data")
+	}
+
+	daily.Quote = &quote
+	return &daily, nil
+}
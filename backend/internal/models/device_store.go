@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteDeviceStore implements DeviceStore interface
+type SQLiteDeviceStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteDeviceStore creates a new SQLite device store
+func NewSQLiteDeviceStore(db *sql.DB) *SQLiteDeviceStore {
+	return &SQLiteDeviceStore{db: db}
+}
+
+// Register stores a device's push token, replacing the device's old token and taking the
+// token away from any other device that had it
+func (s *SQLiteDeviceStore) Register(device *Device) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(`DELETE FROM devices WHERE token = ? AND NOT (user_id = ? AND device_id = ?)`,
+		device.Token, device.UserID, device.DeviceID)
+	if err != nil {
+		return errors.DatabaseError("Failed to register device")
+	}
+
+	query := `
+		INSERT INTO devices (user_id, device_id, platform, token, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, device_id) DO UPDATE SET
+			platform = excluded.platform,
+			token = excluded.token,
+			updated_at = excluded.updated_at
+	`
+
+	_, err = tx.Exec(query,
+		device.UserID,
+		device.DeviceID,
+		device.Platform,
+		device.Token,
+		now,
+		now,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to register device")
+	}
+
+	err = tx.QueryRow(`SELECT created_at FROM devices WHERE user_id = ? AND device_id = ?`,
+		device.UserID, device.DeviceID).Scan(&device.CreatedAt)
+	if err != nil {
+		return errors.DatabaseError("Failed to register device")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit device registration")
+	}
+
+	device.UpdatedAt = now
+	return nil
+}
+
+// ListByUser retrieves every device a user has registered
+func (s *SQLiteDeviceStore) ListByUser(userID string) ([]*Device, error) {
+	query := `
+		SELECT user_id, device_id, platform, token, created_at, updated_at
+		FROM devices
+		WHERE user_id = ?
+		ORDER BY updated_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list devices")
+	}
+	defer rows.Close()
+
+	devices := []*Device{}
+	for rows.Next() {
+		var d Device
+		err := rows.Scan(
+			&d.UserID,
+			&d.DeviceID,
+			&d.Platform,
+			&d.Token,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan device data")
+		}
+		devices = append(devices, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through devices")
+	}
+
+	return devices, nil
+}
+
+// Delete unregisters one of a user's devices
+func (s *SQLiteDeviceStore) Delete(userID, deviceID string) error {
+	result, err := s.db.Exec(`DELETE FROM devices WHERE user_id = ? AND device_id = ?`, userID, deviceID)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete device")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Device not found")
+	}
+
+	return nil
+}
+
+// DeleteToken forgets a push token the push service no longer accepts
+func (s *SQLiteDeviceStore) DeleteToken(token string) error {
+	if _, err := s.db.Exec(`DELETE FROM devices WHERE token = ?`, token); err != nil {
+		return errors.DatabaseError("Failed to delete device token")
+	}
+
+	return nil
+}
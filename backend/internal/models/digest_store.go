@@ -0,0 +1,263 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteDigestStore implements DigestStore interface
+type SQLiteDigestStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteDigestStore creates a new SQLite digest store
+func NewSQLiteDigestStore(db *sql.DB) *SQLiteDigestStore {
+	return &SQLiteDigestStore{db: db}
+}
+
+// Recipients retrieves subscribed users who haven't had a digest since sentBefore
+func (s *SQLiteDigestStore) Recipients(sentBefore time.Time) ([]*DigestRecipient, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.authenticated, u.created_at, d.last_sent_at
+		FROM users u
+		LEFT JOIN digest_subscriptions d ON d.user_id = u.id
+		WHERE u.authenticated = 1
+			AND d.unsubscribed_at IS NULL
+			AND (d.last_sent_at IS NULL OR d.last_sent_at <= ?)
+		ORDER BY u.id
+	`
+
+	rows, err := s.db.Query(query, sentBefore.UTC())
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list digest recipients")
+	}
+	defer rows.Close()
+
+	recipients := []*DigestRecipient{}
+	for rows.Next() {
+		var user User
+		var lastSentAt sql.NullTime
+		err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Username,
+			&user.Authenticated,
+			&user.CreatedAt,
+			&lastSentAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan digest recipient")
+		}
+
+		recipient := &DigestRecipient{User: &user}
+		if lastSentAt.Valid {
+			recipient.LastSentAt = &lastSentAt.Time
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through digest recipients")
+	}
+
+	return recipients, nil
+}
+
+// digestQuoteSelect selects quotes with their uploader's name and how many reactions they got since a time
+const digestQuoteSelect = `
+	SELECT ` + quoteColumns + `,
+		COALESCE((SELECT username FROM users WHERE users.id = quotes.uploader_id), 'Unknown'),
+		(SELECT COUNT(*) FROM quote_reactions r WHERE r.quote_id = quotes.id AND r.created_at >= ?) AS reactions
+	FROM quotes
+`
+
+// GroupDigest collects a group's new quotes, its most reacted quote and a throwback for the
+// period from since to now. Sealed time capsules are left out. now's location decides which
+// day counts as "this day".
+func (s *SQLiteDigestStore) GroupDigest(groupID string, since, now time.Time, limit int) (*GroupDigest, error) {
+	digest := &GroupDigest{GroupID: groupID}
+	sinceUTC, nowUTC := since.UTC(), now.UTC()
+
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM quotes
+		WHERE group_id = ? AND created_at >= ? AND created_at < ? AND (unlock_at IS NULL OR unlock_at <= ?)
+	`, groupID, sinceUTC, nowUTC, nowUTC).Scan(&digest.NewCount)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to count new quotes")
+	}
+
+	if digest.NewCount > 0 {
+		digest.NewQuotes, err = s.digestQuotes(digestQuoteSelect+`
+			WHERE group_id = ? AND created_at >= ? AND created_at < ? AND (unlock_at IS NULL OR unlock_at <= ?)
+			ORDER BY created_at ASC
+			LIMIT ?
+		`, sinceUTC, groupID, sinceUTC, nowUTC, nowUTC, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mostReacted, err := s.digestQuotes(digestQuoteSelect+`
+		WHERE group_id = ? AND (unlock_at IS NULL OR unlock_at <= ?) AND reactions > 0
+		ORDER BY reactions DESC, created_at ASC
+		LIMIT 1
+	`, sinceUTC, groupID, nowUTC)
+	if err != nil {
+		return nil, err
+	}
+	if len(mostReacted) > 0 {
+		digest.MostReacted = mostReacted[0]
+	}
+
+	onThisDay, err := s.digestQuotes(digestQuoteSelect+`
+		WHERE group_id = ? AND (unlock_at IS NULL OR unlock_at <= ?)
+			AND strftime('%m-%d', created_at) = ? AND strftime('%Y', created_at) < ?
+		ORDER BY created_at ASC
+		LIMIT 1
+	`, sinceUTC, groupID, nowUTC, now.Format("01-02"), now.Format("2006"))
+	if err != nil {
+		return nil, err
+	}
+	if len(onThisDay) > 0 {
+		digest.OnThisDay = onThisDay[0]
+	}
+
+	return digest, nil
+}
+
+// digestQuotes runs a query built on digestQuoteSelect
+func (s *SQLiteDigestStore) digestQuotes(query string, args ...interface{}) ([]*DigestQuote, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get digest quotes")
+	}
+	defer rows.Close()
+
+	quotes := []*DigestQuote{}
+	for rows.Next() {
+		var dq DigestQuote
+		dq.Quote, err = scanQuote(scanWith{row: rows, extra: []interface{}{&dq.Uploader, &dq.Reactions}})
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan digest quote")
+		}
+		quotes = append(quotes, &dq)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through digest quotes")
+	}
+
+	return quotes, nil
+}
+
+// UnsubscribeToken returns the token for a user's one-click unsubscribe link, minting it the first time
+func (s *SQLiteDigestStore) UnsubscribeToken(userID string) (string, error) {
+	if err := s.ensure(userID); err != nil {
+		return "", err
+	}
+
+	var token string
+	err := s.db.QueryRow(`SELECT token FROM digest_subscriptions WHERE user_id = ?`, userID).Scan(&token)
+	if err != nil {
+		return "", errors.DatabaseError("Failed to get unsubscribe token")
+	}
+
+	return token, nil
+}
+
+// Unsubscribe stops the digest for whoever the token belongs to
+func (s *SQLiteDigestStore) Unsubscribe(token string) error {
+	result, err := s.db.Exec(`
+		UPDATE digest_subscriptions
+		SET unsubscribed_at = COALESCE(unsubscribed_at, ?)
+		WHERE token = ?
+	`, time.Now().UTC(), token)
+	if err != nil {
+		return errors.DatabaseError("Failed to unsubscribe")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check unsubscribe result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Unsubscribe link not found")
+	}
+
+	return nil
+}
+
+// SetSubscribed turns a user's digest on or off
+func (s *SQLiteDigestStore) SetSubscribed(userID string, subscribed bool) error {
+	if err := s.ensure(userID); err != nil {
+		return err
+	}
+
+	var unsubscribedAt interface{}
+	if !subscribed {
+		unsubscribedAt = time.Now().UTC()
+	}
+
+	_, err := s.db.Exec(`UPDATE digest_subscriptions SET unsubscribed_at = ? WHERE user_id = ?`, unsubscribedAt, userID)
+	if err != nil {
+		return errors.DatabaseError("Failed to update digest subscription")
+	}
+
+	return nil
+}
+
+// Subscribed reports whether a user gets the digest
+func (s *SQLiteDigestStore) Subscribed(userID string) (bool, error) {
+	var unsubscribedAt sql.NullTime
+	err := s.db.QueryRow(`SELECT unsubscribed_at FROM digest_subscriptions WHERE user_id = ?`, userID).Scan(&unsubscribedAt)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.DatabaseError("Failed to get digest subscription")
+	}
+
+	return !unsubscribedAt.Valid, nil
+}
+
+// MarkSent records when a user's digest was last handled
+func (s *SQLiteDigestStore) MarkSent(userID string, at time.Time) error {
+	if err := s.ensure(userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`UPDATE digest_subscriptions SET last_sent_at = ? WHERE user_id = ?`, at.UTC(), userID)
+	if err != nil {
+		return errors.DatabaseError("Failed to record digest")
+	}
+
+	return nil
+}
+
+// ensure creates a user's subscription row if they don't have one yet
+func (s *SQLiteDigestStore) ensure(userID string) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`INSERT OR IGNORE INTO digest_subscriptions (user_id, token) VALUES (?, ?)`, userID, token)
+	if err != nil {
+		return errors.DatabaseError("Failed to create digest subscription")
+	}
+
+	return nil
+}
+
+// scanWith reads extra columns selected after the ones a scan function knows about
+type scanWith struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s scanWith) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteFeedStore implements FeedStore interface
+type SQLiteFeedStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteFeedStore creates a new SQLite feed token store
+func NewSQLiteFeedStore(db *sql.DB) *SQLiteFeedStore {
+	return &SQLiteFeedStore{db: db}
+}
+
+// Create mints a new feed token
+func (s *SQLiteFeedStore) Create(feed *FeedToken) error {
+	if feed.ID == "" {
+		feed.ID = uuid.New().String()
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	feed.Token = token
+	feed.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO feed_tokens (id, token, group_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		feed.ID,
+		feed.Token,
+		feed.GroupID,
+		feed.CreatedBy,
+		feed.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.DatabaseError("Failed to create feed")
+	}
+
+	return nil
+}
+
+// GetByToken retrieves a feed by its token
+func (s *SQLiteFeedStore) GetByToken(token string) (*FeedToken, error) {
+	query := `
+		SELECT id, token, group_id, created_by, created_at
+		FROM feed_tokens
+		WHERE token = ?
+	`
+
+	feed, err := scanFeedToken(s.db.QueryRow(query, token))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Feed not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get feed")
+	}
+
+	return feed, nil
+}
+
+// ListByCreator retrieves the feeds a user made, newest first
+func (s *SQLiteFeedStore) ListByCreator(userID string) ([]*FeedToken, error) {
+	query := `
+		SELECT id, token, group_id, created_by, created_at
+		FROM feed_tokens
+		WHERE created_by = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list feeds")
+	}
+	defer rows.Close()
+
+	feeds := []*FeedToken{}
+	for rows.Next() {
+		feed, err := scanFeedToken(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan feed data")
+		}
+		feeds = append(feeds, feed)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through feeds")
+	}
+
+	return feeds, nil
+}
+
+// Delete revokes a feed if it belongs to the given user
+func (s *SQLiteFeedStore) Delete(id, createdBy string) error {
+	query := `DELETE FROM feed_tokens WHERE id = ? AND created_by = ?`
+
+	result, err := s.db.Exec(query, id, createdBy)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete feed")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Feed not found")
+	}
+
+	return nil
+}
+
+// scanFeedToken reads a feed token row
+func scanFeedToken(row rowScanner) (*FeedToken, error) {
+	var feed FeedToken
+	err := row.Scan(
+		&feed.ID,
+		&feed.Token,
+		&feed.GroupID,
+		&feed.CreatedBy,
+		&feed.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
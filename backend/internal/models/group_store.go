@@ -24,13 +24,17 @@ func (s *SQLiteGroupStore) Create(group *Group) error {
 		group.ID = uuid.New().String()
 	}
 
+	if group.Timezone == "" {
+		group.Timezone = "UTC"
+	}
+
 	now := time.Now()
 	group.CreatedAt = now
 	group.UpdatedAt = now
 
 	query := `
-		INSERT INTO groups (id, group_id, name, member_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO groups (id, group_id, name, member_id, timezone, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := s.db.Exec(query,
@@ -38,6 +42,7 @@ func (s *SQLiteGroupStore) Create(group *Group) error {
 		group.GroupID,
 		group.Name,
 		group.MemberID,
+		group.Timezone,
 		group.CreatedAt,
 		group.UpdatedAt,
 	)
@@ -53,7 +58,7 @@ func (s *SQLiteGroupStore) Create(group *Group) error {
 func (s *SQLiteGroupStore) GetByID(id string) (*Group, error) {
 	var group Group
 	query := `
-		SELECT id, group_id, name, member_id, created_at, updated_at
+		SELECT id, group_id, name, member_id, timezone, created_at, updated_at
 		FROM groups
 		WHERE id = ?
 	`
@@ -63,6 +68,7 @@ func (s *SQLiteGroupStore) GetByID(id string) (*Group, error) {
 		&group.GroupID,
 		&group.Name,
 		&group.MemberID,
+		&group.Timezone,
 		&group.CreatedAt,
 		&group.UpdatedAt,
 	)
@@ -80,7 +86,7 @@ func (s *SQLiteGroupStore) GetByID(id string) (*Group, error) {
 // GetByGroupID retrieves all groups with the given group_id
 func (s *SQLiteGroupStore) GetByGroupID(groupID string) ([]*Group, error) {
 	query := `
-		SELECT id, group_id, name, member_id, created_at, updated_at
+		SELECT id, group_id, name, member_id, timezone, created_at, updated_at
 		FROM groups
 		WHERE group_id = ?
 		ORDER BY created_at DESC
@@ -100,6 +106,7 @@ func (s *SQLiteGroupStore) GetByGroupID(groupID string) ([]*Group, error) {
 			&group.GroupID,
 			&group.Name,
 			&group.MemberID,
+			&group.Timezone,
 			&group.CreatedAt,
 			&group.UpdatedAt,
 		)
@@ -123,7 +130,7 @@ func (s *SQLiteGroupStore) GetByGroupID(groupID string) ([]*Group, error) {
 // GetByMemberID retrieves all groups for a specific member
 func (s *SQLiteGroupStore) GetByMemberID(memberID string) ([]*Group, error) {
 	query := `
-		SELECT id, group_id, name, member_id, created_at, updated_at
+		SELECT id, group_id, name, member_id, timezone, created_at, updated_at
 		FROM groups
 		WHERE member_id = ?
 		ORDER BY created_at DESC
@@ -143,6 +150,7 @@ func (s *SQLiteGroupStore) GetByMemberID(memberID string) ([]*Group, error) {
 			&group.GroupID,
 			&group.Name,
 			&group.MemberID,
+			&group.Timezone,
 			&group.CreatedAt,
 			&group.UpdatedAt,
 		)
@@ -169,12 +177,13 @@ func (s *SQLiteGroupStore) Update(group *Group) error {
 
 	query := `
 		UPDATE groups
-		SET name = ?, updated_at = ?
+		SET name = ?, timezone = ?, updated_at = ?
 		WHERE id = ?
 	`
 
 	result, err := s.db.Exec(query,
 		group.Name,
+		group.Timezone,
 		group.UpdatedAt,
 		group.ID,
 	)
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// addressEncoding spells tokens in lowercase letters and digits, which survive mail servers
+// that change the case of addresses
+var addressEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// SQLiteInboundStore implements InboundStore interface
+type SQLiteInboundStore struct {
+	db     *sql.DB
+	quotes *SQLiteQuoteStore // Told about the quotes emails are turned into
+}
+
+// NewSQLiteInboundStore creates a new SQLite inbound store
+func NewSQLiteInboundStore(db *sql.DB, quotes *SQLiteQuoteStore) *SQLiteInboundStore {
+	return &SQLiteInboundStore{db: db, quotes: quotes}
+}
+
+// AddressToken returns the token in a group's inbound address, creating one the first time it is asked for
+func (s *SQLiteInboundStore) AddressToken(groupID string) (string, error) {
+	var token string
+	err := s.db.QueryRow(`SELECT token FROM inbound_addresses WHERE group_id = ?`, groupID).Scan(&token)
+	if err == nil {
+		return token, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", errors.DatabaseError("Failed to retrieve inbound address")
+	}
+
+	return s.RotateAddressToken(groupID)
+}
+
+// RotateAddressToken gives a group a new inbound address token, the old address stops working
+func (s *SQLiteInboundStore) RotateAddressToken(groupID string) (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.InternalError("Failed to generate inbound address")
+	}
+	token := addressEncoding.EncodeToString(b)
+
+	query := `
+		INSERT INTO inbound_addresses (group_id, token, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (group_id) DO UPDATE SET
+			token = excluded.token,
+			created_at = excluded.created_at
+	`
+
+	if _, err := s.db.Exec(query, groupID, token, time.Now()); err != nil {
+		return "", errors.DatabaseError("Failed to save inbound address")
+	}
+
+	return token, nil
+}
+
+// GroupForToken retrieves the group an inbound address token belongs to
+func (s *SQLiteInboundStore) GroupForToken(token string) (string, error) {
+	var groupID string
+	err := s.db.QueryRow(`SELECT group_id FROM inbound_addresses WHERE token = ?`,
+		strings.ToLower(token)).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return "", errors.NotFound("Inbound address not found")
+	}
+	if err != nil {
+		return "", errors.DatabaseError("Failed to retrieve inbound address")
+	}
+
+	return groupID, nil
+}
+
+// CreateQuote adds the quote an email to a group was turned into. The email's Message-ID is
+// claimed first, in the same transaction, so retries of a delivery arriving together or after a
+// failure add the quote once. Later ones get an already exists error along with the ID of the
+// quote the first one added.
+func (s *SQLiteInboundStore) CreateQuote(messageID string, quote *Quote) (string, error) {
+	if quote.ID == "" {
+		quote.ID = uuid.New().String()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO inbound_messages (group_id, message_id, quote_id, created_at) VALUES (?, ?, ?, ?)`,
+		quote.GroupID, messageID, quote.ID, time.Now())
+	if err != nil {
+		return "", errors.DatabaseError("Failed to record inbound message")
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return "", errors.DatabaseError("Failed to record inbound message")
+	}
+	if claimed == 0 {
+		var quoteID string
+		err := tx.QueryRow(`SELECT quote_id FROM inbound_messages WHERE group_id = ? AND message_id = ?`,
+			quote.GroupID, messageID).Scan(&quoteID)
+		if err != nil {
+			return "", errors.DatabaseError("Failed to retrieve inbound message")
+		}
+		return quoteID, errors.AlreadyExists("Message already turned into a quote")
+	}
+
+	if err := insertQuote(tx, quote); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", errors.DatabaseError("Failed to commit inbound quote")
+	}
+
+	s.quotes.notifyChange(quote.GroupID)
+
+	return quote.ID, nil
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteNotificationStore implements NotificationStore interface
+type SQLiteNotificationStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteNotificationStore creates a new SQLite notification store
+func NewSQLiteNotificationStore(db *sql.DB) *SQLiteNotificationStore {
+	return &SQLiteNotificationStore{db: db}
+}
+
+// Create adds a notification to a user's inbox
+func (s *SQLiteNotificationStore) Create(n *Notification) error {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	n.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO notifications (id, user_id, type, group_id, actor_id, quote_id, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		n.ID,
+		n.UserID,
+		n.Type,
+		n.GroupID,
+		nullString(n.ActorID),
+		nullString(n.QuoteID),
+		n.Message,
+		n.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create notification")
+	}
+
+	return nil
+}
+
+// List retrieves a user's notifications, newest first
+func (s *SQLiteNotificationStore) List(userID string, unreadOnly bool, page, limit int) ([]*Notification, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	where := "WHERE user_id = ?"
+	if unreadOnly {
+		where += " AND read_at IS NULL"
+	}
+
+	query := `
+		SELECT id, user_id, type, group_id, actor_id, quote_id, message, read_at, created_at
+		FROM notifications
+	` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.Query(query, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list notifications")
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan notification data")
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through notifications")
+	}
+
+	return notifications, nil
+}
+
+// UnreadCount counts a user's unread notifications
+func (s *SQLiteNotificationStore) UnreadCount(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, errors.DatabaseError("Failed to count unread notifications")
+	}
+
+	return count, nil
+}
+
+// MarkRead marks some of a user's notifications as read and returns how many changed
+func (s *SQLiteNotificationStore) MarkRead(userID string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{time.Now(), userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `
+		UPDATE notifications
+		SET read_at = ?
+		WHERE user_id = ? AND read_at IS NULL AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)
+	`
+
+	return s.execCount(query, args...)
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many changed
+func (s *SQLiteNotificationStore) MarkAllRead(userID string) (int, error) {
+	return s.execCount(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`, time.Now(), userID)
+}
+
+// execCount runs an update and returns the number of rows it changed
+func (s *SQLiteNotificationStore) execCount(query string, args ...interface{}) (int, error) {
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, errors.DatabaseError("Failed to mark notifications read")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.DatabaseError("Failed to check update result")
+	}
+
+	return int(rows), nil
+}
+
+// GetPreferences retrieves what a member wants to hear about from a group, everything if they never chose
+func (s *SQLiteNotificationStore) GetPreferences(userID, groupID string) (*NotificationPreferences, error) {
+	query := `
+		SELECT user_id, group_id, quotes, mentions, reactions, capsules, updated_at
+		FROM notification_preferences
+		WHERE user_id = ? AND group_id = ?
+	`
+
+	var prefs NotificationPreferences
+	err := s.db.QueryRow(query, userID, groupID).Scan(
+		&prefs.UserID,
+		&prefs.GroupID,
+		&prefs.Quotes,
+		&prefs.Mentions,
+		&prefs.Reactions,
+		&prefs.Capsules,
+		&prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return DefaultNotificationPreferences(userID, groupID), nil
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get notification preferences")
+	}
+
+	return &prefs, nil
+}
+
+// SavePreferences stores a member's notification preferences for a group
+func (s *SQLiteNotificationStore) SavePreferences(prefs *NotificationPreferences) error {
+	prefs.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO notification_preferences (user_id, group_id, quotes, mentions, reactions, capsules, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, group_id) DO UPDATE SET
+			quotes = excluded.quotes,
+			mentions = excluded.mentions,
+			reactions = excluded.reactions,
+			capsules = excluded.capsules,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(query,
+		prefs.UserID,
+		prefs.GroupID,
+		prefs.Quotes,
+		prefs.Mentions,
+		prefs.Reactions,
+		prefs.Capsules,
+		prefs.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to save notification preferences")
+	}
+
+	return nil
+}
+
+// scanNotification reads a notification row
+func scanNotification(row rowScanner) (*Notification, error) {
+	var n Notification
+	var actorID, quoteID sql.NullString
+	var readAt sql.NullTime
+	err := row.Scan(
+		&n.ID,
+		&n.UserID,
+		&n.Type,
+		&n.GroupID,
+		&actorID,
+		&quoteID,
+		&n.Message,
+		&readAt,
+		&n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	n.ActorID = actorID.String
+	n.QuoteID = quoteID.String
+	if readAt.Valid {
+		n.ReadAt = &readAt.Time
+	}
+
+	return &n, nil
+}
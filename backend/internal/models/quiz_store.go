@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// Quiz scoring: a correct answer is worth quizPoints, plus quizStreakBonus for every
+// earlier correct answer in the current streak, up to quizMaxBonus
+const (
+	quizPoints      = 10
+	quizStreakBonus = 2
+	quizMaxBonus    = 10
+)
+
+// SQLiteQuizStore implements QuizStore interface
+type SQLiteQuizStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteQuizStore creates a new SQLite quiz store
+func NewSQLiteQuizStore(db *sql.DB) *SQLiteQuizStore {
+	return &SQLiteQuizStore{db: db}
+}
+
+const quizRoundSelect = `
+	SELECT r.id, r.group_id, r.user_id, r.quote_id, r.choices, r.answer, r.chosen, r.correct, r.points,
+		r.created_at, r.answered_at,
+		q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at, q.unlock_at
+	FROM quiz_rounds r
+	JOIN quotes q ON q.id = r.quote_id
+`
+
+// CreateRound records a new round for a player
+func (s *SQLiteQuizStore) CreateRound(round *QuizRound) error {
+	if round.ID == "" {
+		round.ID = uuid.New().String()
+	}
+	round.CreatedAt = time.Now()
+
+	choices, err := json.Marshal(round.Choices)
+	if err != nil {
+		return errors.InternalError("Failed to encode quiz choices")
+	}
+
+	query := `
+		INSERT INTO quiz_rounds (id, group_id, user_id, quote_id, choices, answer, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		round.ID,
+		round.GroupID,
+		round.UserID,
+		round.QuoteID,
+		string(choices),
+		round.Answer,
+		round.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create quiz round")
+	}
+
+	return nil
+}
+
+// GetRound retrieves a round and its quote by ID
+func (s *SQLiteQuizStore) GetRound(id string) (*QuizRound, error) {
+	round, err := scanQuizRound(s.db.QueryRow(quizRoundSelect+"WHERE r.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Quiz round not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quiz round")
+	}
+
+	return round, nil
+}
+
+// GetOpenRound retrieves the round a player has yet to answer in a group
+func (s *SQLiteQuizStore) GetOpenRound(groupID, userID string) (*QuizRound, error) {
+	query := quizRoundSelect + `
+		WHERE r.group_id = ? AND r.user_id = ? AND r.answered_at IS NULL
+		ORDER BY r.created_at DESC
+		LIMIT 1
+	`
+
+	round, err := scanQuizRound(s.db.QueryRow(query, groupID, userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("No open quiz round")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quiz round")
+	}
+
+	return round, nil
+}
+
+// AnswerRound records the player's choice, scores it and updates their totals in one transaction.
+// A round can only be answered once.
+func (s *SQLiteQuizStore) AnswerRound(round *QuizRound, chosen string) (*QuizScore, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	score, err := scanQuizScore(tx.QueryRow(quizScoreSelect+"WHERE s.group_id = ? AND s.user_id = ?", round.GroupID, round.UserID))
+	if err == sql.ErrNoRows {
+		score = &QuizScore{GroupID: round.GroupID, UserID: round.UserID}
+	} else if err != nil {
+		return nil, errors.DatabaseError("Failed to get quiz score")
+	}
+
+	now := time.Now()
+	correct := chosen == round.Answer
+	points := 0
+	if correct {
+		bonus := score.Streak * quizStreakBonus
+		if bonus > quizMaxBonus {
+			bonus = quizMaxBonus
+		}
+		points = quizPoints + bonus
+		score.Streak++
+		score.Correct++
+		if score.Streak > score.BestStreak {
+			score.BestStreak = score.Streak
+		}
+	} else {
+		score.Streak = 0
+	}
+	score.Points += points
+	score.Answered++
+	score.UpdatedAt = now
+
+	result, err := tx.Exec(`
+		UPDATE quiz_rounds
+		SET chosen = ?, correct = ?, points = ?, answered_at = ?
+		WHERE id = ? AND answered_at IS NULL
+	`, chosen, correct, points, now, round.ID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to answer quiz round")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to check answer result")
+	}
+	if rows == 0 {
+		return nil, errors.InvalidInput("Quiz round has already been answered")
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO quiz_scores (group_id, user_id, points, answered, correct, streak, best_streak, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (group_id, user_id) DO UPDATE SET
+			points = excluded.points,
+			answered = excluded.answered,
+			correct = excluded.correct,
+			streak = excluded.streak,
+			best_streak = excluded.best_streak,
+			updated_at = excluded.updated_at
+	`,
+		score.GroupID,
+		score.UserID,
+		score.Points,
+		score.Answered,
+		score.Correct,
+		score.Streak,
+		score.BestStreak,
+		score.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to update quiz score")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.DatabaseError("Failed to commit quiz answer")
+	}
+
+	round.Chosen = chosen
+	round.Correct = correct
+	round.Points = points
+	round.AnsweredAt = &now
+
+	return score, nil
+}
+
+const quizScoreSelect = `
+	SELECT s.group_id, s.user_id, COALESCE(u.username, 'Unknown'), s.points, s.answered, s.correct,
+		s.streak, s.best_streak, s.updated_at
+	FROM quiz_scores s
+	LEFT JOIN users u ON u.id = s.user_id
+`
+
+// GetScore retrieves a player's totals in a group, all zero if they haven't played
+func (s *SQLiteQuizStore) GetScore(groupID, userID string) (*QuizScore, error) {
+	score, err := scanQuizScore(s.db.QueryRow(quizScoreSelect+"WHERE s.group_id = ? AND s.user_id = ?", groupID, userID))
+	if err == sql.ErrNoRows {
+		return &QuizScore{GroupID: groupID, UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quiz score")
+	}
+
+	return score, nil
+}
+
+// Leaderboard retrieves the top players in a group by points
+func (s *SQLiteQuizStore) Leaderboard(groupID string, limit int) ([]*QuizScore, error) {
+	if limit < 1 {
+		limit = 10
+	}
+
+	query := quizScoreSelect + `
+		WHERE s.group_id = ?
+		ORDER BY s.points DESC, s.best_streak DESC, s.correct DESC, s.user_id ASC
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, groupID, limit)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quiz leaderboard")
+	}
+	defer rows.Close()
+
+	scores := []*QuizScore{}
+	for rows.Next() {
+		score, err := scanQuizScore(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan quiz score")
+		}
+		scores = append(scores, score)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through quiz scores")
+	}
+
+	return scores, nil
+}
+
+// scanQuizScore reads a row selected with quizScoreSelect
+func scanQuizScore(row rowScanner) (*QuizScore, error) {
+	var score QuizScore
+	var updatedAt sql.NullTime
+	err := row.Scan(
+		&score.GroupID,
+		&score.UserID,
+		&score.Username,
+		&score.Points,
+		&score.Answered,
+		&score.Correct,
+		&score.Streak,
+		&score.BestStreak,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	score.UpdatedAt = updatedAt.Time
+	return &score, nil
+}
+
+// scanQuizRound reads a round joined with its quote
+func scanQuizRound(row rowScanner) (*QuizRound, error) {
+	var round QuizRound
+	var choices string
+	var chosen sql.NullString
+	var answeredAt sql.NullTime
+	var quote Quote
+	var unlockAt sql.NullTime
+	err := row.Scan(
+		&round.ID,
+		&round.GroupID,
+		&round.UserID,
+		&round.QuoteID,
+		&choices,
+		&round.Answer,
+		&chosen,
+		&round.Correct,
+		&round.Points,
+		&round.CreatedAt,
+		&answeredAt,
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+		&unlockAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(choices), &round.Choices); err != nil {
+		return nil, err
+	}
+	round.Chosen = chosen.String
+	if answeredAt.Valid {
+		round.AnsweredAt = &answeredAt.Time
+	}
+	if unlockAt.Valid {
+		quote.UnlockAt = &unlockAt.Time
+	}
+	round.Quote = &quote
+
+	return &round, nil
+}
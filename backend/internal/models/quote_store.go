@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,7 +11,8 @@ import (
 
 // SQLiteQuoteStore implements QuoteStore interface
 type SQLiteQuoteStore struct {
-	db *sql.DB
+	db        *sql.DB
+	onChanges []func(groupID string)
 }
 
 // NewSQLiteQuoteStore creates a new SQLite quote store
@@ -18,22 +20,50 @@ func NewSQLiteQuoteStore(db *sql.DB) *SQLiteQuoteStore {
 	return &SQLiteQuoteStore{db: db}
 }
 
+// OnChange registers a callback run after quotes in a group are created, updated or deleted
+func (s *SQLiteQuoteStore) OnChange(fn func(groupID string)) {
+	s.onChanges = append(s.onChanges, fn)
+}
+
+// notifyChange runs the registered change callbacks for a group
+func (s *SQLiteQuoteStore) notifyChange(groupID string) {
+	for _, fn := range s.onChanges {
+		fn(groupID)
+	}
+}
+
 // Create inserts a new quote into the database
 func (s *SQLiteQuoteStore) Create(quote *Quote) error {
 	if quote.ID == "" {
 		quote.ID = uuid.New().String()
 	}
 
+	if err := insertQuote(s.db, quote); err != nil {
+		return err
+	}
+
+	s.notifyChange(quote.GroupID)
+
+	return nil
+}
+
+// execer runs statements on a database or inside a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertQuote stores a new quote, stamping its creation time
+func insertQuote(db execer, quote *Quote) error {
 	now := time.Now()
 	quote.CreatedAt = now
 	quote.UpdatedAt = now
 
 	query := `
-		INSERT INTO quotes (id, text, author, uploader_id, group_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO quotes (id, text, author, uploader_id, group_id, created_at, updated_at, unlock_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(query,
+	_, err := db.Exec(query,
 		quote.ID,
 		quote.Text,
 		quote.Author,
@@ -41,6 +71,7 @@ func (s *SQLiteQuoteStore) Create(quote *Quote) error {
 		quote.GroupID,
 		quote.CreatedAt,
 		quote.UpdatedAt,
+		utcTime(quote.UnlockAt),
 	)
 
 	if err != nil {
@@ -52,22 +83,9 @@ func (s *SQLiteQuoteStore) Create(quote *Quote) error {
 
 // GetByID retrieves a quote by its ID
 func (s *SQLiteQuoteStore) GetByID(id string) (*Quote, error) {
-	var quote Quote
-	query := `
-		SELECT id, text, author, uploader_id, group_id, created_at, updated_at
-		FROM quotes
-		WHERE id = ?
-	`
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE id = ?`
 
-	err := s.db.QueryRow(query, id).Scan(
-		&quote.ID,
-		&quote.Text,
-		&quote.Author,
-		&quote.UploaderID,
-		&quote.GroupID,
-		&quote.CreatedAt,
-		&quote.UpdatedAt,
-	)
+	quote, err := scanQuote(s.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound("Quote not found")
@@ -76,42 +94,21 @@ func (s *SQLiteQuoteStore) GetByID(id string) (*Quote, error) {
 		return nil, errors.DatabaseError("Failed to get quote")
 	}
 
-	return &quote, nil
+	return quote, nil
 }
 
-// GetRandom retrieves a random quote, optionally filtered by author
+// GetRandom retrieves a random quote, optionally filtered by author and group
 func (s *SQLiteQuoteStore) GetRandom(filter QuoteFilter) (*Quote, error) {
-	var query string
-	var args []interface{}
-
-	if filter.Author != "" {
-		query = `
-			SELECT id, text, author, uploader_id, group_id, created_at, updated_at
-			FROM quotes
-			WHERE author = ?
-			ORDER BY RANDOM()
-			LIMIT 1
-		`
-		args = append(args, filter.Author)
-	} else {
-		query = `
-			SELECT id, text, author, uploader_id, group_id, created_at, updated_at
-			FROM quotes
-			ORDER BY RANDOM()
-			LIMIT 1
-		`
-	}
+	where, args := quoteFilterWhere(filter)
+	query := `
+		SELECT ` + quoteColumns + `
+		FROM quotes
+	` + where + `
+		ORDER BY RANDOM()
+		LIMIT 1
+	`
 
-	var quote Quote
-	err := s.db.QueryRow(query, args...).Scan(
-		&quote.ID,
-		&quote.Text,
-		&quote.Author,
-		&quote.UploaderID,
-		&quote.GroupID,
-		&quote.CreatedAt,
-		&quote.UpdatedAt,
-	)
+	quote, err := scanQuote(s.db.QueryRow(query, args...))
 
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound("No quotes found")
@@ -120,10 +117,10 @@ func (s *SQLiteQuoteStore) GetRandom(filter QuoteFilter) (*Quote, error) {
 		return nil, errors.DatabaseError("Failed to get random quote")
 	}
 
-	return &quote, nil
+	return quote, nil
 }
 
-// List retrieves quotes with pagination and optional author filter
+// List retrieves quotes with pagination and optional author and group filters
 func (s *SQLiteQuoteStore) List(filter QuoteFilter) ([]*Quote, error) {
 	if filter.Page < 1 {
 		filter.Page = 1
@@ -134,28 +131,119 @@ func (s *SQLiteQuoteStore) List(filter QuoteFilter) ([]*Quote, error) {
 
 	offset := (filter.Page - 1) * filter.Limit
 
-	var query string
-	var args []interface{}
+	where, args := quoteFilterWhere(filter)
+	query := `
+		SELECT ` + quoteColumns + `
+		FROM quotes
+	` + where + `
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, filter.Limit, offset)
 
-	if filter.Author != "" {
-		query = `
-			SELECT id, text, author, uploader_id, group_id, created_at, updated_at
-			FROM quotes
-			WHERE author = ?
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = append(args, filter.Author, filter.Limit, offset)
-	} else {
-		query = `
-			SELECT id, text, author, uploader_id, group_id, created_at, updated_at
-			FROM quotes
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = append(args, filter.Limit, offset)
+	return s.queryQuotes(query, args...)
+}
+
+// ListByGroup retrieves every quote in a group, oldest first
+func (s *SQLiteQuoteStore) ListByGroup(groupID string) ([]*Quote, error) {
+	query := `
+		SELECT ` + quoteColumns + `
+		FROM quotes
+		WHERE group_id = ?
+		ORDER BY created_at ASC
+	`
+
+	return s.queryQuotes(query, groupID)
+}
+
+// eachBatchSize is how many quotes EachByGroup reads per query
+const eachBatchSize = 500
+
+// EachByGroup calls fn for every quote in a group, oldest first, without loading them all into memory.
+// Quotes are read in batches and no query is open while fn runs, so a slow caller such as a
+// download doesn't hold a read on the database. Iteration stops at the first error fn returns,
+// which is passed back to the caller.
+func (s *SQLiteQuoteStore) EachByGroup(groupID string, fn func(*Quote) error) error {
+	var after *quoteKey
+	for {
+		quotes, keys, err := s.groupBatch(groupID, after)
+		if err != nil {
+			return err
+		}
+
+		for _, quote := range quotes {
+			if err := fn(quote); err != nil {
+				return err
+			}
+		}
+
+		if len(quotes) < eachBatchSize {
+			return nil
+		}
+		after = &keys[len(keys)-1]
+	}
+}
+
+// quoteKey is the position of a quote in created_at, id order. The time is kept as stored,
+// since that is what the database orders by.
+type quoteKey struct {
+	createdAt string
+	id        string
+}
+
+// groupBatch reads the next batch of a group's quotes after the given position, or from the start
+func (s *SQLiteQuoteStore) groupBatch(groupID string, after *quoteKey) ([]*Quote, []quoteKey, error) {
+	query := `
+		SELECT ` + quoteColumns + `, CAST(created_at AS TEXT)
+		FROM quotes
+		WHERE group_id = ?
+	`
+	args := []interface{}{groupID}
+	if after != nil {
+		query += ` AND (created_at > ? OR (created_at = ? AND id > ?))`
+		args = append(args, after.createdAt, after.createdAt, after.id)
 	}
+	query += ` ORDER BY created_at ASC, id ASC LIMIT ?`
+	args = append(args, eachBatchSize)
 
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, errors.DatabaseError("Failed to list quotes")
+	}
+	defer rows.Close()
+
+	var quotes []*Quote
+	var keys []quoteKey
+	for rows.Next() {
+		var key quoteKey
+		quote, err := scanQuote(keyedRow{rows, &key.createdAt})
+		if err != nil {
+			return nil, nil, errors.DatabaseError("Failed to scan quote data")
+		}
+		key.id = quote.ID
+		quotes = append(quotes, quote)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.DatabaseError("Error iterating through quotes")
+	}
+
+	return quotes, keys, nil
+}
+
+// keyedRow scans one extra column after a row's quote columns into key
+type keyedRow struct {
+	rows *sql.Rows
+	key  *string
+}
+
+func (r keyedRow) Scan(dest ...interface{}) error {
+	return r.rows.Scan(append(dest, r.key)...)
+}
+
+// queryQuotes runs a query returning full quote rows
+func (s *SQLiteQuoteStore) queryQuotes(query string, args ...interface{}) ([]*Quote, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, errors.DatabaseError("Failed to list quotes")
@@ -164,20 +252,11 @@ func (s *SQLiteQuoteStore) List(filter QuoteFilter) ([]*Quote, error) {
 
 	var quotes []*Quote
 	for rows.Next() {
-		var quote Quote
-		err := rows.Scan(
-			&quote.ID,
-			&quote.Text,
-			&quote.Author,
-			&quote.UploaderID,
-			&quote.GroupID,
-			&quote.CreatedAt,
-			&quote.UpdatedAt,
-		)
+		quote, err := scanQuote(rows)
 		if err != nil {
 			return nil, errors.DatabaseError("Failed to scan quote data")
 		}
-		quotes = append(quotes, &quote)
+		quotes = append(quotes, quote)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -187,6 +266,83 @@ func (s *SQLiteQuoteStore) List(filter QuoteFilter) ([]*Quote, error) {
 	return quotes, nil
 }
 
+// quoteColumns lists the columns scanQuote reads, in order
+const quoteColumns = `id, text, author, uploader_id, group_id, created_at, updated_at, unlock_at, reply_author, reply_text,
+	original_uploader`
+
+// scanQuote reads a quote selected with quoteColumns
+func scanQuote(row rowScanner) (*Quote, error) {
+	var quote Quote
+	var unlockAt sql.NullTime
+	var replyAuthor, replyText, originalUploader sql.NullString
+	err := row.Scan(
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+		&unlockAt,
+		&replyAuthor,
+		&replyText,
+		&originalUploader,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if unlockAt.Valid {
+		quote.UnlockAt = &unlockAt.Time
+	}
+	if replyText.Valid {
+		quote.ReplyTo = &Reply{Author: replyAuthor.String, Text: replyText.String}
+	}
+	quote.OriginalUploader = originalUploader.String
+
+	return &quote, nil
+}
+
+// utcTime stores optional times in UTC so they compare correctly as text in SQLite
+func utcTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// quoteFilterWhere builds the WHERE clause and arguments for a quote filter
+func quoteFilterWhere(filter QuoteFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Author != "" {
+		conditions = append(conditions, "author = ?")
+		args = append(args, filter.Author)
+	}
+	if filter.GroupID != "" {
+		conditions = append(conditions, "group_id = ?")
+		args = append(args, filter.GroupID)
+	}
+	if filter.VisibleTo != "" {
+		conditions = append(conditions, "(unlock_at IS NULL OR unlock_at <= ? OR uploader_id = ?)")
+		args = append(args, time.Now().UTC(), filter.VisibleTo)
+	}
+	if filter.Unlocked {
+		conditions = append(conditions, "(unlock_at IS NULL OR unlock_at <= ?)")
+		args = append(args, time.Now().UTC())
+	}
+	if filter.HasAuthor {
+		conditions = append(conditions, "author IS NOT NULL AND author <> ''")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // Update updates an existing quote
 func (s *SQLiteQuoteStore) Update(quote *Quote) error {
 	quote.UpdatedAt = time.Now()
@@ -216,11 +372,22 @@ func (s *SQLiteQuoteStore) Update(quote *Quote) error {
 		return errors.NotFound("Quote not found")
 	}
 
+	s.notifyChange(quote.GroupID)
+
 	return nil
 }
 
 // Delete removes a quote from the database
 func (s *SQLiteQuoteStore) Delete(id string) error {
+	var groupID string
+	err := s.db.QueryRow(`SELECT group_id FROM quotes WHERE id = ?`, id).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("Quote not found")
+	}
+	if err != nil {
+		return errors.DatabaseError("Failed to get quote")
+	}
+
 	query := `DELETE FROM quotes WHERE id = ?`
 
 	result, err := s.db.Exec(query, id)
@@ -237,5 +404,422 @@ func (s *SQLiteQuoteStore) Delete(id string) error {
 		return errors.NotFound("Quote not found")
 	}
 
+	s.notifyChange(groupID)
+
+	return nil
+}
+
+// AuthorCounts counts the quotes attributed to each author in a group, most quoted first.
+// Sealed time capsules aren't counted.
+func (s *SQLiteQuoteStore) AuthorCounts(groupID string) ([]StatCount, error) {
+	rows, err := s.db.Query(`
+		SELECT author, COUNT(*) AS total
+		FROM quotes
+		WHERE group_id = ? AND author IS NOT NULL AND author <> ''
+		AND (unlock_at IS NULL OR unlock_at <= ?)
+		GROUP BY author
+		ORDER BY total DESC, author ASC
+	`, groupID, time.Now().UTC())
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to count authors")
+	}
+	defer rows.Close()
+
+	counts := []StatCount{}
+	for rows.Next() {
+		var c StatCount
+		if err := rows.Scan(&c.Label, &c.Count); err != nil {
+			return nil, errors.DatabaseError("Failed to scan author count")
+		}
+		counts = append(counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through authors")
+	}
+
+	return counts, nil
+}
+
+// ReleaseUnlocked marks every time capsule that has unlocked by now as announced and returns them.
+// Each capsule is returned once, so callers can notify its group without repeating themselves.
+func (s *SQLiteQuoteStore) ReleaseUnlocked(now time.Time) ([]*Quote, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT `+quoteColumns+`
+		FROM quotes
+		WHERE unlock_at IS NOT NULL AND unlock_at <= ? AND unlock_notified_at IS NULL
+		ORDER BY unlock_at ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list unlocked quotes")
+	}
+
+	var quotes []*Quote
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			rows.Close()
+			return nil, errors.DatabaseError("Failed to scan quote data")
+		}
+		quotes = append(quotes, quote)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through unlocked quotes")
+	}
+
+	for _, quote := range quotes {
+		if _, err := tx.Exec(`UPDATE quotes SET unlock_notified_at = ? WHERE id = ?`, now.UTC(), quote.ID); err != nil {
+			return nil, errors.DatabaseError("Failed to mark quote unlocked")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.DatabaseError("Failed to commit unlocked quotes")
+	}
+
+	// Unlocking changes what the group can see, so cached stats need rebuilding
+	notified := make(map[string]bool)
+	for _, quote := range quotes {
+		if !notified[quote.GroupID] {
+			notified[quote.GroupID] = true
+			s.notifyChange(quote.GroupID)
+		}
+	}
+
+	return quotes, nil
+}
+
+// Import inserts a batch of quotes, the reactions to them and their import record in a single
+// transaction. Reactions refer to quotes by the ID they are passed with, and follow a quote whose
+// ID is already taken here to the new one it gets.
+func (s *SQLiteQuoteStore) Import(imp *Import, quotes []*Quote, reactions []*Reaction) error {
+	if imp.ID == "" {
+		imp.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	imp.CreatedAt = now
+	imp.QuoteCount = len(quotes)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO imports (id, group_id, source, quote_count, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`,
+		imp.ID,
+		imp.GroupID,
+		imp.Source,
+		imp.QuoteCount,
+		imp.CreatedBy,
+		imp.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create import")
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO quotes (id, text, author, uploader_id, group_id, import_id, created_at, updated_at, unlock_at, reply_author, reply_text,
+			original_uploader)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return errors.DatabaseError("Failed to prepare quote insert")
+	}
+	defer stmt.Close()
+
+	exists, err := tx.Prepare(`SELECT COUNT(*) FROM quotes WHERE id = ?`)
+	if err != nil {
+		return errors.DatabaseError("Failed to prepare quote lookup")
+	}
+	defer exists.Close()
+
+	imported := make(map[string]string, len(quotes)) // ID passed in to ID stored
+	for _, quote := range quotes {
+		// Quotes restored from an export keep their ID unless it is already taken here
+		passedID := quote.ID
+		if quote.ID != "" {
+			var count int
+			if err := exists.QueryRow(quote.ID).Scan(&count); err != nil {
+				return errors.DatabaseError("Failed to check quote ID")
+			}
+			if count > 0 {
+				quote.ID = ""
+			}
+		}
+		if quote.ID == "" {
+			quote.ID = uuid.New().String()
+		}
+		if passedID != "" {
+			imported[passedID] = quote.ID
+		}
+		if quote.CreatedAt.IsZero() {
+			quote.CreatedAt = now
+		}
+		if quote.UpdatedAt.IsZero() {
+			quote.UpdatedAt = now
+		}
+		quote.GroupID = imp.GroupID
+
+		var replyAuthor, replyText interface{}
+		if quote.ReplyTo != nil {
+			replyAuthor, replyText = quote.ReplyTo.Author, quote.ReplyTo.Text
+		}
+
+		_, err := stmt.Exec(
+			quote.ID,
+			quote.Text,
+			quote.Author,
+			quote.UploaderID,
+			quote.GroupID,
+			imp.ID,
+			quote.CreatedAt,
+			quote.UpdatedAt,
+			utcTime(quote.UnlockAt),
+			replyAuthor,
+			replyText,
+			nullString(quote.OriginalUploader),
+		)
+		if err != nil {
+			return errors.DatabaseError("Failed to import quote")
+		}
+	}
+
+	if len(reactions) > 0 {
+		react, err := tx.Prepare(`
+			INSERT OR IGNORE INTO quote_reactions (quote_id, user_id, emoji, created_at)
+			VALUES (?, ?, ?, ?)
+		`)
+		if err != nil {
+			return errors.DatabaseError("Failed to prepare reaction insert")
+		}
+		defer react.Close()
+
+		for _, r := range reactions {
+			quoteID, ok := imported[r.QuoteID]
+			if !ok {
+				continue
+			}
+			r.QuoteID = quoteID
+			if r.CreatedAt.IsZero() {
+				r.CreatedAt = now
+			}
+			if _, err := react.Exec(r.QuoteID, r.UserID, r.Emoji, r.CreatedAt); err != nil {
+				return errors.DatabaseError("Failed to import reaction")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit import")
+	}
+
+	s.notifyChange(imp.GroupID)
+
+	return nil
+}
+
+// GetImport retrieves an import by its ID
+func (s *SQLiteQuoteStore) GetImport(id string) (*Import, error) {
+	query := `
+		SELECT id, group_id, source, quote_count, created_by, created_at, undone_at
+		FROM imports
+		WHERE id = ?
+	`
+
+	imp, err := scanImport(s.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Import not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get import")
+	}
+
+	return imp, nil
+}
+
+// ListImports retrieves the imports made into a group, most recent first
+func (s *SQLiteQuoteStore) ListImports(groupID string) ([]*Import, error) {
+	query := `
+		SELECT id, group_id, source, quote_count, created_by, created_at, undone_at
+		FROM imports
+		WHERE group_id = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query, groupID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list imports")
+	}
+	defer rows.Close()
+
+	var imports []*Import
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan import data")
+		}
+		imports = append(imports, imp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through imports")
+	}
+
+	return imports, nil
+}
+
+// UndoImport deletes every quote created by an import and marks it undone
+func (s *SQLiteQuoteStore) UndoImport(id string) error {
+	imp, err := s.GetImport(id)
+	if err != nil {
+		return err
+	}
+	if imp.UndoneAt != nil {
+		return errors.InvalidInput("Import has already been undone")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM quotes WHERE import_id = ?`, id); err != nil {
+		return errors.DatabaseError("Failed to delete imported quotes")
+	}
+
+	if _, err := tx.Exec(`UPDATE imports SET undone_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		return errors.DatabaseError("Failed to mark import undone")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit undo")
+	}
+
+	s.notifyChange(imp.GroupID)
+
+	return nil
+}
+
+func scanImport(row rowScanner) (*Import, error) {
+	var imp Import
+	var undoneAt sql.NullTime
+	err := row.Scan(
+		&imp.ID,
+		&imp.GroupID,
+		&imp.Source,
+		&imp.QuoteCount,
+		&imp.CreatedBy,
+		&imp.CreatedAt,
+		&undoneAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if undoneAt.Valid {
+		imp.UndoneAt = &undoneAt.Time
+	}
+
+	return &imp, nil
+}
+
+// CreateImportPreview stores a parsed export, clearing out previews that have expired
+func (s *SQLiteQuoteStore) CreateImportPreview(preview *ImportPreview) error {
+	if preview.ID == "" {
+		preview.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	preview.CreatedAt = now
+	if preview.ExpiresAt.IsZero() {
+		preview.ExpiresAt = now.Add(24 * time.Hour)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM import_previews WHERE expires_at < ?`, now); err != nil {
+		return errors.DatabaseError("Failed to clear expired previews")
+	}
+
+	query := `
+		INSERT INTO import_previews (id, group_id, source, payload, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		preview.ID,
+		preview.GroupID,
+		preview.Source,
+		string(preview.Payload),
+		preview.CreatedBy,
+		preview.CreatedAt,
+		preview.ExpiresAt,
+	)
+
+	if err != nil {
+		return errors.DatabaseError("Failed to create import preview")
+	}
+
+	return nil
+}
+
+// GetImportPreview retrieves an import preview that has not expired
+func (s *SQLiteQuoteStore) GetImportPreview(id string) (*ImportPreview, error) {
+	var preview ImportPreview
+	var payload string
+	query := `
+		SELECT id, group_id, source, payload, created_by, created_at, expires_at
+		FROM import_previews
+		WHERE id = ? AND expires_at > ?
+	`
+
+	err := s.db.QueryRow(query, id, time.Now()).Scan(
+		&preview.ID,
+		&preview.GroupID,
+		&preview.Source,
+		&payload,
+		&preview.CreatedBy,
+		&preview.CreatedAt,
+		&preview.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Import preview not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get import preview")
+	}
+
+	preview.Payload = []byte(payload)
+	return &preview, nil
+}
+
+// DeleteImportPreview removes an import preview
+func (s *SQLiteQuoteStore) DeleteImportPreview(id string) error {
+	result, err := s.db.Exec(`DELETE FROM import_previews WHERE id = ?`, id)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete import preview")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Import preview not found")
+	}
+
 	return nil
 }
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteReactionStore implements ReactionStore interface
+type SQLiteReactionStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteReactionStore creates a new SQLite reaction store
+func NewSQLiteReactionStore(db *sql.DB) *SQLiteReactionStore {
+	return &SQLiteReactionStore{db: db}
+}
+
+// Add records a user's reaction to a quote, reporting false if they had already left it
+func (s *SQLiteReactionStore) Add(quoteID, userID, emoji string) (bool, error) {
+	query := `
+		INSERT OR IGNORE INTO quote_reactions (quote_id, user_id, emoji, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(query, quoteID, userID, emoji, time.Now())
+	if err != nil {
+		return false, errors.DatabaseError("Failed to add reaction")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.DatabaseError("Failed to check insert result")
+	}
+
+	return rows > 0, nil
+}
+
+// Remove deletes a user's reaction to a quote
+func (s *SQLiteReactionStore) Remove(quoteID, userID, emoji string) error {
+	query := `DELETE FROM quote_reactions WHERE quote_id = ? AND user_id = ? AND emoji = ?`
+
+	result, err := s.db.Exec(query, quoteID, userID, emoji)
+	if err != nil {
+		return errors.DatabaseError("Failed to remove reaction")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Reaction not found")
+	}
+
+	return nil
+}
+
+// Summary counts the reactions to a quote by emoji, most used first, noting which ones the user made
+func (s *SQLiteReactionStore) Summary(quoteID, userID string) ([]ReactionCount, error) {
+	query := `
+		SELECT emoji, COUNT(*) AS total, MAX(user_id = ?)
+		FROM quote_reactions
+		WHERE quote_id = ?
+		GROUP BY emoji
+		ORDER BY total DESC, MIN(created_at) ASC
+	`
+
+	rows, err := s.db.Query(query, userID, quoteID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get reactions")
+	}
+	defer rows.Close()
+
+	counts := []ReactionCount{}
+	for rows.Next() {
+		var c ReactionCount
+		if err := rows.Scan(&c.Emoji, &c.Count, &c.Reacted); err != nil {
+			return nil, errors.DatabaseError("Failed to scan reaction data")
+		}
+		counts = append(counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through reactions")
+	}
+
+	return counts, nil
+}
+
+// ListByGroup retrieves every reaction to a group's quotes, keyed by quote ID and oldest first
+func (s *SQLiteReactionStore) ListByGroup(groupID string) (map[string][]*Reaction, error) {
+	query := `
+		SELECT r.quote_id, r.user_id, r.emoji, r.created_at
+		FROM quote_reactions r
+		JOIN quotes q ON q.id = r.quote_id
+		WHERE q.group_id = ?
+		ORDER BY r.created_at ASC
+	`
+
+	rows, err := s.db.Query(query, groupID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list reactions")
+	}
+	defer rows.Close()
+
+	reactions := make(map[string][]*Reaction)
+	for rows.Next() {
+		var r Reaction
+		if err := rows.Scan(&r.QuoteID, &r.UserID, &r.Emoji, &r.CreatedAt); err != nil {
+			return nil, errors.DatabaseError("Failed to scan reaction data")
+		}
+		reactions[r.QuoteID] = append(reactions[r.QuoteID], &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through reactions")
+	}
+
+	return reactions, nil
+}
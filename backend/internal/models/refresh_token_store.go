@@ -0,0 +1,174 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteRefreshTokenStore implements RefreshTokenStore interface
+type SQLiteRefreshTokenStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteRefreshTokenStore creates a new SQLite refresh token store
+func NewSQLiteRefreshTokenStore(db *sql.DB) *SQLiteRefreshTokenStore {
+	return &SQLiteRefreshTokenStore{db: db}
+}
+
+// Create issues the first refresh token of a new family, for a fresh login. The token is
+// returned along with its record, only its hash is stored.
+func (s *SQLiteRefreshTokenStore) Create(userID string, ttl time.Duration) (string, *RefreshToken, error) {
+	// Expired tokens can't be used or reused, so they're cleared out as new logins come in
+	if _, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at <= ?`, time.Now().UTC()); err != nil {
+		return "", nil, errors.DatabaseError("Failed to create refresh token")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", nil, errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	token, record, err := insertRefreshToken(tx, userID, uuid.New().String(), ttl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, errors.DatabaseError("Failed to commit refresh token")
+	}
+
+	return token, record, nil
+}
+
+// Rotate trades a refresh token in for the next one in its family. Each token can be traded
+// in once, when one comes back a second time either it or its successor is in the wrong hands,
+// so the whole family is revoked and the user has to log in again.
+func (s *SQLiteRefreshTokenStore) Rotate(token string, ttl time.Duration) (string, *RefreshToken, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", nil, errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var (
+		id, userID, familyID string
+		expiresAt            time.Time
+		usedAt, revokedAt    sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`, hashRefreshToken(token)).Scan(&id, &userID, &familyID, &expiresAt, &usedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return "", nil, errors.InvalidToken("Invalid refresh token")
+	}
+	if err != nil {
+		return "", nil, errors.DatabaseError("Failed to retrieve refresh token")
+	}
+
+	now := time.Now().UTC()
+	if revokedAt.Valid || !now.Before(expiresAt) {
+		return "", nil, errors.InvalidToken("Refresh token has expired or been revoked")
+	}
+
+	// Marking the token used only if it wasn't already also settles two uses racing each other
+	reused := usedAt.Valid
+	if !reused {
+		result, err := tx.Exec(`UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`, now, id)
+		if err != nil {
+			return "", nil, errors.DatabaseError("Failed to use refresh token")
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return "", nil, errors.DatabaseError("Failed to check update result")
+		}
+		reused = rows == 0
+	}
+
+	if reused {
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, now, familyID); err != nil {
+			return "", nil, errors.DatabaseError("Failed to revoke refresh tokens")
+		}
+		if err := tx.Commit(); err != nil {
+			return "", nil, errors.DatabaseError("Failed to revoke refresh tokens")
+		}
+		return "", nil, errors.InvalidToken("Refresh token was already used, log in again")
+	}
+
+	next, record, err := insertRefreshToken(tx, userID, familyID, ttl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, errors.DatabaseError("Failed to commit refresh token")
+	}
+
+	return next, record, nil
+}
+
+// RevokeFamily revokes a refresh token along with every other token in its family, for logging
+// out. Unknown tokens are ignored so logging out twice isn't an error.
+func (s *SQLiteRefreshTokenStore) RevokeFamily(token string) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = ?
+		WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id FROM refresh_tokens WHERE token_hash = ?
+		)
+	`
+
+	if _, err := s.db.Exec(query, time.Now().UTC(), hashRefreshToken(token)); err != nil {
+		return errors.DatabaseError("Failed to revoke refresh tokens")
+	}
+
+	return nil
+}
+
+// insertRefreshToken mints a token in the given family
+func insertRefreshToken(tx *sql.Tx, userID, familyID string, ttl time.Duration) (string, *RefreshToken, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	record := &RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (id, token_hash, user_id, family_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.Exec(query,
+		record.ID,
+		hashRefreshToken(token),
+		record.UserID,
+		record.FamilyID,
+		record.ExpiresAt.UTC(),
+		record.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return "", nil, errors.DatabaseError("Failed to create refresh token")
+	}
+
+	return token, record, nil
+}
+
+// hashRefreshToken is what's stored in place of a token. Tokens are random, so a plain hash
+// is enough to keep a leaked database from handing out working ones.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
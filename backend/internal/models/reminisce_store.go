@@ -0,0 +1,225 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// reminisceFirstDelay is how long a newly queued quote waits before it first comes back
+const reminisceFirstDelay = 24 * time.Hour
+
+// SQLiteReminisceStore implements ReminisceStore interface
+type SQLiteReminisceStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteReminisceStore creates a new SQLite reminisce store
+func NewSQLiteReminisceStore(db *sql.DB) *SQLiteReminisceStore {
+	return &SQLiteReminisceStore{db: db}
+}
+
+const reminisceSelect = `
+	SELECT r.user_id, r.quote_id, r.loved, r.ease, r.interval_days, r.repetitions, r.due_at, r.reviewed_at,
+		r.created_at,
+		q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at, q.unlock_at
+	FROM reminisce_items r
+	JOIN quotes q ON q.id = r.quote_id
+`
+
+// reminisceVisible limits items to quotes in groups the user still belongs to and that they can read.
+// Its arguments are the user ID, the current time and the user ID again.
+const reminisceVisible = `
+	AND EXISTS (SELECT 1 FROM groups g WHERE g.group_id = q.group_id AND g.member_id = ?)
+	AND (q.unlock_at IS NULL OR q.unlock_at <= ? OR q.uploader_id = ?)
+`
+
+// Enroll adds a quote to a user's queue. Quotes already queued keep their schedule.
+func (s *SQLiteReminisceStore) Enroll(userID, quoteID string) error {
+	now := time.Now()
+	query := `
+		INSERT OR IGNORE INTO reminisce_items (user_id, quote_id, due_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	if _, err := s.db.Exec(query, userID, quoteID, now.Add(reminisceFirstDelay).UTC(), now); err != nil {
+		return errors.DatabaseError("Failed to queue quote")
+	}
+
+	return nil
+}
+
+// SetLoved marks or unmarks a quote as loved, queueing it if it isn't already
+func (s *SQLiteReminisceStore) SetLoved(userID, quoteID string, loved bool) error {
+	if !loved {
+		if _, err := s.db.Exec(`UPDATE reminisce_items SET loved = 0 WHERE user_id = ? AND quote_id = ?`, userID, quoteID); err != nil {
+			return errors.DatabaseError("Failed to unlove quote")
+		}
+		return nil
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO reminisce_items (user_id, quote_id, loved, due_at, created_at)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (user_id, quote_id) DO UPDATE SET loved = 1
+	`
+
+	if _, err := s.db.Exec(query, userID, quoteID, now.Add(reminisceFirstDelay).UTC(), now); err != nil {
+		return errors.DatabaseError("Failed to love quote")
+	}
+
+	return nil
+}
+
+// Unenroll drops a quote from a user's queue once they neither love it nor have any reaction to it
+func (s *SQLiteReminisceStore) Unenroll(userID, quoteID string) error {
+	query := `
+		DELETE FROM reminisce_items
+		WHERE user_id = ? AND quote_id = ? AND loved = 0
+		AND NOT EXISTS (SELECT 1 FROM quote_reactions WHERE quote_id = ? AND user_id = ?)
+	`
+
+	if _, err := s.db.Exec(query, userID, quoteID, quoteID, userID); err != nil {
+		return errors.DatabaseError("Failed to remove quote from queue")
+	}
+
+	return nil
+}
+
+// Get retrieves a quote's place in a user's queue, as long as the user can still read the quote
+func (s *SQLiteReminisceStore) Get(userID, quoteID string) (*ReminisceItem, error) {
+	query := reminisceSelect + `
+		WHERE r.user_id = ? AND r.quote_id = ?
+	` + reminisceVisible
+
+	now := time.Now().UTC()
+	item, err := scanReminisceItem(s.db.QueryRow(query, userID, quoteID, userID, now, userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Quote is not in the reminisce queue")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get reminisce item")
+	}
+
+	return item, nil
+}
+
+// Next retrieves the most overdue quote in a user's queue
+func (s *SQLiteReminisceStore) Next(userID string, now time.Time) (*ReminisceItem, error) {
+	query := reminisceSelect + `
+		WHERE r.user_id = ? AND r.due_at <= ?
+	` + reminisceVisible + `
+		ORDER BY r.due_at ASC
+		LIMIT 1
+	`
+
+	item, err := scanReminisceItem(s.db.QueryRow(query, userID, now.UTC(), userID, now.UTC(), userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Nothing to reminisce right now")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get next reminisce item")
+	}
+
+	return item, nil
+}
+
+// NextDue returns when the next quote in a user's queue comes due, nil if the queue is empty
+func (s *SQLiteReminisceStore) NextDue(userID string) (*time.Time, error) {
+	now := time.Now().UTC()
+	query := `
+		SELECT r.due_at
+		FROM reminisce_items r
+		JOIN quotes q ON q.id = r.quote_id
+		WHERE r.user_id = ?
+	` + reminisceVisible + `
+		ORDER BY r.due_at ASC
+		LIMIT 1
+	`
+
+	var dueAt time.Time
+	err := s.db.QueryRow(query, userID, userID, now, userID).Scan(&dueAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get next due time")
+	}
+
+	return &dueAt, nil
+}
+
+// UpdateSchedule saves an item's schedule after it has been reviewed
+func (s *SQLiteReminisceStore) UpdateSchedule(item *ReminisceItem) error {
+	query := `
+		UPDATE reminisce_items
+		SET ease = ?, interval_days = ?, repetitions = ?, due_at = ?, reviewed_at = ?
+		WHERE user_id = ? AND quote_id = ?
+	`
+
+	result, err := s.db.Exec(query,
+		item.Ease,
+		item.Interval,
+		item.Repetitions,
+		item.DueAt.UTC(),
+		item.ReviewedAt,
+		item.UserID,
+		item.QuoteID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update reminisce schedule")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check update result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Quote is not in the reminisce queue")
+	}
+
+	return nil
+}
+
+// scanReminisceItem reads an item joined with its quote
+func scanReminisceItem(row rowScanner) (*ReminisceItem, error) {
+	var item ReminisceItem
+	var reviewedAt sql.NullTime
+	var quote Quote
+	var unlockAt sql.NullTime
+	err := row.Scan(
+		&item.UserID,
+		&item.QuoteID,
+		&item.Loved,
+		&item.Ease,
+		&item.Interval,
+		&item.Repetitions,
+		&item.DueAt,
+		&reviewedAt,
+		&item.CreatedAt,
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+		&unlockAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewedAt.Valid {
+		item.ReviewedAt = &reviewedAt.Time
+	}
+	if unlockAt.Valid {
+		quote.UnlockAt = &unlockAt.Time
+	}
+	item.Quote = &quote
+
+	return &item, nil
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteShareStore implements ShareStore interface
+type SQLiteShareStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteShareStore creates a new SQLite share link store
+func NewSQLiteShareStore(db *sql.DB) *SQLiteShareStore {
+	return &SQLiteShareStore{db: db}
+}
+
+// Create mints a new share link with a fresh token
+func (s *SQLiteShareStore) Create(link *ShareLink) error {
+	if link.ID == "" {
+		link.ID = uuid.New().String()
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	link.Token = token
+	link.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO share_links (id, token, quote_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		link.ID,
+		link.Token,
+		link.QuoteID,
+		link.CreatedBy,
+		link.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.DatabaseError("Failed to create share link")
+	}
+
+	return nil
+}
+
+// GetByToken retrieves a share link and its quote by token
+func (s *SQLiteShareStore) GetByToken(token string) (*ShareLink, error) {
+	query := `
+		SELECT l.id, l.token, l.quote_id, l.created_by, l.created_at,
+			q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at, q.unlock_at
+		FROM share_links l
+		JOIN quotes q ON q.id = l.quote_id
+		WHERE l.token = ?
+	`
+
+	link, err := scanShareLink(s.db.QueryRow(query, token))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Share link not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get share link")
+	}
+
+	return link, nil
+}
+
+// ListByCreator retrieves the share links a user created, newest first
+func (s *SQLiteShareStore) ListByCreator(userID string) ([]*ShareLink, error) {
+	query := `
+		SELECT l.id, l.token, l.quote_id, l.created_by, l.created_at,
+			q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at, q.unlock_at
+		FROM share_links l
+		JOIN quotes q ON q.id = l.quote_id
+		WHERE l.created_by = ?
+		ORDER BY l.created_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list share links")
+	}
+	defer rows.Close()
+
+	var links []*ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan share link data")
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through share links")
+	}
+
+	return links, nil
+}
+
+// Delete revokes a share link created by the given user
+func (s *SQLiteShareStore) Delete(id, createdBy string) error {
+	query := `DELETE FROM share_links WHERE id = ? AND created_by = ?`
+
+	result, err := s.db.Exec(query, id, createdBy)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete share link")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Share link not found")
+	}
+
+	return nil
+}
+
+// scanShareLink reads a share link joined with its quote
+func scanShareLink(row rowScanner) (*ShareLink, error) {
+	var link ShareLink
+	var quote Quote
+	var unlockAt sql.NullTime
+	err := row.Scan(
+		&link.ID,
+		&link.Token,
+		&link.QuoteID,
+		&link.CreatedBy,
+		&link.CreatedAt,
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+		&unlockAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if unlockAt.Valid {
+		quote.UnlockAt = &unlockAt.Time
+	}
+	link.Quote = &quote
+	return &link, nil
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// statsTopLimit is the number of entries returned in each leaderboard
+const statsTopLimit = 10
+
+// statsWhere limits statistics to a group's quotes, leaving out sealed time capsules so nothing
+// counted gives them away. Its arguments are the group ID and the current time.
+const statsWhere = "WHERE group_id = ? AND (unlock_at IS NULL OR unlock_at <= ?)"
+
+var weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// SQLiteStatsStore implements StatsStore interface, caching results until a group's quotes change
+type SQLiteStatsStore struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	cache map[string]*GroupStats
+
+	// generation counts each group's invalidations, so statistics computed while a group's
+	// quotes changed aren't cached over the change
+	generation map[string]uint64
+}
+
+// NewSQLiteStatsStore creates a new SQLite stats store
+func NewSQLiteStatsStore(db *sql.DB) *SQLiteStatsStore {
+	return &SQLiteStatsStore{
+		db:         db,
+		cache:      make(map[string]*GroupStats),
+		generation: make(map[string]uint64),
+	}
+}
+
+// Invalidate drops the cached statistics for a group
+func (s *SQLiteStatsStore) Invalidate(groupID string) {
+	s.mu.Lock()
+	delete(s.cache, groupID)
+	s.generation[groupID]++
+	s.mu.Unlock()
+}
+
+// GetGroupStats returns aggregated statistics for a group's quotes
+func (s *SQLiteStatsStore) GetGroupStats(groupID string) (*GroupStats, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[groupID]
+	generation := s.generation[groupID]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	stats := &GroupStats{GroupID: groupID}
+	now := time.Now().UTC()
+
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(LENGTH(text)), 0)
+		FROM quotes
+		`+statsWhere, groupID, now).Scan(&stats.TotalQuotes, &stats.AverageLength)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quote totals")
+	}
+
+	stats.TopAuthors, err = s.queryCounts(`
+		SELECT author, COUNT(*) AS total
+		FROM quotes
+		`+statsWhere+` AND author IS NOT NULL AND author <> ''
+		GROUP BY author
+		ORDER BY total DESC, author ASC
+		LIMIT ?
+	`, groupID, now, statsTopLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.TopUploaders, err = s.topUploaders(groupID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.QuotesPerMonth, err = s.queryCounts(`
+		SELECT strftime('%Y-%m', created_at) AS month, COUNT(*)
+		FROM quotes
+		`+statsWhere+`
+		GROUP BY month
+		ORDER BY month ASC
+	`, groupID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	weekdays, err := s.queryCounts(`
+		SELECT strftime('%w', created_at) AS weekday, COUNT(*)
+		FROM quotes
+		`+statsWhere+`
+		GROUP BY weekday
+	`, groupID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Weekdays = make([]StatCount, len(weekdayNames))
+	for i, name := range weekdayNames {
+		stats.Weekdays[i].Label = name
+	}
+	busiest := -1
+	for _, w := range weekdays {
+		if len(w.Label) != 1 || w.Label[0] < '0' || w.Label[0] > '6' {
+			continue
+		}
+		day := int(w.Label[0] - '0')
+		stats.Weekdays[day].Count = w.Count
+		if busiest < 0 || w.Count > stats.Weekdays[busiest].Count || (w.Count == stats.Weekdays[busiest].Count && day < busiest) {
+			busiest = day
+		}
+	}
+	if busiest >= 0 {
+		stats.BusiestWeekday = weekdayNames[busiest]
+	}
+
+	// Split quote text into words with a recursive CTE so counting stays in SQL
+	stats.TopWords, err = s.queryCounts(`
+		WITH RECURSIVE split(word, rest) AS (
+			SELECT '', lower(
+				replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(
+					text, char(10), ' '), char(13), ' '), '''', ''), '’', ''), '.', ' '), ',', ' '),
+					'!', ' '), '?', ' '), ';', ' '), ':', ' '), '"', ' ')
+			) || ' '
+			FROM quotes
+			`+statsWhere+`
+			UNION ALL
+			SELECT substr(rest, 1, instr(rest, ' ') - 1), substr(rest, instr(rest, ' ') + 1)
+			FROM split
+			WHERE rest <> ''
+		)
+		SELECT trim(word, '()[]-_*') AS w, COUNT(*) AS total
+		FROM split
+		WHERE trim(word, '()[]-_*') <> ''
+		AND trim(word, '()[]-_*') NOT IN (SELECT word FROM stop_words)
+		GROUP BY w
+		ORDER BY total DESC, w ASC
+		LIMIT ?
+	`, groupID, now, statsTopLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.generation[groupID] == generation {
+		s.cache[groupID] = stats
+	}
+	s.mu.Unlock()
+
+	return stats, nil
+}
+
+// topUploaders returns the users who uploaded the most quotes in a group
+func (s *SQLiteStatsStore) topUploaders(groupID string, now time.Time) ([]UploaderCount, error) {
+	query := `
+		SELECT q.uploader_id, COALESCE(u.username, 'Unknown'), COUNT(*) AS total
+		FROM (SELECT uploader_id FROM quotes ` + statsWhere + `) q
+		LEFT JOIN users u ON u.id = q.uploader_id
+		GROUP BY q.uploader_id
+		ORDER BY total DESC, q.uploader_id ASC
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, groupID, now, statsTopLimit)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get top uploaders")
+	}
+	defer rows.Close()
+
+	uploaders := []UploaderCount{}
+	for rows.Next() {
+		var u UploaderCount
+		if err := rows.Scan(&u.UserID, &u.Username, &u.Count); err != nil {
+			return nil, errors.DatabaseError("Failed to scan uploader data")
+		}
+		uploaders = append(uploaders, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through uploaders")
+	}
+
+	return uploaders, nil
+}
+
+// queryCounts runs a query returning label and count columns
+func (s *SQLiteStatsStore) queryCounts(query string, args ...interface{}) ([]StatCount, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get statistics")
+	}
+	defer rows.Close()
+
+	counts := []StatCount{}
+	for rows.Next() {
+		var c StatCount
+		if err := rows.Scan(&c.Label, &c.Count); err != nil {
+			return nil, errors.DatabaseError("Failed to scan statistics data")
+		}
+		counts = append(counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through statistics")
+	}
+
+	return counts, nil
+}
@@ -6,19 +6,57 @@ import (
 
 // SQLiteStore implements Store interface and combines all SQLite store implementations
 type SQLiteStore struct {
-	db         *sql.DB
-	userStore  *SQLiteUserStore
-	groupStore *SQLiteGroupStore
-	quoteStore *SQLiteQuoteStore
+	db                *sql.DB
+	userStore         *SQLiteUserStore
+	groupStore        *SQLiteGroupStore
+	quoteStore        *SQLiteQuoteStore
+	dailyStore        *SQLiteDailyQuoteStore
+	statsStore        *SQLiteStatsStore
+	wrappedStore      *SQLiteWrappedStore
+	shareStore        *SQLiteShareStore
+	quizStore         *SQLiteQuizStore
+	reactionStore     *SQLiteReactionStore
+	reminisceStore    *SQLiteReminisceStore
+	notificationStore *SQLiteNotificationStore
+	deviceStore       *SQLiteDeviceStore
+	digestStore       *SQLiteDigestStore
+	webhookStore      *SQLiteWebhookStore
+	activityStore     *SQLiteActivityStore
+	chatStore         *SQLiteChatStore
+	inboundStore      *SQLiteInboundStore
+	feedStore         *SQLiteFeedStore
+	refreshTokenStore *SQLiteRefreshTokenStore
 }
 
 // NewSQLiteStore creates a new SQLite store that implements the Store interface
 func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	statsStore := NewSQLiteStatsStore(db)
+	quoteStore := NewSQLiteQuoteStore(db)
+
+	// Cached statistics are stale as soon as a group's quotes change
+	quoteStore.OnChange(statsStore.Invalidate)
+
 	return &SQLiteStore{
-		db:         db,
-		userStore:  NewSQLiteUserStore(db),
-		groupStore: NewSQLiteGroupStore(db),
-		quoteStore: NewSQLiteQuoteStore(db),
+		db:                db,
+		userStore:         NewSQLiteUserStore(db),
+		groupStore:        NewSQLiteGroupStore(db),
+		quoteStore:        quoteStore,
+		dailyStore:        NewSQLiteDailyQuoteStore(db),
+		statsStore:        statsStore,
+		wrappedStore:      NewSQLiteWrappedStore(db),
+		shareStore:        NewSQLiteShareStore(db),
+		quizStore:         NewSQLiteQuizStore(db),
+		reactionStore:     NewSQLiteReactionStore(db),
+		reminisceStore:    NewSQLiteReminisceStore(db),
+		notificationStore: NewSQLiteNotificationStore(db),
+		deviceStore:       NewSQLiteDeviceStore(db),
+		digestStore:       NewSQLiteDigestStore(db),
+		webhookStore:      NewSQLiteWebhookStore(db),
+		activityStore:     NewSQLiteActivityStore(db),
+		chatStore:         NewSQLiteChatStore(db),
+		inboundStore:      NewSQLiteInboundStore(db, quoteStore),
+		feedStore:         NewSQLiteFeedStore(db),
+		refreshTokenStore: NewSQLiteRefreshTokenStore(db),
 	}
 }
 
@@ -37,6 +75,86 @@ func (s *SQLiteStore) Quotes() QuoteStore {
 	return s.quoteStore
 }
 
+// DailyQuotes returns the DailyQuoteStore implementation
+func (s *SQLiteStore) DailyQuotes() DailyQuoteStore {
+	return s.dailyStore
+}
+
+// Stats returns the StatsStore implementation
+func (s *SQLiteStore) Stats() StatsStore {
+	return s.statsStore
+}
+
+// Wrapped returns the WrappedStore implementation
+func (s *SQLiteStore) Wrapped() WrappedStore {
+	return s.wrappedStore
+}
+
+// Shares returns the ShareStore implementation
+func (s *SQLiteStore) Shares() ShareStore {
+	return s.shareStore
+}
+
+// Quiz returns the QuizStore implementation
+func (s *SQLiteStore) Quiz() QuizStore {
+	return s.quizStore
+}
+
+// Reactions returns the ReactionStore implementation
+func (s *SQLiteStore) Reactions() ReactionStore {
+	return s.reactionStore
+}
+
+// Reminisce returns the ReminisceStore implementation
+func (s *SQLiteStore) Reminisce() ReminisceStore {
+	return s.reminisceStore
+}
+
+// Notifications returns the NotificationStore implementation
+func (s *SQLiteStore) Notifications() NotificationStore {
+	return s.notificationStore
+}
+
+// Devices returns the DeviceStore implementation
+func (s *SQLiteStore) Devices() DeviceStore {
+	return s.deviceStore
+}
+
+// Digests returns the DigestStore implementation
+func (s *SQLiteStore) Digests() DigestStore {
+	return s.digestStore
+}
+
+// Webhooks returns the WebhookStore implementation
+func (s *SQLiteStore) Webhooks() WebhookStore {
+	return s.webhookStore
+}
+
+// Activity returns the ActivityStore implementation
+func (s *SQLiteStore) Activity() ActivityStore {
+	return s.activityStore
+}
+
+// Chat returns the ChatStore implementation
+func (s *SQLiteStore) Chat() ChatStore {
+	return s.chatStore
+}
+
+// Inbound returns the InboundStore implementation
+func (s *SQLiteStore) Inbound() InboundStore {
+	return s.inboundStore
+}
+
+// Feeds returns the FeedStore implementation
+func (s *SQLiteStore) Feeds() FeedStore {
+	return s.feedStore
+}
+
+// RefreshTokens returns the RefreshTokenStore implementation
+func (s *SQLiteStore) RefreshTokens() RefreshTokenStore {
+	return s.refreshTokenStore
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// newToken returns an unguessable URL-safe token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.InternalError("Failed to generate token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -29,6 +29,7 @@ type Group struct {
 	GroupID   string    `json:"group_id"`
 	Name      string    `json:"name"`
 	MemberID  string    `json:"member_id"`
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -45,20 +46,68 @@ type GroupStore interface {
 
 // Quote represents a quote in the system
 type Quote struct {
-	ID         string    `json:"id"`
-	Text       string    `json:"text"`
-	Author     string    `json:"author,omitempty"`
-	UploaderID string    `json:"uploader_id"`
-	GroupID    string    `json:"group_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string     `json:"id"`
+	Text       string     `json:"text"`
+	Author     string     `json:"author,omitempty"`
+	UploaderID string     `json:"uploader_id"`
+	GroupID    string     `json:"group_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UnlockAt   *time.Time `json:"unlock_at,omitempty"` // Time capsules stay sealed until then
+	ReplyTo    *Reply     `json:"reply_to,omitempty"`  // Message the quote answered, from chat imports
+
+	// OriginalUploader names who uploaded the quote where it was exported from, when that was
+	// someone other than the member who imported it
+	OriginalUploader string `json:"original_uploader,omitempty"`
+}
+
+// Reply is the message a quote was said in reply to
+type Reply struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+}
+
+// Sealed reports whether the quote is a time capsule that hasn't unlocked yet
+func (q *Quote) Sealed(now time.Time) bool {
+	return q.UnlockAt != nil && now.Before(*q.UnlockAt)
+}
+
+// SealedFor reports whether the quote's text is hidden from a user. Uploaders can always read their own capsules.
+func (q *Quote) SealedFor(userID string, now time.Time) bool {
+	return q.UploaderID != userID && q.Sealed(now)
 }
 
 // QuoteFilter represents the filtering options for quotes
 type QuoteFilter struct {
-	Author string
-	Page   int
-	Limit  int
+	Author    string
+	GroupID   string
+	VisibleTo string // Leave out time capsules this user can't open yet
+	Unlocked  bool   // Leave out every time capsule that hasn't unlocked, even to its uploader
+	HasAuthor bool   // Only quotes attributed to someone
+	Page      int
+	Limit     int
+}
+
+// Import records a batch of quotes created together so it can be undone
+type Import struct {
+	ID         string     `json:"id"`
+	GroupID    string     `json:"group_id"`
+	Source     string     `json:"source"` // File format the quotes came from, e.g. csv or json
+	QuoteCount int        `json:"quote_count"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UndoneAt   *time.Time `json:"undone_at,omitempty"`
+}
+
+// ImportPreview holds a parsed export until the user picks what to import from it
+type ImportPreview struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Source    string    `json:"source"`
+	Payload   []byte    `json:"-"` // JSON encoded parsed messages
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // QuoteStore handles all database operations for quotes
@@ -67,8 +116,497 @@ type QuoteStore interface {
 	GetByID(id string) (*Quote, error)
 	GetRandom(filter QuoteFilter) (*Quote, error)
 	List(filter QuoteFilter) ([]*Quote, error)
+	ListByGroup(groupID string) ([]*Quote, error)
+	EachByGroup(groupID string, fn func(*Quote) error) error
 	Update(quote *Quote) error
 	Delete(id string) error
+	ReleaseUnlocked(now time.Time) ([]*Quote, error)
+	AuthorCounts(groupID string) ([]StatCount, error)
+	Import(imp *Import, quotes []*Quote, reactions []*Reaction) error
+	GetImport(id string) (*Import, error)
+	ListImports(groupID string) ([]*Import, error)
+	UndoImport(id string) error
+	CreateImportPreview(preview *ImportPreview) error
+	GetImportPreview(id string) (*ImportPreview, error)
+	DeleteImportPreview(id string) error
+}
+
+// DailyQuote represents the quote picked for a group on a given day
+type DailyQuote struct {
+	GroupID   string    `json:"group_id"`
+	Day       string    `json:"day"` // YYYY-MM-DD in the group's timezone
+	QuoteID   string    `json:"quote_id"`
+	Cycle     int       `json:"cycle"`
+	CreatedAt time.Time `json:"created_at"`
+	Quote     *Quote    `json:"quote"`
+}
+
+// DailyQuoteStore handles all database operations for daily quotes
+type DailyQuoteStore interface {
+	GetOrPick(groupID, day string) (*DailyQuote, error)
+	ListHistory(groupID string, page, limit int) ([]*DailyQuote, error)
+}
+
+// StatCount is a labelled count used in statistics
+type StatCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// UploaderCount is the number of quotes uploaded by a user
+type UploaderCount struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Count    int    `json:"count"`
+}
+
+// GroupStats holds aggregated statistics about a group's quotes
+type GroupStats struct {
+	GroupID        string          `json:"group_id"`
+	TotalQuotes    int             `json:"total_quotes"`
+	AverageLength  float64         `json:"average_length"`
+	TopAuthors     []StatCount     `json:"top_authors"`
+	TopUploaders   []UploaderCount `json:"top_uploaders"`
+	QuotesPerMonth []StatCount     `json:"quotes_per_month"` // Labelled YYYY-MM, oldest first
+	Weekdays       []StatCount     `json:"weekdays"`         // Sunday to Saturday
+	BusiestWeekday string          `json:"busiest_weekday,omitempty"`
+	TopWords       []StatCount     `json:"top_words"`
+}
+
+// StatsStore handles aggregated statistics queries
+type StatsStore interface {
+	GetGroupStats(groupID string) (*GroupStats, error)
+	Invalidate(groupID string)
+}
+
+// WrappedFilter scopes a year-in-review report to a group or an uploader
+type WrappedFilter struct {
+	GroupID    string
+	UploaderID string
+	Year       int
+}
+
+// WrappedYear holds the totals for one year of a report
+type WrappedYear struct {
+	Year        int `json:"year"`
+	TotalQuotes int `json:"total_quotes"`
+	ActiveDays  int `json:"active_days"`
+}
+
+// WrappedStreak is the longest run of consecutive days with at least one quote
+type WrappedStreak struct {
+	Days  int    `json:"days"`
+	Start string `json:"start,omitempty"` // YYYY-MM-DD
+	End   string `json:"end,omitempty"`   // YYYY-MM-DD
+}
+
+// WrappedReactedQuote is the quote of a report period with the most reactions
+type WrappedReactedQuote struct {
+	Quote     *Quote `json:"quote"`
+	Reactions int    `json:"reactions"`
+}
+
+// WrappedReport is a year-in-review summary for a group or a user
+type WrappedReport struct {
+	Year           int                  `json:"year"`
+	GroupID        string               `json:"group_id,omitempty"`
+	UserID         string               `json:"user_id,omitempty"`
+	TotalQuotes    int                  `json:"total_quotes"`
+	ActiveDays     int                  `json:"active_days"`
+	TopAuthors     []StatCount          `json:"top_authors"`
+	TopUploaders   []UploaderCount      `json:"top_uploaders"`
+	BusiestMonth   *StatCount           `json:"busiest_month,omitempty"`
+	LongestStreak  WrappedStreak        `json:"longest_streak"`
+	FirstQuote     *Quote               `json:"first_quote,omitempty"`
+	LastQuote      *Quote               `json:"last_quote,omitempty"`
+	MostReacted    *WrappedReactedQuote `json:"most_reacted,omitempty"`
+	PreviousYear   WrappedYear          `json:"previous_year"`
+	QuoteChangePct *float64             `json:"quote_change_pct,omitempty"` // nil when the previous year had no quotes
+}
+
+// WrappedSnapshot is a stored, shareable copy of a report
+type WrappedSnapshot struct {
+	ID        string         `json:"id"`
+	Token     string         `json:"token"`
+	GroupID   string         `json:"group_id,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	Year      int            `json:"year"`
+	Report    *WrappedReport `json:"report"`
+	CreatedBy string         `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WrappedStore handles year-in-review reports and their snapshots
+type WrappedStore interface {
+	GetReport(filter WrappedFilter) (*WrappedReport, error)
+	CreateSnapshot(snapshot *WrappedSnapshot) error
+	GetSnapshot(token string) (*WrappedSnapshot, error)
+	DeleteSnapshot(id, createdBy string) error
+}
+
+// ShareLink is a public link to a single quote
+type ShareLink struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	QuoteID   string    `json:"quote_id"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Quote     *Quote    `json:"quote"`
+}
+
+// ShareStore handles public quote links
+type ShareStore interface {
+	Create(link *ShareLink) error
+	GetByToken(token string) (*ShareLink, error)
+	ListByCreator(userID string) ([]*ShareLink, error)
+	Delete(id, createdBy string) error
+}
+
+// QuizRound is one "Who said it?" question asked to a player
+type QuizRound struct {
+	ID         string     `json:"id"`
+	GroupID    string     `json:"group_id"`
+	UserID     string     `json:"user_id"`
+	QuoteID    string     `json:"quote_id"`
+	Choices    []string   `json:"choices"` // Authors offered, in display order
+	Answer     string     `json:"answer"`
+	Chosen     string     `json:"chosen,omitempty"`
+	Correct    bool       `json:"correct"`
+	Points     int        `json:"points"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+	Quote      *Quote     `json:"quote"`
+}
+
+// QuizScore is a player's running quiz totals in a group
+type QuizScore struct {
+	GroupID    string    `json:"group_id"`
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	Points     int       `json:"points"`
+	Answered   int       `json:"answered"`
+	Correct    int       `json:"correct"`
+	Streak     int       `json:"streak"` // Correct answers in a row, reset by a wrong one
+	BestStreak int       `json:"best_streak"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QuizStore handles all database operations for the quiz game
+type QuizStore interface {
+	CreateRound(round *QuizRound) error
+	GetRound(id string) (*QuizRound, error)
+	GetOpenRound(groupID, userID string) (*QuizRound, error)
+	AnswerRound(round *QuizRound, chosen string) (*QuizScore, error)
+	GetScore(groupID, userID string) (*QuizScore, error)
+	Leaderboard(groupID string, limit int) ([]*QuizScore, error)
+}
+
+// Reaction is a user's emoji reaction to a quote
+type Reaction struct {
+	QuoteID   string    `json:"quote_id"`
+	UserID    string    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionCount is how many people reacted to a quote with an emoji
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"` // Whether the requesting user is one of them
+}
+
+// ReactionStore handles all database operations for quote reactions
+type ReactionStore interface {
+	Add(quoteID, userID, emoji string) (bool, error)
+	Remove(quoteID, userID, emoji string) error
+	Summary(quoteID, userID string) ([]ReactionCount, error)
+	ListByGroup(groupID string) (map[string][]*Reaction, error)
+}
+
+// ReminisceItem is a quote in a user's spaced repetition queue
+type ReminisceItem struct {
+	UserID      string     `json:"user_id"`
+	QuoteID     string     `json:"quote_id"`
+	Loved       bool       `json:"loved"`
+	Ease        float64    `json:"ease"`
+	Interval    int        `json:"interval_days"`
+	Repetitions int        `json:"repetitions"`
+	DueAt       time.Time  `json:"due_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Quote       *Quote     `json:"quote"`
+}
+
+// ReminisceStore handles all database operations for reminisce queues
+type ReminisceStore interface {
+	Enroll(userID, quoteID string) error
+	SetLoved(userID, quoteID string, loved bool) error
+	Unenroll(userID, quoteID string) error
+	Get(userID, quoteID string) (*ReminisceItem, error)
+	Next(userID string, now time.Time) (*ReminisceItem, error)
+	NextDue(userID string) (*time.Time, error)
+	UpdateSchedule(item *ReminisceItem) error
+}
+
+// Notification is an entry in a user's inbox
+type Notification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	GroupID   string     `json:"group_id"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	QuoteID   string     `json:"quote_id,omitempty"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPreferences are what a member wants to be notified about in a group
+type NotificationPreferences struct {
+	UserID    string    `json:"user_id"`
+	GroupID   string    `json:"group_id"`
+	Quotes    bool      `json:"quotes"`    // New quotes
+	Mentions  bool      `json:"mentions"`  // Being @mentioned in a quote
+	Reactions bool      `json:"reactions"` // Reactions to the member's quotes
+	Capsules  bool      `json:"capsules"`  // Time capsules unlocking
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences turns every notification on
+func DefaultNotificationPreferences(userID, groupID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:    userID,
+		GroupID:   groupID,
+		Quotes:    true,
+		Mentions:  true,
+		Reactions: true,
+		Capsules:  true,
+	}
+}
+
+// NotificationStore handles all database operations for notifications
+type NotificationStore interface {
+	Create(n *Notification) error
+	List(userID string, unreadOnly bool, page, limit int) ([]*Notification, error)
+	UnreadCount(userID string) (int, error)
+	MarkRead(userID string, ids []string) (int, error)
+	MarkAllRead(userID string) (int, error)
+	GetPreferences(userID, groupID string) (*NotificationPreferences, error)
+	SavePreferences(prefs *NotificationPreferences) error
+}
+
+// Device is one of a user's devices registered for push notifications
+type Device struct {
+	UserID    string    `json:"user_id"`
+	DeviceID  string    `json:"device_id"`
+	Platform  string    `json:"platform"`
+	Token     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DeviceStore handles all database operations for push devices
+type DeviceStore interface {
+	Register(device *Device) error
+	ListByUser(userID string) ([]*Device, error)
+	Delete(userID, deviceID string) error
+	DeleteToken(token string) error
+}
+
+// DigestRecipient is a user due a weekly digest
+type DigestRecipient struct {
+	User       *User
+	LastSentAt *time.Time
+}
+
+// DigestQuote is a quote featured in a digest
+type DigestQuote struct {
+	Quote     *Quote
+	Uploader  string
+	Reactions int
+}
+
+// GroupDigest is what happened in one group since the last digest
+type GroupDigest struct {
+	GroupID     string
+	Name        string
+	NewQuotes   []*DigestQuote
+	NewCount    int          // All new quotes, NewQuotes may be cut short
+	MostReacted *DigestQuote // Quote with the most reactions in the period, nil if nobody reacted
+	OnThisDay   *DigestQuote // Quote from this day in an earlier year
+}
+
+// Empty reports whether the digest has nothing worth sending
+func (d *GroupDigest) Empty() bool {
+	return d.NewCount == 0 && d.MostReacted == nil && d.OnThisDay == nil
+}
+
+// DigestStore handles all database operations for email digests
+type DigestStore interface {
+	Recipients(sentBefore time.Time) ([]*DigestRecipient, error)
+	GroupDigest(groupID string, since, now time.Time, limit int) (*GroupDigest, error)
+	UnsubscribeToken(userID string) (string, error)
+	Unsubscribe(token string) error
+	SetSubscribed(userID string, subscribed bool) error
+	Subscribed(userID string) (bool, error)
+	MarkSent(userID string, at time.Time) error
+}
+
+// Webhook is an outgoing webhook a group subscribed to its events
+type Webhook struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"` // Empty for every event
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the webhook is subscribed to an event
+func (w *Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one payload sent, or waiting to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookStore handles all database operations for webhooks and their deliveries
+type WebhookStore interface {
+	Create(webhook *Webhook) error
+	GetByID(id string) (*Webhook, error)
+	ListByGroup(groupID string) ([]*Webhook, error)
+	Delete(id string) error
+	CreateDelivery(delivery *WebhookDelivery) error
+	DueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error)
+	UpdateDelivery(delivery *WebhookDelivery) error
+	ListDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error)
+}
+
+// Activity is an entry in a group's activity log
+type Activity struct {
+	ID        int64     `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Type      string    `json:"type"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	QuoteID   string    `json:"quote_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Emoji     string    `json:"emoji,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActivityStore handles all database operations for group activity. Activity is only ever appended.
+type ActivityStore interface {
+	Append(activity *Activity) error
+	List(groupID string, beforeID int64, limit int) ([]*Activity, error)
+}
+
+// ChatAccount links an account on a chat platform, e.g. Slack, to a Reminiscer user
+type ChatAccount struct {
+	Platform   string    `json:"platform"`
+	TeamID     string    `json:"team_id,omitempty"` // Workspace on platforms that have them
+	ExternalID string    `json:"external_id"`
+	UserID     string    `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ChatChannel connects a chat platform channel to the group its quotes are added to
+type ChatChannel struct {
+	Platform  string    `json:"platform"`
+	TeamID    string    `json:"team_id,omitempty"`
+	ChannelID string    `json:"channel_id"`
+	GroupID   string    `json:"group_id"`
+	LinkedBy  string    `json:"linked_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatLinkCode is a one-time code a user sends from a chat platform to link their account
+type ChatLinkCode struct {
+	Code      string    `json:"code"`
+	UserID    string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ChatStore handles all database operations for chat platform integrations
+type ChatStore interface {
+	CreateLinkCode(userID string, ttl time.Duration) (*ChatLinkCode, error)
+	RedeemLinkCode(code string, account *ChatAccount) error
+	GetAccount(platform, teamID, externalID string) (*ChatAccount, error)
+	ListAccounts(userID string) ([]*ChatAccount, error)
+	DeleteAccounts(userID, platform string) error
+	SetChannel(channel *ChatChannel) error
+	GetChannel(platform, teamID, channelID string) (*ChatChannel, error)
+}
+
+// InboundStore handles all database operations for receiving quotes by email
+type InboundStore interface {
+	AddressToken(groupID string) (string, error)
+	RotateAddressToken(groupID string) (string, error)
+	GroupForToken(token string) (string, error)
+	CreateQuote(messageID string, quote *Quote) (string, error)
+}
+
+// FeedToken grants a feed reader access to a group's quotes on behalf of the member who made it
+type FeedToken struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	GroupID   string    `json:"group_id"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FeedStore handles private feed tokens
+type FeedStore interface {
+	Create(feed *FeedToken) error
+	GetByToken(token string) (*FeedToken, error)
+	ListByCreator(userID string) ([]*FeedToken, error)
+	Delete(id, createdBy string) error
+}
+
+// RefreshToken is a long lived credential traded for new access tokens. The token itself is only
+// handed out when it's made, the store keeps a hash of it.
+type RefreshToken struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	FamilyID  string    `json:"family_id"` // Shared by every token rotated from the same login
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RefreshTokenStore handles refresh tokens
+type RefreshTokenStore interface {
+	Create(userID string, ttl time.Duration) (string, *RefreshToken, error)
+	Rotate(token string, ttl time.Duration) (string, *RefreshToken, error)
+	RevokeFamily(token string) error
 }
 
 // Store combines all storage interfaces
@@ -76,4 +614,20 @@ type Store interface {
 	Users() UserStore
 	Groups() GroupStore
 	Quotes() QuoteStore
+	DailyQuotes() DailyQuoteStore
+	Stats() StatsStore
+	Wrapped() WrappedStore
+	Shares() ShareStore
+	Quiz() QuizStore
+	Reactions() ReactionStore
+	Reminisce() ReminisceStore
+	Notifications() NotificationStore
+	Devices() DeviceStore
+	Digests() DigestStore
+	Webhooks() WebhookStore
+	Activity() ActivityStore
+	Chat() ChatStore
+	Inbound() InboundStore
+	Feeds() FeedStore
+	RefreshTokens() RefreshTokenStore
 }
@@ -0,0 +1,297 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// SQLiteWebhookStore implements WebhookStore interface
+type SQLiteWebhookStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteWebhookStore creates a new SQLite webhook store
+func NewSQLiteWebhookStore(db *sql.DB) *SQLiteWebhookStore {
+	return &SQLiteWebhookStore{db: db}
+}
+
+// Create adds a webhook with a fresh signing secret
+func (s *SQLiteWebhookStore) Create(webhook *Webhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	secret, err := newToken()
+	if err != nil {
+		return err
+	}
+	webhook.Secret = secret
+	webhook.CreatedAt = time.Now()
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return errors.InternalError("Failed to encode webhook events")
+	}
+
+	query := `
+		INSERT INTO webhooks (id, group_id, url, secret, events, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		webhook.ID,
+		webhook.GroupID,
+		webhook.URL,
+		webhook.Secret,
+		string(events),
+		webhook.CreatedBy,
+		webhook.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to create webhook")
+	}
+
+	return nil
+}
+
+const webhookSelect = `
+	SELECT id, group_id, url, secret, events, created_by, created_at
+	FROM webhooks
+`
+
+// GetByID retrieves a webhook by ID
+func (s *SQLiteWebhookStore) GetByID(id string) (*Webhook, error) {
+	webhook, err := scanWebhook(s.db.QueryRow(webhookSelect+"WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Webhook not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get webhook")
+	}
+
+	return webhook, nil
+}
+
+// ListByGroup retrieves a group's webhooks, oldest first
+func (s *SQLiteWebhookStore) ListByGroup(groupID string) ([]*Webhook, error) {
+	rows, err := s.db.Query(webhookSelect+"WHERE group_id = ? ORDER BY created_at ASC", groupID)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list webhooks")
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan webhook data")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through webhooks")
+	}
+
+	return webhooks, nil
+}
+
+// Delete removes a webhook and its delivery log
+func (s *SQLiteWebhookStore) Delete(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.DatabaseError("Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return errors.DatabaseError("Failed to delete webhook deliveries")
+	}
+
+	result, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete webhook")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Webhook not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError("Failed to commit webhook deletion")
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a payload for a webhook
+func (s *SQLiteWebhookStore) CreateDelivery(d *WebhookDelivery) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	if d.Status == "" {
+		d.Status = DeliveryPending
+	}
+	d.CreatedAt = time.Now()
+	if d.NextAttemptAt == nil && d.Status == DeliveryPending {
+		next := d.CreatedAt
+		d.NextAttemptAt = &next
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		d.ID,
+		d.WebhookID,
+		d.Event,
+		d.Payload,
+		d.Status,
+		utcTime(d.NextAttemptAt),
+		d.CreatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to queue webhook delivery")
+	}
+
+	return nil
+}
+
+const deliverySelect = `
+	SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, error,
+		created_at, delivered_at
+	FROM webhook_deliveries
+`
+
+// DueDeliveries retrieves pending deliveries whose next attempt is due, oldest first
+func (s *SQLiteWebhookStore) DueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	return s.listDeliveries(deliverySelect+`
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`, DeliveryPending, now.UTC(), limit)
+}
+
+// ListDeliveries retrieves a webhook's most recent deliveries, newest first
+func (s *SQLiteWebhookStore) ListDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error) {
+	return s.listDeliveries(deliverySelect+`
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, webhookID, limit)
+}
+
+// UpdateDelivery records the outcome of an attempt
+func (s *SQLiteWebhookStore) UpdateDelivery(d *WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, error = ?, delivered_at = ?
+		WHERE id = ?
+	`
+
+	var responseStatus interface{}
+	if d.ResponseStatus != 0 {
+		responseStatus = d.ResponseStatus
+	}
+
+	_, err := s.db.Exec(query,
+		d.Status,
+		d.Attempts,
+		utcTime(d.NextAttemptAt),
+		responseStatus,
+		nullString(d.Error),
+		d.DeliveredAt,
+		d.ID,
+	)
+	if err != nil {
+		return errors.DatabaseError("Failed to update webhook delivery")
+	}
+
+	return nil
+}
+
+func (s *SQLiteWebhookStore) listDeliveries(query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to list webhook deliveries")
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		var nextAttemptAt, deliveredAt sql.NullTime
+		var responseStatus sql.NullInt64
+		var deliveryError sql.NullString
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&nextAttemptAt,
+			&responseStatus,
+			&deliveryError,
+			&d.CreatedAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			return nil, errors.DatabaseError("Failed to scan webhook delivery")
+		}
+
+		if nextAttemptAt.Valid {
+			d.NextAttemptAt = &nextAttemptAt.Time
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		d.ResponseStatus = int(responseStatus.Int64)
+		d.Error = deliveryError.String
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.DatabaseError("Error iterating through webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhook reads a row selected with webhookSelect
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var webhook Webhook
+	var events string
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.GroupID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.CreatedBy,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(events), &webhook.Events); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
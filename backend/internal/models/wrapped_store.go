@@ -0,0 +1,393 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/errors"
+)
+
+// wrappedTopLimit is the number of people listed in each part of a report
+const wrappedTopLimit = 5
+
+// SQLiteWrappedStore implements WrappedStore interface
+type SQLiteWrappedStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteWrappedStore creates a new SQLite wrapped store
+func NewSQLiteWrappedStore(db *sql.DB) *SQLiteWrappedStore {
+	return &SQLiteWrappedStore{db: db}
+}
+
+// GetReport computes a year-in-review report for a group or an uploader
+func (s *SQLiteWrappedStore) GetReport(filter WrappedFilter) (*WrappedReport, error) {
+	if filter.GroupID == "" && filter.UploaderID == "" {
+		return nil, errors.InvalidInput("Report needs a group or a user")
+	}
+
+	report := &WrappedReport{
+		Year:         filter.Year,
+		GroupID:      filter.GroupID,
+		UserID:       filter.UploaderID,
+		TopAuthors:   []StatCount{},
+		TopUploaders: []UploaderCount{},
+	}
+
+	current, err := s.yearTotals(filter, filter.Year)
+	if err != nil {
+		return nil, err
+	}
+	report.TotalQuotes = current.TotalQuotes
+	report.ActiveDays = current.ActiveDays
+
+	report.PreviousYear, err = s.yearTotals(filter, filter.Year-1)
+	if err != nil {
+		return nil, err
+	}
+	if report.PreviousYear.TotalQuotes > 0 {
+		change := float64(report.TotalQuotes-report.PreviousYear.TotalQuotes) / float64(report.PreviousYear.TotalQuotes) * 100
+		report.QuoteChangePct = &change
+	}
+
+	if report.TotalQuotes == 0 {
+		return report, nil
+	}
+
+	where, args := wrappedWhere(filter, filter.Year)
+
+	rows, err := s.db.Query(`
+		SELECT q.author, COUNT(*) AS total
+		FROM quotes q
+		`+where+` AND q.author IS NOT NULL AND q.author <> ''
+		GROUP BY q.author
+		ORDER BY total DESC, q.author ASC
+		LIMIT ?
+	`, append(args, wrappedTopLimit)...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get top authors")
+	}
+	for rows.Next() {
+		var c StatCount
+		if err := rows.Scan(&c.Label, &c.Count); err != nil {
+			rows.Close()
+			return nil, errors.DatabaseError("Failed to scan author data")
+		}
+		report.TopAuthors = append(report.TopAuthors, c)
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`
+		SELECT q.uploader_id, COALESCE(u.username, 'Unknown'), COUNT(*) AS total
+		FROM quotes q
+		LEFT JOIN users u ON u.id = q.uploader_id
+		`+where+`
+		GROUP BY q.uploader_id
+		ORDER BY total DESC, q.uploader_id ASC
+		LIMIT ?
+	`, append(args, wrappedTopLimit)...)
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get top uploaders")
+	}
+	for rows.Next() {
+		var u UploaderCount
+		if err := rows.Scan(&u.UserID, &u.Username, &u.Count); err != nil {
+			rows.Close()
+			return nil, errors.DatabaseError("Failed to scan uploader data")
+		}
+		report.TopUploaders = append(report.TopUploaders, u)
+	}
+	rows.Close()
+
+	var month StatCount
+	err = s.db.QueryRow(`
+		SELECT strftime('%Y-%m', q.created_at) AS month, COUNT(*) AS total
+		FROM quotes q
+		`+where+`
+		GROUP BY month
+		ORDER BY total DESC, month ASC
+		LIMIT 1
+	`, args...).Scan(&month.Label, &month.Count)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.DatabaseError("Failed to get busiest month")
+	}
+	if err == nil {
+		report.BusiestMonth = &month
+	}
+
+	report.FirstQuote, err = s.edgeQuote(where, args, "ASC")
+	if err != nil {
+		return nil, err
+	}
+	report.LastQuote, err = s.edgeQuote(where, args, "DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	report.MostReacted, err = s.mostReacted(where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	report.LongestStreak, err = s.longestStreak(where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// CreateSnapshot stores a copy of a report under a new share token
+func (s *SQLiteWrappedStore) CreateSnapshot(snapshot *WrappedSnapshot) error {
+	if snapshot.ID == "" {
+		snapshot.ID = uuid.New().String()
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	snapshot.Token = token
+	snapshot.CreatedAt = time.Now()
+
+	payload, err := json.Marshal(snapshot.Report)
+	if err != nil {
+		return errors.InternalError("Failed to encode report")
+	}
+
+	query := `
+		INSERT INTO wrapped_snapshots (id, token, group_id, user_id, year, payload, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		snapshot.ID,
+		snapshot.Token,
+		nullString(snapshot.GroupID),
+		nullString(snapshot.UserID),
+		snapshot.Year,
+		string(payload),
+		snapshot.CreatedBy,
+		snapshot.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.DatabaseError("Failed to create snapshot")
+	}
+
+	return nil
+}
+
+// GetSnapshot retrieves a snapshot by its share token
+func (s *SQLiteWrappedStore) GetSnapshot(token string) (*WrappedSnapshot, error) {
+	var snapshot WrappedSnapshot
+	var groupID, userID sql.NullString
+	var payload string
+
+	query := `
+		SELECT id, token, group_id, user_id, year, payload, created_by, created_at
+		FROM wrapped_snapshots
+		WHERE token = ?
+	`
+
+	err := s.db.QueryRow(query, token).Scan(
+		&snapshot.ID,
+		&snapshot.Token,
+		&groupID,
+		&userID,
+		&snapshot.Year,
+		&payload,
+		&snapshot.CreatedBy,
+		&snapshot.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound("Snapshot not found")
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get snapshot")
+	}
+
+	snapshot.GroupID = groupID.String
+	snapshot.UserID = userID.String
+
+	if err := json.Unmarshal([]byte(payload), &snapshot.Report); err != nil {
+		return nil, errors.InternalError("Failed to decode report")
+	}
+
+	return &snapshot, nil
+}
+
+// DeleteSnapshot removes a snapshot created by the given user
+func (s *SQLiteWrappedStore) DeleteSnapshot(id, createdBy string) error {
+	query := `DELETE FROM wrapped_snapshots WHERE id = ? AND created_by = ?`
+
+	result, err := s.db.Exec(query, id, createdBy)
+	if err != nil {
+		return errors.DatabaseError("Failed to delete snapshot")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError("Failed to check delete result")
+	}
+
+	if rows == 0 {
+		return errors.NotFound("Snapshot not found")
+	}
+
+	return nil
+}
+
+// yearTotals counts the quotes and active days in a year
+func (s *SQLiteWrappedStore) yearTotals(filter WrappedFilter, year int) (WrappedYear, error) {
+	totals := WrappedYear{Year: year}
+	where, args := wrappedWhere(filter, year)
+
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT date(q.created_at))
+		FROM quotes q
+		`+where, args...).Scan(&totals.TotalQuotes, &totals.ActiveDays)
+	if err != nil {
+		return totals, errors.DatabaseError("Failed to get yearly totals")
+	}
+
+	return totals, nil
+}
+
+// edgeQuote returns the first or last quote of the report period
+func (s *SQLiteWrappedStore) edgeQuote(where string, args []interface{}, order string) (*Quote, error) {
+	var quote Quote
+	err := s.db.QueryRow(`
+		SELECT q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at
+		FROM quotes q
+		`+where+`
+		ORDER BY q.created_at `+order+`
+		LIMIT 1
+	`, args...).Scan(
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get quote")
+	}
+
+	return &quote, nil
+}
+
+// mostReacted returns the quote of the report period with the most reactions. Ties go to the
+// earlier quote.
+func (s *SQLiteWrappedStore) mostReacted(where string, args []interface{}) (*WrappedReactedQuote, error) {
+	var quote Quote
+	var reactions int
+	err := s.db.QueryRow(`
+		SELECT q.id, q.text, q.author, q.uploader_id, q.group_id, q.created_at, q.updated_at, COUNT(*) AS total
+		FROM quotes q
+		JOIN quote_reactions r ON r.quote_id = q.id
+		`+where+`
+		GROUP BY q.id
+		ORDER BY total DESC, q.created_at ASC
+		LIMIT 1
+	`, args...).Scan(
+		&quote.ID,
+		&quote.Text,
+		&quote.Author,
+		&quote.UploaderID,
+		&quote.GroupID,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+		&reactions,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.DatabaseError("Failed to get most reacted quote")
+	}
+
+	return &WrappedReactedQuote{Quote: &quote, Reactions: reactions}, nil
+}
+
+// longestStreak finds the longest run of consecutive days with quotes
+func (s *SQLiteWrappedStore) longestStreak(where string, args []interface{}) (WrappedStreak, error) {
+	var best WrappedStreak
+
+	rows, err := s.db.Query(`
+		SELECT DISTINCT date(q.created_at) AS day
+		FROM quotes q
+		`+where+`
+		ORDER BY day ASC
+	`, args...)
+	if err != nil {
+		return best, errors.DatabaseError("Failed to get active days")
+	}
+	defer rows.Close()
+
+	var current WrappedStreak
+	var previous time.Time
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return best, errors.DatabaseError("Failed to scan active day")
+		}
+
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+
+		if current.Days > 0 && date.Sub(previous) == 24*time.Hour {
+			current.Days++
+			current.End = day
+		} else {
+			current = WrappedStreak{Days: 1, Start: day, End: day}
+		}
+		previous = date
+
+		if current.Days > best.Days {
+			best = current
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return best, errors.DatabaseError("Error iterating through active days")
+	}
+
+	return best, nil
+}
+
+// wrappedWhere builds the WHERE clause selecting a scope's quotes in a year. Sealed time capsules
+// are left out so nothing in the report gives them away.
+func wrappedWhere(filter WrappedFilter, year int) (string, []interface{}) {
+	where := "WHERE strftime('%Y', q.created_at) = ? AND (q.unlock_at IS NULL OR q.unlock_at <= ?)"
+	args := []interface{}{fmt.Sprintf("%04d", year), time.Now().UTC()}
+
+	if filter.GroupID != "" {
+		where += " AND q.group_id = ?"
+		args = append(args, filter.GroupID)
+	}
+	if filter.UploaderID != "" {
+		where += " AND q.uploader_id = ?"
+		args = append(args, filter.UploaderID)
+	}
+
+	return where, args
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
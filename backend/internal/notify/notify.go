@@ -0,0 +1,157 @@
+// Package notify turns group events into entries in members' notification inboxes
+package notify
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// mentionPattern matches an @handle that isn't part of a word or email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+)`)
+
+// ParseMentions returns the distinct handles @mentioned in text, lower-cased, in the order they first appear
+func ParseMentions(text string) []string {
+	seen := make(map[string]bool)
+	var handles []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.ToLower(m[1])
+		if seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// Notifier writes notifications for events, respecting each member's preferences for the group
+type Notifier struct {
+	store    models.Store
+	onNotify []func(*models.Notification)
+}
+
+// New creates a notifier backed by store
+func New(store models.Store) *Notifier {
+	return &Notifier{store: store}
+}
+
+// OnNotify registers a callback run for every notification written, e.g. to push it to devices
+func (n *Notifier) OnNotify(fn func(*models.Notification)) {
+	n.onNotify = append(n.onNotify, fn)
+}
+
+// Handle notifies everyone an event concerns. It is meant to be subscribed to an events.Bus,
+// so failures are logged rather than returned to the request that raised the event.
+func (n *Notifier) Handle(e events.Event) {
+	if err := n.handle(e); err != nil {
+		log.Printf("Failed to notify %s in group %s: %v", e.Type, e.GroupID, err)
+	}
+}
+
+func (n *Notifier) handle(e events.Event) error {
+	groups, err := n.store.Groups().GetByGroupID(e.GroupID)
+	if err != nil {
+		return err
+	}
+	group := groups[0].Name
+	actor := n.username(e.ActorID)
+
+	switch e.Type {
+	case events.QuoteCreated:
+		skip := map[string]bool{e.ActorID: true}
+		for _, id := range e.Mentioned {
+			skip[id] = true
+		}
+		message := fmt.Sprintf("%s added a quote to %s", actor, group)
+		for _, g := range groups {
+			if skip[g.MemberID] {
+				continue
+			}
+			if err := n.notify(g.MemberID, e, message, wantsQuotes); err != nil {
+				return err
+			}
+		}
+
+	case events.Mention:
+		if e.UserID == e.ActorID {
+			return nil
+		}
+		return n.notify(e.UserID, e, fmt.Sprintf("%s mentioned you in %s", actor, group), wantsMentions)
+
+	case events.ReactionAdded:
+		quote, err := n.store.Quotes().GetByID(e.QuoteID)
+		if err != nil {
+			return err
+		}
+		if quote.UploaderID == e.ActorID {
+			return nil
+		}
+		return n.notify(quote.UploaderID, e, fmt.Sprintf("%s reacted %s to your quote in %s", actor, e.Emoji, group), wantsReactions)
+
+	case events.GroupInvite:
+		return n.notify(e.UserID, e, fmt.Sprintf("%s added you to %s", actor, group), nil)
+
+	case events.CapsuleUnlocked:
+		message := fmt.Sprintf("A time capsule opened in %s", group)
+		for _, g := range groups {
+			if err := n.notify(g.MemberID, e, message, wantsCapsules); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// notify writes one notification unless the user turned that kind off for the group.
+// A nil wants means the notification can't be turned off.
+func (n *Notifier) notify(userID string, e events.Event, message string, wants func(*models.NotificationPreferences) bool) error {
+	if wants != nil {
+		prefs, err := n.store.Notifications().GetPreferences(userID, e.GroupID)
+		if err != nil {
+			return err
+		}
+		if !wants(prefs) {
+			return nil
+		}
+	}
+
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    string(e.Type),
+		GroupID: e.GroupID,
+		ActorID: e.ActorID,
+		QuoteID: e.QuoteID,
+		Message: message,
+	}
+	if err := n.store.Notifications().Create(notification); err != nil {
+		return err
+	}
+
+	for _, fn := range n.onNotify {
+		fn(notification)
+	}
+	return nil
+}
+
+// username resolves the user who caused an event
+func (n *Notifier) username(userID string) string {
+	if userID == "" {
+		return "Someone"
+	}
+	u, err := n.store.Users().GetByID(userID)
+	if err != nil || u == nil {
+		return "Someone"
+	}
+	return u.Username
+}
+
+func wantsQuotes(p *models.NotificationPreferences) bool    { return p.Quotes }
+func wantsMentions(p *models.NotificationPreferences) bool  { return p.Mentions }
+func wantsReactions(p *models.NotificationPreferences) bool { return p.Reactions }
+func wantsCapsules(p *models.NotificationPreferences) bool  { return p.Capsules }
@@ -0,0 +1,63 @@
+// Package push delivers inbox notifications to users' devices
+package push
+
+import (
+	"context"
+	"log"
+
+	"github.com/jamoowen/reminiscer/internal/apns"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// Pusher sends notifications to every device a user has registered
+type Pusher struct {
+	store  models.Store
+	client *apns.Client
+}
+
+// New creates a pusher sending through client
+func New(store models.Store, client *apns.Client) *Pusher {
+	return &Pusher{store: store, client: client}
+}
+
+// Push sends a notification to the user's devices, badged with their unread count.
+// Tokens APNs says are dead are forgotten so they aren't tried again.
+func (p *Pusher) Push(ctx context.Context, n *models.Notification) {
+	devices, err := p.store.Devices().ListByUser(n.UserID)
+	if err != nil {
+		log.Printf("Failed to list devices for user %s: %v", n.UserID, err)
+		return
+	}
+	if len(devices) == 0 {
+		return
+	}
+
+	push := &apns.Notification{
+		Alert: n.Message,
+		Data: map[string]string{
+			"notification_id": n.ID,
+			"type":            n.Type,
+			"group_id":        n.GroupID,
+		},
+	}
+	if n.QuoteID != "" {
+		push.Data["quote_id"] = n.QuoteID
+	}
+	if unread, err := p.store.Notifications().UnreadCount(n.UserID); err == nil {
+		push.Badge = &unread
+	}
+
+	for _, d := range devices {
+		err := p.client.Send(ctx, d.Token, push)
+		if err == nil {
+			continue
+		}
+		if apns.IsInvalidToken(err) {
+			if err := p.store.Devices().DeleteToken(d.Token); err != nil {
+				log.Printf("Failed to forget dead push token for device %s: %v", d.DeviceID, err)
+			}
+			continue
+		}
+		log.Printf("Failed to push notification %s to device %s: %v", n.ID, d.DeviceID, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package quiz builds the choices for "Who said it?" rounds
+package quiz
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// MaxChoices is how many authors a round offers, counting the real one
+const MaxChoices = 4
+
+// ErrNotEnoughAuthors is returned when a group has nobody to use as a decoy
+var ErrNotEnoughAuthors = errors.New("quotes from at least two authors are needed to play")
+
+// Choices returns the real author and up to MaxChoices-1 decoys in random order.
+// Decoys come from the group's other authors, drawn in proportion to how often
+// each is quoted so the people the group quotes most are the likeliest suspects.
+// Authors that only differ from the answer by case are never used as decoys.
+func Choices(answer string, authors []models.StatCount) ([]string, error) {
+	seen := map[string]bool{strings.ToLower(answer): true}
+	var pool []models.StatCount
+	for _, a := range authors {
+		key := strings.ToLower(strings.TrimSpace(a.Label))
+		if key == "" || seen[key] || a.Count < 1 {
+			continue
+		}
+		seen[key] = true
+		pool = append(pool, a)
+	}
+	if len(pool) == 0 {
+		return nil, ErrNotEnoughAuthors
+	}
+
+	choices := []string{answer}
+	for len(choices) < MaxChoices && len(pool) > 0 {
+		i := weightedPick(pool)
+		choices = append(choices, pool[i].Label)
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+
+	rand.Shuffle(len(choices), func(i, j int) {
+		choices[i], choices[j] = choices[j], choices[i]
+	})
+	return choices, nil
+}
+
+// weightedPick returns the index of an author chosen with probability proportional to their count
+func weightedPick(pool []models.StatCount) int {
+	total := 0
+	for _, a := range pool {
+		total += a.Count
+	}
+
+	n := rand.Intn(total)
+	for i, a := range pool {
+		if n < a.Count {
+			return i
+		}
+		n -= a.Count
+	}
+	return len(pool) - 1
+}
@@ -0,0 +1,291 @@
+// Package realtime fans group events out to connected clients and keeps a short
+// history so clients that drop can resume where they left off
+package realtime
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+const (
+	// DefaultHistory is how many recent messages are kept for clients resuming a stream
+	DefaultHistory = 1024
+
+	// subscriptionBuffer is how many messages can wait for a slow client before it is dropped
+	subscriptionBuffer = 64
+)
+
+// Message names streamed to clients
+const (
+	MessageQuoteCreated    = "quote.created"
+	MessageQuoteUpdated    = "quote.updated"
+	MessageReactionAdded   = "reaction.added"
+	MessageCapsuleUnlocked = "capsule.unlocked"
+)
+
+// messageNames maps the events clients are streamed to their message names
+var messageNames = map[events.Type]string{
+	events.QuoteCreated:    MessageQuoteCreated,
+	events.QuoteUpdated:    MessageQuoteUpdated,
+	events.ReactionAdded:   MessageReactionAdded,
+	events.CapsuleUnlocked: MessageCapsuleUnlocked,
+}
+
+// Message is an event ready to stream. The quote is loaded once when the event is published,
+// each stream decides for itself what its user may see of it.
+type Message struct {
+	ID       uint64
+	Name     string
+	Event    events.Event
+	Quote    *models.Quote
+	Uploader string
+	Actor    string
+}
+
+// Subscription receives the messages of a user's groups
+type Subscription struct {
+	C      <-chan *Message
+	c      chan *Message
+	userID string
+	groups map[string]bool
+	fixed  bool // Receives only the groups it was started with, not ones the user joins later
+	closed bool
+}
+
+// Hub delivers messages to subscriptions and remembers recent ones
+type Hub struct {
+	store models.Store
+
+	mu      sync.RWMutex
+	nextID  uint64
+	history []*Message // Ring buffer of the most recent messages
+	start   int        // Index of the oldest message in history
+	size    int
+	byGroup map[string]map[*Subscription]bool
+	byUser  map[string]map[*Subscription]bool
+	closed  bool
+}
+
+// NewHub creates a hub remembering the last historySize messages
+func NewHub(store models.Store, historySize int) *Hub {
+	if historySize < 1 {
+		historySize = DefaultHistory
+	}
+	return &Hub{
+		store: store,
+		// IDs carry on from the clock across restarts, so an ID from before a restart
+		// is recognisably older than anything the new process remembers
+		nextID:  uint64(time.Now().UnixMilli()) * 1000,
+		history: make([]*Message, historySize),
+		byGroup: make(map[string]map[*Subscription]bool),
+		byUser:  make(map[string]map[*Subscription]bool),
+	}
+}
+
+// Handle turns an event into a message for the group's subscribers. It is meant to be subscribed to an events.Bus.
+func (h *Hub) Handle(e events.Event) {
+	if e.Type == events.GroupInvite {
+		h.join(e.UserID, e.GroupID)
+		return
+	}
+
+	name, ok := messageNames[e.Type]
+	if !ok {
+		return
+	}
+
+	msg := &Message{Name: name, Event: e}
+	if e.QuoteID != "" {
+		quote, err := h.store.Quotes().GetByID(e.QuoteID)
+		if err != nil {
+			log.Printf("Failed to load quote %s for %s: %v", e.QuoteID, name, err)
+			return
+		}
+		msg.Quote = quote
+		msg.Uploader = h.username(quote.UploaderID)
+	}
+	if e.ActorID != "" {
+		msg.Actor = h.username(e.ActorID)
+	}
+
+	h.Publish(msg)
+}
+
+// Publish numbers a message, remembers it and delivers it to its group's subscribers.
+// Subscribers too slow to keep up are dropped, they can reconnect and resume.
+func (h *Hub) Publish(msg *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	msg.ID = h.nextID
+
+	if h.size < len(h.history) {
+		h.history[(h.start+h.size)%len(h.history)] = msg
+		h.size++
+	} else {
+		h.history[h.start] = msg
+		h.start = (h.start + 1) % len(h.history)
+	}
+
+	for sub := range h.byGroup[msg.Event.GroupID] {
+		select {
+		case sub.c <- msg:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts delivering messages from the given groups to a user, and from any group they
+// join while subscribed
+func (h *Hub) Subscribe(userID string, groupIDs []string) *Subscription {
+	return h.subscribe(userID, groupIDs, false)
+}
+
+// SubscribeGroup starts delivering messages from a single group to a user
+func (h *Hub) SubscribeGroup(userID, groupID string) *Subscription {
+	return h.subscribe(userID, []string{groupID}, true)
+}
+
+// subscribe registers a subscription, fixed to its groups or following the user's joins
+func (h *Hub) subscribe(userID string, groupIDs []string, fixed bool) *Subscription {
+	c := make(chan *Message, subscriptionBuffer)
+	sub := &Subscription{C: c, c: c, userID: userID, groups: make(map[string]bool), fixed: fixed}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(c)
+		sub.closed = true
+		return sub
+	}
+
+	for _, id := range groupIDs {
+		sub.groups[id] = true
+		h.add(h.byGroup, id, sub)
+	}
+	h.add(h.byUser, userID, sub)
+
+	return sub
+}
+
+// Unsubscribe stops a subscription and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Close ends every subscription so streams finish when the server shuts down
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.byUser {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// Since returns the remembered messages after lastID from the given groups. ok is false when
+// messages after lastID have been forgotten, or lastID is not one the hub handed out, so the
+// client has to reload instead of resuming.
+func (h *Hub) Since(lastID uint64, groupIDs []string) (messages []*Message, ok bool) {
+	groups := make(map[string]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		groups[id] = true
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if lastID > h.nextID {
+		return nil, false
+	}
+
+	oldest := h.nextID - uint64(h.size) + 1
+	if lastID+1 < oldest {
+		return nil, false
+	}
+
+	for i := 0; i < h.size; i++ {
+		msg := h.history[(h.start+i)%len(h.history)]
+		if msg.ID > lastID && groups[msg.Event.GroupID] {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, true
+}
+
+// Groups returns the groups a subscription currently receives
+func (h *Hub) Groups(sub *Subscription) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	groups := make([]string, 0, len(sub.groups))
+	for id := range sub.groups {
+		groups = append(groups, id)
+	}
+	return groups
+}
+
+// join adds a group to every subscription of a user who was just added to it, apart from
+// subscriptions to a single group
+func (h *Hub) join(userID, groupID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.byUser[userID] {
+		if !sub.fixed && !sub.groups[groupID] {
+			sub.groups[groupID] = true
+			h.add(h.byGroup, groupID, sub)
+		}
+	}
+}
+
+// add indexes a subscription, the caller holds the lock
+func (h *Hub) add(index map[string]map[*Subscription]bool, key string, sub *Subscription) {
+	subs, ok := index[key]
+	if !ok {
+		subs = make(map[*Subscription]bool)
+		index[key] = subs
+	}
+	subs[sub] = true
+}
+
+// remove drops a subscription from the indexes and closes it, the caller holds the lock
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+
+	for id := range sub.groups {
+		delete(h.byGroup[id], sub)
+		if len(h.byGroup[id]) == 0 {
+			delete(h.byGroup, id)
+		}
+	}
+	delete(h.byUser[sub.userID], sub)
+	if len(h.byUser[sub.userID]) == 0 {
+		delete(h.byUser, sub.userID)
+	}
+	close(sub.c)
+}
+
+// username resolves a user for a message
+func (h *Hub) username(userID string) string {
+	u, err := h.store.Users().GetByID(userID)
+	if err != nil || u == nil {
+		return "Unknown"
+	}
+	return u.Username
+}
@@ -0,0 +1,69 @@
+// Package reminisce schedules quotes to resurface at growing intervals using SM-2
+package reminisce
+
+import (
+	"math"
+	"time"
+)
+
+// Grades run from 0 (forgot it entirely) to 5 (remembered it perfectly).
+// Anything below PassGrade starts the quote's intervals over.
+const (
+	MinGrade  = 0
+	MaxGrade  = 5
+	PassGrade = 3
+)
+
+// DefaultEase is the ease factor a newly queued quote starts with
+const DefaultEase = 2.5
+
+// minEase stops a quote graded poorly many times from coming back every day forever
+const minEase = 1.3
+
+// Schedule is where a quote stands in a user's queue
+type Schedule struct {
+	Ease        float64
+	Interval    int // Days until the quote is due again
+	Repetitions int // Passing grades in a row
+}
+
+// Review applies a grade to a schedule and returns the next one
+func Review(s Schedule, grade int) Schedule {
+	if grade < MinGrade {
+		grade = MinGrade
+	}
+	if grade > MaxGrade {
+		grade = MaxGrade
+	}
+	if s.Ease == 0 {
+		s.Ease = DefaultEase
+	}
+
+	if grade < PassGrade {
+		s.Repetitions = 0
+		s.Interval = 1
+	} else {
+		switch s.Repetitions {
+		case 0:
+			s.Interval = 1
+		case 1:
+			s.Interval = 6
+		default:
+			s.Interval = int(math.Round(float64(s.Interval) * s.Ease))
+		}
+		s.Repetitions++
+	}
+
+	miss := float64(MaxGrade - grade)
+	s.Ease += 0.1 - miss*(0.08+miss*0.02)
+	if s.Ease < minEase {
+		s.Ease = minEase
+	}
+
+	return s
+}
+
+// Due returns when a quote reviewed at reviewedAt comes back
+func (s Schedule) Due(reviewedAt time.Time) time.Time {
+	return reviewedAt.AddDate(0, 0, s.Interval)
+}
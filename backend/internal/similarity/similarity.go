@@ -0,0 +1,184 @@
+package similarity
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DuplicateThreshold is the similarity above which two quotes are considered likely duplicates
+const DuplicateThreshold = 0.85
+
+// Normalize folds text into a canonical form for comparison.
+// It applies Unicode NFKC, lowercases, turns punctuation and symbols into word breaks and collapses whitespace.
+func Normalize(text string) string {
+	text = norm.NFKC.String(text)
+
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		case r == '\'' || r == '’':
+			// Drop apostrophes so contractions compare as one word
+		default:
+			space = true
+		}
+	}
+
+	return b.String()
+}
+
+// levenshteinWithin computes the edit distance between two rune slices,
+// giving up as soon as it is certain to exceed max
+func levenshteinWithin(a, b []rune, max int) (int, bool) {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return 0, false
+		}
+		prev, curr = curr, prev
+	}
+
+	if prev[len(b)] > max {
+		return 0, false
+	}
+	return prev[len(b)], true
+}
+
+// Match is an indexed text that closely resembles the text being checked
+type Match struct {
+	Index int
+	Score float64
+}
+
+// gramSize is the length of the character n-grams used to shortlist candidates
+const gramSize = 3
+
+// Index holds normalized texts so many lookups can be made against the same set.
+// Texts are shortlisted through shared trigrams before the edit distance is computed.
+type Index struct {
+	normalized [][]rune
+	grams      map[string][]int
+}
+
+// NewIndex builds an index over the given texts; match indices refer to positions in texts
+func NewIndex(texts []string) *Index {
+	idx := &Index{
+		normalized: make([][]rune, 0, len(texts)),
+		grams:      make(map[string][]int),
+	}
+	for _, t := range texts {
+		idx.Add(t)
+	}
+	return idx
+}
+
+// Add appends a text to the index
+func (idx *Index) Add(text string) {
+	normalized := []rune(Normalize(text))
+	i := len(idx.normalized)
+	idx.normalized = append(idx.normalized, normalized)
+	for gram := range trigrams(normalized) {
+		idx.grams[gram] = append(idx.grams[gram], i)
+	}
+}
+
+// Find returns the indexed texts whose similarity to text reaches DuplicateThreshold, most similar first
+func (idx *Index) Find(text string) []Match {
+	target := []rune(Normalize(text))
+	targetGrams := trigrams(target)
+
+	shared := make([]int, len(idx.normalized))
+	var touched []int
+	for gram := range targetGrams {
+		for _, i := range idx.grams[gram] {
+			if shared[i] == 0 {
+				touched = append(touched, i)
+			}
+			shared[i]++
+		}
+	}
+
+	var matches []Match
+	for _, i := range touched {
+		candidate := idx.normalized[i]
+		shortest, longest := len(target), len(candidate)
+		if shortest > longest {
+			shortest, longest = longest, shortest
+		}
+
+		// The length difference alone bounds the ratio, so skip the edit distance when it can't match
+		if longest > 0 && float64(shortest)/float64(longest) < DuplicateThreshold {
+			continue
+		}
+
+		// Each edit removes at most gramSize of the target's distinct grams,
+		// so too few shared grams means the distance must exceed the threshold
+		maxEdits := int((1 - DuplicateThreshold) * float64(longest))
+		if shared[i] < len(targetGrams)-gramSize*maxEdits {
+			continue
+		}
+
+		score := 1.0
+		if longest > 0 {
+			distance, ok := levenshteinWithin(target, candidate, maxEdits)
+			if !ok {
+				continue
+			}
+			score = 1 - float64(distance)/float64(longest)
+		}
+		if score >= DuplicateThreshold {
+			matches = append(matches, Match{Index: i, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Index < matches[j].Index
+	})
+
+	return matches
+}
+
+// trigrams returns the distinct character trigrams of a padded string
+func trigrams(text []rune) map[string]bool {
+	padded := make([]rune, 0, len(text)+2*(gramSize-1))
+	for i := 0; i < gramSize-1; i++ {
+		padded = append(padded, 0)
+	}
+	padded = append(padded, text...)
+	for i := 0; i < gramSize-1; i++ {
+		padded = append(padded, 0)
+	}
+
+	grams := make(map[string]bool, len(padded))
+	for i := 0; i+gramSize <= len(padded); i++ {
+		grams[string(padded[i:i+gramSize])] = true
+	}
+	return grams
+}
@@ -0,0 +1,91 @@
+package slack
+
+import "strings"
+
+// Response types for replies to commands
+const (
+	ResponseEphemeral = "ephemeral"  // Only the user who ran the command sees the reply
+	ResponseInChannel = "in_channel" // Everyone in the channel sees the reply
+)
+
+// Message is a reply to a command or interaction, see https://api.slack.com/block-kit
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	DeleteOriginal  bool    `json:"delete_original,omitempty"`
+	Text            string  `json:"text"` // Shown in notifications and by clients that can't render blocks
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+// Block is a Block Kit layout block. Which fields are set depends on its type.
+type Block struct {
+	Type     string    `json:"type"` // section, context or actions
+	BlockID  string    `json:"block_id,omitempty"`
+	Text     *Text     `json:"text,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+}
+
+// Text is a Block Kit text object
+type Text struct {
+	Type string `json:"type"` // mrkdwn or plain_text
+	Text string `json:"text"`
+}
+
+// Element is an element of a context or actions block, either text or a button
+type Element struct {
+	Type     string      `json:"type"` // mrkdwn or button
+	Text     interface{} `json:"text,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	Style    string      `json:"style,omitempty"` // primary or danger for buttons
+}
+
+// Ephemeral returns a plain reply only the user who ran the command sees
+func Ephemeral(text string) *Message {
+	return &Message{
+		ResponseType: ResponseEphemeral,
+		Text:         text,
+		Blocks:       []Block{Section(text)},
+	}
+}
+
+// Section returns a block of mrkdwn text
+func Section(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}}
+}
+
+// Context returns a block of small print
+func Context(text string) Block {
+	return Block{Type: "context", Elements: []Element{{Type: "mrkdwn", Text: text}}}
+}
+
+// Actions returns a block of buttons
+func Actions(buttons ...Element) Block {
+	return Block{Type: "actions", Elements: buttons}
+}
+
+// Button returns a button that sends actionID and value back as an interaction when clicked
+func Button(label, actionID, value, style string) Element {
+	return Element{
+		Type:     "button",
+		Text:     &Text{Type: "plain_text", Text: label},
+		ActionID: actionID,
+		Value:    value,
+		Style:    style,
+	}
+}
+
+// Quote formats a quote as a mrkdwn block quote with its attribution
+func Quote(text, author string) string {
+	lines := strings.Split(Escape(text), "\n")
+	quoted := "> " + strings.Join(lines, "\n> ")
+	if author == "" {
+		return quoted
+	}
+	return quoted + "\n— *" + Escape(author) + "*"
+}
+
+// Escape escapes the characters Slack treats as control characters in message text
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,69 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Command is a slash command invocation
+type Command struct {
+	TeamID      string
+	ChannelID   string
+	UserID      string
+	UserName    string
+	Command     string // e.g. "/quote"
+	Text        string // Everything typed after the command
+	ResponseURL string
+}
+
+// ParseCommand reads a slash command from its form encoded body
+func ParseCommand(form url.Values) *Command {
+	return &Command{
+		TeamID:      form.Get("team_id"),
+		ChannelID:   form.Get("channel_id"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		Command:     form.Get("command"),
+		Text:        strings.TrimSpace(form.Get("text")),
+		ResponseURL: form.Get("response_url"),
+	}
+}
+
+// Interaction is a click on an interactive component of a message the app sent
+type Interaction struct {
+	Type string `json:"type"` // Only "block_actions" is handled
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Actions     []Action `json:"actions"`
+	ResponseURL string   `json:"response_url"`
+}
+
+// Action is the component that was clicked
+type Action struct {
+	ActionID string `json:"action_id"`
+	Value    string `json:"value"`
+}
+
+// ParseInteraction reads an interaction from its form encoded body, which carries it as JSON in "payload"
+func ParseInteraction(form url.Values) (*Interaction, error) {
+	payload := form.Get("payload")
+	if payload == "" {
+		return nil, errors.New("slack: interaction payload missing")
+	}
+
+	var i Interaction
+	if err := json.Unmarshal([]byte(payload), &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
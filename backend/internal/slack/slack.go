@@ -0,0 +1,106 @@
+// Package slack speaks Slack's slash command and interactivity protocols: verifying request
+// signatures, parsing payloads and building Block Kit replies
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Platform identifies Slack among linked chat accounts and channels
+const Platform = "slack"
+
+// Request headers Slack signs its requests with
+const (
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+	HeaderSignature = "X-Slack-Signature"
+)
+
+// MaxClockSkew is how old a request can be before it is rejected as a possible replay
+const MaxClockSkew = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned for requests that weren't signed with the signing secret
+	ErrInvalidSignature = errors.New("slack: invalid request signature")
+
+	// ErrStaleRequest is returned for requests signed too long ago
+	ErrStaleRequest = errors.New("slack: request timestamp out of range")
+)
+
+// Verifier checks that requests come from Slack
+type Verifier struct {
+	Secret string // The app's signing secret
+
+	// Now returns the current time, time.Now if nil. Recorded requests can be replayed by pinning it.
+	Now func() time.Time
+}
+
+// Sign returns the signature Slack sends for a body with the given timestamp: "v0=" and the hex
+// HMAC-SHA256 of "v0:timestamp:body" under the signing secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request's signature headers against its raw body
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	timestamp := header.Get(HeaderTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrStaleRequest
+	}
+
+	expected := Sign(v.Secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(HeaderSignature))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Respond posts a message to a response_url Slack handed out with a command or interaction
+func Respond(ctx context.Context, client *http.Client, responseURL string, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack: response_url returned %s", resp.Status)
+	}
+	return nil
+}
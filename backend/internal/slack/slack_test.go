@@ -0,0 +1,179 @@
+package slack
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testSecret is the signing secret the requests in testdata were signed with. signed.http is
+// the example request from Slack's request verification docs. The others are a /quote command
+// and a button click in the shape Slack sends them, signed with the same secret.
+const testSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// readRequest loads a recorded request, returning its headers, raw body and the time it was signed
+func readRequest(t *testing.T, name string) (http.Header, []byte, time.Time) {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	req, err := http.ReadRequest(bufio.NewReader(f))
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("reading %s body: %v", name, err)
+	}
+
+	ts, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("%s has no timestamp: %v", name, err)
+	}
+	return req.Header, body, time.Unix(ts, 0)
+}
+
+// verifierAt returns a verifier for the test secret whose clock reads at
+func verifierAt(at time.Time) *Verifier {
+	return &Verifier{Secret: testSecret, Now: func() time.Time { return at }}
+}
+
+func TestVerifyValidSignature(t *testing.T) {
+	for _, name := range []string{"signed.http", "quote_command.http", "save_interaction.http"} {
+		header, body, signedAt := readRequest(t, name)
+		if err := verifierAt(signedAt.Add(2*time.Second)).Verify(header, body); err != nil {
+			t.Errorf("%s: Verify() = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestVerifyBadSignature(t *testing.T) {
+	header, body, signedAt := readRequest(t, "signed.http")
+	v := verifierAt(signedAt)
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-1] ^= 1
+	if err := v.Verify(header, tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered body: Verify() = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	wrongSecret := &Verifier{Secret: "not-the-signing-secret", Now: v.Now}
+	if err := wrongSecret.Verify(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong secret: Verify() = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	unsigned := header.Clone()
+	unsigned.Del(HeaderSignature)
+	if err := v.Verify(unsigned, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("missing signature: Verify() = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	// Re-signing under a later timestamp needs the secret, so moving the timestamp breaks the signature
+	moved := header.Clone()
+	moved.Set(HeaderTimestamp, strconv.FormatInt(signedAt.Unix()+1, 10))
+	if err := v.Verify(moved, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("moved timestamp: Verify() = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestVerifyStaleTimestamp(t *testing.T) {
+	header, body, signedAt := readRequest(t, "signed.http")
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want error
+	}{
+		{"just inside the window", signedAt.Add(MaxClockSkew), nil},
+		{"replayed later", signedAt.Add(MaxClockSkew + time.Second), ErrStaleRequest},
+		{"replayed days later", signedAt.Add(72 * time.Hour), ErrStaleRequest},
+		{"from the future", signedAt.Add(-MaxClockSkew - time.Second), ErrStaleRequest},
+	}
+
+	for _, tt := range tests {
+		if err := verifierAt(tt.now).Verify(header, body); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Verify() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseQuoteCommand(t *testing.T) {
+	header, body, signedAt := readRequest(t, "quote_command.http")
+	if err := verifierAt(signedAt).Verify(header, body); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := ParseCommand(form)
+
+	want := Command{
+		TeamID:      "T1DC2JH3J",
+		ChannelID:   "C05QWJ2LM1K",
+		UserID:      "U2CERLKJA",
+		UserName:    "roadrunner",
+		Command:     "/quote",
+		Text:        "“I never said I was fast” - Wile E.",
+		ResponseURL: "https://hooks.slack.com/commands/T1DC2JH3J/5910246135461/dGhpcyBpcyBub3QgcmVhbA",
+	}
+	if *cmd != want {
+		t.Errorf("ParseCommand() = %+v, want %+v", *cmd, want)
+	}
+}
+
+func TestParseSaveInteraction(t *testing.T) {
+	header, body, signedAt := readRequest(t, "save_interaction.http")
+	if err := verifierAt(signedAt).Verify(header, body); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := ParseInteraction(form)
+	if err != nil {
+		t.Fatalf("ParseInteraction() = %v", err)
+	}
+
+	if i.Type != "block_actions" || i.Team.ID != "T1DC2JH3J" || i.User.ID != "U2CERLKJA" || i.Channel.ID != "C05QWJ2LM1K" {
+		t.Errorf("ParseInteraction() = type %q team %q user %q channel %q", i.Type, i.Team.ID, i.User.ID, i.Channel.ID)
+	}
+	if i.ResponseURL != "https://hooks.slack.com/actions/T1DC2JH3J/5910246135512/bm90IGEgcmVhbCB1cmw" {
+		t.Errorf("ResponseURL = %q", i.ResponseURL)
+	}
+	if len(i.Actions) != 1 || i.Actions[0].ActionID != "save_quote" {
+		t.Fatalf("Actions = %+v, want one save_quote action", i.Actions)
+	}
+
+	var draft struct {
+		Text   string `json:"text"`
+		Author string `json:"author"`
+	}
+	if err := json.Unmarshal([]byte(i.Actions[0].Value), &draft); err != nil {
+		t.Fatalf("action value isn't JSON: %v", err)
+	}
+	if draft.Text != "I never said I was fast" || draft.Author != "Wile E." {
+		t.Errorf("action value = %+v", draft)
+	}
+}
+
+func TestParseInteractionMissingPayload(t *testing.T) {
+	if _, err := ParseInteraction(url.Values{"token": {"xyzz0WbapA4vBCDEFasx0q6G"}}); err == nil {
+		t.Error("ParseInteraction() without a payload = nil error")
+	}
+}
@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Bot API's address
+const DefaultAPIURL = "https://api.telegram.org"
+
+// Client calls the Bot API as one bot
+type Client struct {
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient creates a client for the bot with the given token. baseURL defaults to the real
+// Bot API and can point at a local stand-in, httpClient defaults to one with a short timeout.
+func NewClient(token, baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    httpClient,
+	}
+}
+
+// Error is a request the Bot API refused
+type Error struct {
+	Code        int
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+// OutgoingMessage is a message for the bot to send, with HTML formatting
+type OutgoingMessage struct {
+	ChatID           int64  `json:"chat_id"`
+	Text             string `json:"text"`
+	ParseMode        string `json:"parse_mode,omitempty"`
+	ReplyToMessageID int64  `json:"reply_to_message_id,omitempty"`
+}
+
+// SendMessage sends a message, formatted as HTML
+func (c *Client) SendMessage(ctx context.Context, msg *OutgoingMessage) error {
+	if msg.ParseMode == "" {
+		msg.ParseMode = "HTML"
+	}
+	return c.call(ctx, "sendMessage", msg)
+}
+
+// SetWebhook registers the URL Telegram sends updates to, along with the secret it sends with them
+func (c *Client) SetWebhook(ctx context.Context, webhookURL, secret string) error {
+	return c.call(ctx, "setWebhook", map[string]interface{}{
+		"url":             webhookURL,
+		"secret_token":    secret,
+		"allowed_updates": []string{"message"},
+	})
+}
+
+// call invokes a Bot API method with a JSON body
+func (c *Client) call(ctx context.Context, method string, params interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The URL holds the bot token, which mustn't end up in logs
+		return fmt.Errorf("telegram: %s failed: %w", method, unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram: %s returned %s", method, resp.Status)
+	}
+	if !result.OK {
+		return &Error{Code: result.ErrorCode, Description: result.Description}
+	}
+
+	return nil
+}
+
+// unwrapURLError drops the request URL from a transport error
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
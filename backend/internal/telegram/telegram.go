@@ -0,0 +1,79 @@
+// Package telegram receives updates from the Telegram Bot API's webhook and sends messages back
+// through the Bot API
+package telegram
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Platform identifies Telegram among linked chat accounts and channels
+const Platform = "telegram"
+
+// HeaderSecretToken carries the secret the webhook was registered with on every update
+const HeaderSecretToken = "X-Telegram-Bot-Api-Secret-Token"
+
+// Update is an incoming update, only messages are handled
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message is a message in a chat
+type Message struct {
+	MessageID      int64    `json:"message_id"`
+	From           *User    `json:"from"`
+	Chat           Chat     `json:"chat"`
+	Date           int64    `json:"date"`
+	Text           string   `json:"text"`
+	Caption        string   `json:"caption"` // Text sent along with a photo or other media
+	ReplyToMessage *Message `json:"reply_to_message"`
+}
+
+// Content returns the message's text, or its caption for media messages
+func (m *Message) Content() string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.Caption
+}
+
+// User is a Telegram user or bot
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+}
+
+// Name returns the user's name as Telegram shows it
+func (u *User) Name() string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// Chat is a private chat, group or channel
+type Chat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"` // private, group, supergroup or channel
+	Title string `json:"title"`
+}
+
+// VerifySecret reports whether a webhook request carries the secret the webhook was registered with
+func VerifySecret(header http.Header, secret string) bool {
+	got := header.Get(HeaderSecretToken)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+// ParseCommand splits a message like "/quote@ReminiscerBot anyway" into the command, lowercased
+// and without the bot's name, and its arguments. ok is false if the message isn't a command.
+func ParseCommand(text string) (command, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	command, args, _ = strings.Cut(text, " ")
+	command, _, _ = strings.Cut(command[1:], "@")
+	return strings.ToLower(command), strings.TrimSpace(args), command != ""
+}
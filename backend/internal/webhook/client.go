@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a webhook URL resolves to an address on the server's own network
+var ErrBlockedAddress = errors.New("webhook address is not publicly routable")
+
+// NewClient returns the HTTP client deliveries are sent with.
+//
+// Webhook URLs come from group members, so unless allowPrivate is set the client refuses to
+// connect to loopback, private, link-local and other non-public addresses. The check runs on
+// the address being dialled, after DNS resolution, so a hostname can't be pointed at the
+// server's own network. Redirects aren't followed, a redirect counts as a failed delivery.
+func NewClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// No proxy, the guard must see the receiver's address rather than a proxy's
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivate is a net.Dialer Control hook rejecting connections to non-public addresses
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+
+	if !Routable(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by netip's IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Routable reports whether addr is a public unicast address deliveries may be sent to
+func Routable(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(),
+		addr.IsUnspecified(),
+		addr.IsLoopback(),
+		addr.IsPrivate(),
+		addr.IsLinkLocalUnicast(),
+		addr.IsLinkLocalMulticast(),
+		addr.IsInterfaceLocalMulticast(),
+		addr.IsMulticast(),
+		sharedAddressSpace.Contains(addr):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/jamoowen/reminiscer/internal/events"
+)
+
+// QuoteData describes a quote in a payload. Sealed time capsules are sent without their text.
+type QuoteData struct {
+	ID         string     `json:"id"`
+	Text       string     `json:"text"`
+	Author     string     `json:"author,omitempty"`
+	UploaderID string     `json:"uploader_id"`
+	Uploader   string     `json:"uploader"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UnlockAt   *time.Time `json:"unlock_at,omitempty"`
+	Sealed     bool       `json:"sealed,omitempty"`
+}
+
+// MemberData describes a group member in a payload
+type MemberData struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// QuoteEventData is the payload data for quote and capsule events
+type QuoteEventData struct {
+	Quote *QuoteData  `json:"quote"`
+	Actor *MemberData `json:"actor,omitempty"`
+}
+
+// MemberJoinedData is the payload data for member.joined
+type MemberJoinedData struct {
+	Member    *MemberData `json:"member"`
+	InvitedBy *MemberData `json:"invited_by,omitempty"`
+}
+
+// ReactionData is the payload data for reaction.added
+type ReactionData struct {
+	Quote *QuoteData  `json:"quote"`
+	Actor *MemberData `json:"actor"`
+	Emoji string      `json:"emoji"`
+}
+
+// PingData is the payload data for the test event
+type PingData struct {
+	Message string      `json:"message"`
+	Actor   *MemberData `json:"actor"`
+}
+
+// eventData builds the data for an event's payload
+func (d *Dispatcher) eventData(e events.Event) (interface{}, error) {
+	switch e.Type {
+	case events.GroupInvite:
+		return &MemberJoinedData{Member: d.member(e.UserID), InvitedBy: d.member(e.ActorID)}, nil
+
+	case events.ReactionAdded:
+		quote, err := d.quote(e.QuoteID)
+		if err != nil {
+			return nil, err
+		}
+		return &ReactionData{Quote: quote, Actor: d.member(e.ActorID), Emoji: e.Emoji}, nil
+
+	default:
+		quote, err := d.quote(e.QuoteID)
+		if err != nil {
+			return nil, err
+		}
+		return &QuoteEventData{Quote: quote, Actor: d.member(e.ActorID)}, nil
+	}
+}
+
+// quote loads a quote for a payload
+func (d *Dispatcher) quote(id string) (*QuoteData, error) {
+	q, err := d.store.Quotes().GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &QuoteData{
+		ID:         q.ID,
+		Text:       q.Text,
+		Author:     q.Author,
+		UploaderID: q.UploaderID,
+		Uploader:   d.member(q.UploaderID).Username,
+		CreatedAt:  q.CreatedAt,
+		UpdatedAt:  q.UpdatedAt,
+		UnlockAt:   q.UnlockAt,
+	}
+	if q.Sealed(time.Now()) {
+		data.Text = ""
+		data.Sealed = true
+	}
+	return data, nil
+}
+
+// member describes a user for a payload, nil for events the server raised itself
+func (d *Dispatcher) member(userID string) *MemberData {
+	if userID == "" {
+		return nil
+	}
+	u, err := d.store.Users().GetByID(userID)
+	if err != nil || u == nil {
+		return &MemberData{UserID: userID, Username: "Unknown"}
+	}
+	return &MemberData{UserID: u.ID, Username: u.Username}
+}
+
+// Ping builds the data for a test event sent by a member
+func (d *Dispatcher) Ping(userID string) interface{} {
+	return &PingData{Message: "Test event from Reminiscer", Actor: d.member(userID)}
+}
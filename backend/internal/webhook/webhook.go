@@ -0,0 +1,304 @@
+// Package webhook delivers group events to the URLs groups subscribed, signed and retried in the background
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamoowen/reminiscer/internal/events"
+	"github.com/jamoowen/reminiscer/internal/models"
+)
+
+// Event names webhooks can subscribe to
+const (
+	EventQuoteCreated    = "quote.created"
+	EventQuoteUpdated    = "quote.updated"
+	EventMemberJoined    = "member.joined"
+	EventReactionAdded   = "reaction.added"
+	EventCapsuleUnlocked = "capsule.unlocked"
+
+	// EventPing is sent by the "send test event" endpoint whatever the webhook subscribed to
+	EventPing = "ping"
+)
+
+// Events lists every event a webhook can subscribe to
+var Events = []string{EventQuoteCreated, EventQuoteUpdated, EventMemberJoined, EventReactionAdded, EventCapsuleUnlocked}
+
+// eventNames maps the internal events that webhooks hear about to their public names
+var eventNames = map[events.Type]string{
+	events.QuoteCreated:    EventQuoteCreated,
+	events.QuoteUpdated:    EventQuoteUpdated,
+	events.GroupInvite:     EventMemberJoined,
+	events.ReactionAdded:   EventReactionAdded,
+	events.CapsuleUnlocked: EventCapsuleUnlocked,
+}
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Reminiscer-Event"
+	HeaderDelivery  = "X-Reminiscer-Delivery"
+	HeaderTimestamp = "X-Reminiscer-Timestamp"
+	HeaderSignature = "X-Reminiscer-Signature"
+)
+
+const (
+	// DefaultInterval is how often the dispatcher looks for deliveries due a retry
+	DefaultInterval = 15 * time.Second
+
+	// MaxAttempts is how many times a delivery is tried before it is marked failed
+	MaxAttempts = 10
+
+	// firstBackoff is the wait before the first retry, doubled for each one after up to maxBackoff
+	firstBackoff = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+
+	// batchSize is how many due deliveries are sent per check
+	batchSize = 50
+)
+
+// ValidEvent reports whether a webhook can subscribe to name
+func ValidEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" under the webhook's secret.
+// Receivers recompute it to check a payload came from us, and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	GroupID   string      `json:"group_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher queues deliveries for group events and sends them in the background
+type Dispatcher struct {
+	store    models.Store
+	client   *http.Client
+	interval time.Duration
+	kick     chan struct{}
+}
+
+// NewDispatcher creates a dispatcher retrying failed deliveries every interval.
+// A nil client sends with NewClient, refusing non-public addresses.
+func NewDispatcher(store models.Store, client *http.Client, interval time.Duration) *Dispatcher {
+	if client == nil {
+		client = NewClient(false)
+	}
+	return &Dispatcher{
+		store:    store,
+		client:   client,
+		interval: interval,
+		kick:     make(chan struct{}, 1),
+	}
+}
+
+// Handle queues a delivery to every webhook of the event's group subscribed to it.
+// It is meant to be subscribed to an events.Bus.
+func (d *Dispatcher) Handle(e events.Event) {
+	name, ok := eventNames[e.Type]
+	if !ok {
+		return
+	}
+
+	webhooks, err := d.store.Webhooks().ListByGroup(e.GroupID)
+	if err != nil {
+		log.Printf("Failed to list webhooks for group %s: %v", e.GroupID, err)
+		return
+	}
+
+	var subscribed []*models.Webhook
+	for _, w := range webhooks {
+		if w.Wants(name) {
+			subscribed = append(subscribed, w)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	data, err := d.eventData(e)
+	if err != nil {
+		log.Printf("Failed to build %s webhook payload: %v", name, err)
+		return
+	}
+
+	for _, w := range subscribed {
+		if _, err := d.Enqueue(w, name, data); err != nil {
+			log.Printf("Failed to queue %s for webhook %s: %v", name, w.ID, err)
+		}
+	}
+}
+
+// Enqueue queues a payload for a webhook and wakes the dispatcher to send it
+func (d *Dispatcher) Enqueue(w *models.Webhook, event string, data interface{}) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{ID: uuid.New().String(), WebhookID: w.ID, Event: event}
+
+	body, err := json.Marshal(&Payload{
+		ID:        delivery.ID,
+		Event:     event,
+		GroupID:   w.GroupID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+
+	if err := d.store.Webhooks().CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+	return delivery, nil
+}
+
+// Run sends deliveries as they are queued or fall due for a retry, until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Check(ctx, time.Now()); err != nil {
+			log.Printf("Failed to send webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.kick:
+		}
+	}
+}
+
+// Check sends every delivery that is due
+func (d *Dispatcher) Check(ctx context.Context, now time.Time) error {
+	for {
+		due, err := d.store.Webhooks().DueDeliveries(now, batchSize)
+		if err != nil {
+			return err
+		}
+
+		webhooks := make(map[string]*models.Webhook)
+		for _, delivery := range due {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			w, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				w, err = d.store.Webhooks().GetByID(delivery.WebhookID)
+				if err != nil {
+					return err
+				}
+				webhooks[w.ID] = w
+			}
+
+			d.attempt(ctx, w, delivery)
+			if err := d.store.Webhooks().UpdateDelivery(delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(due) < batchSize {
+			return nil
+		}
+	}
+}
+
+// attempt sends a delivery once and updates it with the outcome, scheduling a retry if it failed
+func (d *Dispatcher) attempt(ctx context.Context, w *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	status, err := d.post(ctx, w, delivery)
+	delivery.ResponseStatus = status
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(Backoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+}
+
+// post sends the payload, returning the response status if the receiver answered
+func (d *Dispatcher) post(ctx context.Context, w *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Reminiscer-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(w.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Backoff is how long to wait after a delivery's nth failed attempt
+func Backoff(attempts int) time.Duration {
+	wait := firstBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
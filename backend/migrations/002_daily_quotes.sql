@@ -0,0 +1,15 @@
+-- Group timezone used to decide when a new day starts
+ALTER TABLE groups ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- Daily quotes table
+CREATE TABLE IF NOT EXISTS daily_quotes (
+    group_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    quote_id TEXT NOT NULL,
+    cycle INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, day),
+    FOREIGN KEY (quote_id) REFERENCES quotes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_quotes_cycle ON daily_quotes(group_id, cycle);
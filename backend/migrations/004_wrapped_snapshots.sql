@@ -0,0 +1,14 @@
+-- Shareable year-in-review snapshots
+CREATE TABLE IF NOT EXISTS wrapped_snapshots (
+    id TEXT PRIMARY KEY,
+    token TEXT UNIQUE NOT NULL,
+    group_id TEXT,
+    user_id TEXT,
+    year INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_wrapped_snapshots_creator ON wrapped_snapshots(created_by);
@@ -0,0 +1,17 @@
+-- Bulk imports, kept so a batch of quotes can be undone
+CREATE TABLE IF NOT EXISTS imports (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    source TEXT NOT NULL,
+    quote_count INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    undone_at DATETIME,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_imports_group ON imports(group_id);
+
+ALTER TABLE quotes ADD COLUMN import_id TEXT REFERENCES imports(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_quotes_import ON quotes(import_id);
@@ -0,0 +1,13 @@
+-- Parsed chat exports waiting for the user to pick messages to import
+CREATE TABLE IF NOT EXISTS import_previews (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    source TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_previews_expires ON import_previews(expires_at);
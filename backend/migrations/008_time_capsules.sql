@@ -0,0 +1,6 @@
+-- Time capsules stay sealed until unlock_at. unlock_notified_at records when
+-- the group was told a capsule opened so it is only announced once.
+ALTER TABLE quotes ADD COLUMN unlock_at DATETIME;
+ALTER TABLE quotes ADD COLUMN unlock_notified_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_quotes_unlock ON quotes(unlock_at);
@@ -0,0 +1,32 @@
+-- "Who said it?" rounds. The answer stays on the server until the round is answered.
+CREATE TABLE IF NOT EXISTS quiz_rounds (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    quote_id TEXT NOT NULL,
+    choices TEXT NOT NULL, -- JSON array of authors in the order they are offered
+    answer TEXT NOT NULL,
+    chosen TEXT,
+    correct BOOLEAN NOT NULL DEFAULT 0,
+    points INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    answered_at DATETIME,
+    FOREIGN KEY (quote_id) REFERENCES quotes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_rounds_player ON quiz_rounds(group_id, user_id, answered_at);
+
+-- Running totals per player and group
+CREATE TABLE IF NOT EXISTS quiz_scores (
+    group_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    points INTEGER NOT NULL DEFAULT 0,
+    answered INTEGER NOT NULL DEFAULT 0,
+    correct INTEGER NOT NULL DEFAULT 0,
+    streak INTEGER NOT NULL DEFAULT 0,
+    best_streak INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME,
+    PRIMARY KEY (group_id, user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,30 @@
+-- Emoji reactions to quotes, one of each emoji per user
+CREATE TABLE IF NOT EXISTS quote_reactions (
+    quote_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    emoji TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quote_id, user_id, emoji),
+    FOREIGN KEY (quote_id) REFERENCES quotes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_reactions_user ON quote_reactions(user_id);
+
+-- Per-user spaced repetition schedule (SM-2) for quotes they loved or reacted to
+CREATE TABLE IF NOT EXISTS reminisce_items (
+    user_id TEXT NOT NULL,
+    quote_id TEXT NOT NULL,
+    loved BOOLEAN NOT NULL DEFAULT 0,
+    ease REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    reviewed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, quote_id),
+    FOREIGN KEY (quote_id) REFERENCES quotes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminisce_due ON reminisce_items(user_id, due_at);
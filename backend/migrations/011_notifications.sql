@@ -0,0 +1,29 @@
+-- In-app notification inbox
+CREATE TABLE IF NOT EXISTS notifications (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    group_id TEXT NOT NULL,
+    actor_id TEXT,
+    quote_id TEXT,
+    message TEXT NOT NULL,
+    read_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id, read_at);
+
+-- What each member wants to hear about from a group. Missing rows mean everything is on.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id TEXT NOT NULL,
+    group_id TEXT NOT NULL,
+    quotes BOOLEAN NOT NULL DEFAULT 1,
+    mentions BOOLEAN NOT NULL DEFAULT 1,
+    reactions BOOLEAN NOT NULL DEFAULT 1,
+    capsules BOOLEAN NOT NULL DEFAULT 1,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- Weekly email digest state. Users get the digest until they unsubscribe, rows are
+-- created the first time a digest is sent or an unsubscribe link is minted.
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id TEXT PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    unsubscribed_at DATETIME,
+    last_sent_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
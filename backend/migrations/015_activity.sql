@@ -0,0 +1,14 @@
+-- Append-only log of what happened in each group, read back as the activity feed
+CREATE TABLE IF NOT EXISTS activities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    actor_id TEXT,
+    quote_id TEXT,
+    user_id TEXT, -- Member the activity is about, e.g. who joined
+    emoji TEXT,
+    name TEXT, -- Group name, for creations and renames
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activities_group ON activities(group_id, id);
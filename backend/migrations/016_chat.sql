@@ -0,0 +1,32 @@
+-- Accounts on chat platforms (Slack, ...) linked to Reminiscer users
+CREATE TABLE IF NOT EXISTS chat_accounts (
+    platform TEXT NOT NULL,
+    team_id TEXT NOT NULL, -- Workspace the account belongs to, empty on platforms without one
+    external_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (platform, team_id, external_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_accounts_user ON chat_accounts(user_id);
+
+-- Chat channels connected to the group their quotes go to
+CREATE TABLE IF NOT EXISTS chat_channels (
+    platform TEXT NOT NULL,
+    team_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+    group_id TEXT NOT NULL,
+    linked_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (platform, team_id, channel_id),
+    FOREIGN KEY (linked_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Short lived codes a user types into a chat platform to link their account
+CREATE TABLE IF NOT EXISTS chat_link_codes (
+    code TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
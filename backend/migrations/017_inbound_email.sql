@@ -0,0 +1,13 @@
+-- Secret tokens in the addresses groups receive quotes by email at
+CREATE TABLE IF NOT EXISTS inbound_addresses (
+    group_id TEXT PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Emails already turned into quotes, so a relay retrying a delivery doesn't add the quote twice
+CREATE TABLE IF NOT EXISTS inbound_messages (
+    message_id TEXT PRIMARY KEY,
+    quote_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Private feed URLs members made for reading a group in a feed reader, the token is the only credential
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    id TEXT PRIMARY KEY,
+    token TEXT UNIQUE NOT NULL,
+    group_id TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_creator ON feed_tokens(created_by);
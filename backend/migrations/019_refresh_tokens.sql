@@ -0,0 +1,16 @@
+-- Long lived tokens traded for new access tokens. Only a hash of each is kept, and every use
+-- replaces the token with the next in its family, the chain descended from a single login.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id TEXT PRIMARY KEY,
+    token_hash TEXT UNIQUE NOT NULL,
+    user_id TEXT NOT NULL,
+    family_id TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME, -- Set when traded in, a second use means the token was stolen
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id);
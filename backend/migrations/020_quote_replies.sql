@@ -0,0 +1,3 @@
+-- The message a quote was replying to, kept from chat imports for context
+ALTER TABLE quotes ADD COLUMN reply_author TEXT;
+ALTER TABLE quotes ADD COLUMN reply_text TEXT;
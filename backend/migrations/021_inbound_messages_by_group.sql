@@ -0,0 +1,16 @@
+-- Emails are remembered per group, so a Message-ID seen by one group says nothing to another
+CREATE TABLE inbound_messages_by_group (
+    group_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    quote_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, message_id)
+);
+
+INSERT INTO inbound_messages_by_group (group_id, message_id, quote_id, created_at)
+SELECT q.group_id, m.message_id, m.quote_id, m.created_at
+FROM inbound_messages m
+JOIN quotes q ON q.id = m.quote_id;
+
+DROP TABLE inbound_messages;
+ALTER TABLE inbound_messages_by_group RENAME TO inbound_messages;
@@ -0,0 +1,2 @@
+-- Who uploaded a quote where it was exported from, when an import could only credit the importer
+ALTER TABLE quotes ADD COLUMN original_uploader TEXT;